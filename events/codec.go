@@ -0,0 +1,249 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	eventspb "events/gen/go/events"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// HeaderContentType is the message header naming the encoding of the
+	// value. Messages without it are JSON.
+	HeaderContentType = "content-type"
+	// HeaderEventType is the message header carrying the event type, so
+	// consumers can route a message without relying on its key.
+	HeaderEventType = "event-type"
+	// HeaderEventID is the message header carrying the envelope id. It
+	// stays the same when a message is published again, so consumers can
+	// drop duplicates by it.
+	HeaderEventID = "event-id"
+
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+// Marshal encodes the envelope as contentType.
+func Marshal(envelope Envelope, contentType string) ([]byte, error) {
+	switch contentType {
+	case ContentTypeJSON:
+		data, err := json.Marshal(envelope)
+		if err != nil {
+			return nil, fmt.Errorf("marshal envelope: %w", err)
+		}
+		return data, nil
+	case ContentTypeProtobuf:
+		message, err := toProto(envelope)
+		if err != nil {
+			return nil, err
+		}
+		data, err := proto.Marshal(message)
+		if err != nil {
+			return nil, fmt.Errorf("marshal envelope: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+}
+
+// Unmarshal decodes a message value encoded as contentType. An empty
+// content type means JSON, which is what messages written before the
+// header existed use; key is passed on to Parse for them.
+func Unmarshal(contentType string, key string, value []byte) (Envelope, error) {
+	switch contentType {
+	case "", ContentTypeJSON:
+		return Parse(key, value)
+	case ContentTypeProtobuf:
+		var message eventspb.Envelope
+		if err := proto.Unmarshal(value, &message); err != nil {
+			return Envelope{}, fmt.Errorf("%w: unmarshal envelope: %w", ErrMalformed, err)
+		}
+		return fromProto(&message)
+	default:
+		return Envelope{}, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+}
+
+// ContentType validates the name of an encoding from configuration and
+// returns its content type.
+func ContentType(encoding string) (string, error) {
+	switch encoding {
+	case "", "json":
+		return ContentTypeJSON, nil
+	case "protobuf":
+		return ContentTypeProtobuf, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedContentType, encoding)
+	}
+}
+
+func toProto(envelope Envelope) (*eventspb.Envelope, error) {
+	message := &eventspb.Envelope{
+		Id:            envelope.ID,
+		Type:          envelope.Type,
+		Version:       int32(envelope.Version),
+		Producer:      envelope.Producer,
+		CorrelationId: envelope.CorrelationID,
+	}
+	if !envelope.OccurredAt.IsZero() {
+		message.OccurredAt = timestamppb.New(envelope.OccurredAt)
+	}
+
+	switch envelope.Type {
+	case TypeUserCreated:
+		var payload UserCreated
+		if err := envelope.Decode(&payload); err != nil {
+			return nil, err
+		}
+		message.Payload = &eventspb.Envelope_UserCreated{UserCreated: &eventspb.UserCreated{
+			Id:    payload.UserID,
+			Email: payload.Email,
+		}}
+	case TypeUserInvited:
+		var payload UserInvited
+		if err := envelope.Decode(&payload); err != nil {
+			return nil, err
+		}
+		message.Payload = &eventspb.Envelope_UserInvited{UserInvited: &eventspb.UserInvited{
+			Id:      payload.UserID,
+			Email:   payload.Email,
+			Name:    payload.Name,
+			Surname: payload.Surname,
+		}}
+	case TypeOrgMemberAdded:
+		var payload OrgMemberAdded
+		if err := envelope.Decode(&payload); err != nil {
+			return nil, err
+		}
+		message.Payload = &eventspb.Envelope_OrgMemberAdded{OrgMemberAdded: &eventspb.OrgMemberAdded{
+			OrgId:  payload.OrgID,
+			UserId: payload.UserID,
+			Role:   payload.Role,
+		}}
+	case TypeAppAccessGranted, TypeAppAccessRevoked:
+		var payload AppAccess
+		if err := envelope.Decode(&payload); err != nil {
+			return nil, err
+		}
+		message.Payload = &eventspb.Envelope_AppAccess{AppAccess: &eventspb.AppAccess{
+			AppId:  payload.AppID,
+			UserId: payload.UserID,
+		}}
+	case TypeUserSnapshot:
+		var payload UserSnapshot
+		if err := envelope.Decode(&payload); err != nil {
+			return nil, err
+		}
+		message.Payload = &eventspb.Envelope_UserSnapshot{UserSnapshot: &eventspb.UserSnapshot{
+			Id:     payload.UserID,
+			Email:  payload.Email,
+			Status: payload.Status,
+		}}
+	case TypeUserProfileUpdated:
+		var payload UserProfileUpdated
+		if err := envelope.Decode(&payload); err != nil {
+			return nil, err
+		}
+		message.Payload = &eventspb.Envelope_UserProfileUpdated{UserProfileUpdated: &eventspb.UserProfileUpdated{
+			UserId:     payload.UserID,
+			Changed:    payload.Changed,
+			Name:       payload.Name,
+			Surname:    payload.Surname,
+			AvatarHash: payload.AvatarHash,
+		}}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownType, envelope.Type)
+	}
+
+	return message, nil
+}
+
+func fromProto(message *eventspb.Envelope) (Envelope, error) {
+	envelope := Envelope{
+		ID:            message.GetId(),
+		Type:          message.GetType(),
+		Version:       int(message.GetVersion()),
+		Producer:      message.GetProducer(),
+		CorrelationID: message.GetCorrelationId(),
+	}
+	if message.GetOccurredAt() != nil {
+		envelope.OccurredAt = message.GetOccurredAt().AsTime()
+	}
+	if message.GetPayload() == nil {
+		return Envelope{}, fmt.Errorf("%w: no payload in %s event", ErrMalformed, envelope.Type)
+	}
+
+	var payload any
+	switch envelope.Type {
+	case TypeUserCreated:
+		p := message.GetUserCreated()
+		if p == nil {
+			return Envelope{}, payloadMismatch(message)
+		}
+		payload = UserCreated{UserID: p.GetId(), Email: p.GetEmail()}
+	case TypeUserInvited:
+		p := message.GetUserInvited()
+		if p == nil {
+			return Envelope{}, payloadMismatch(message)
+		}
+		payload = UserInvited{
+			UserID:  p.GetId(),
+			Email:   p.GetEmail(),
+			Name:    p.GetName(),
+			Surname: p.GetSurname(),
+		}
+	case TypeOrgMemberAdded:
+		p := message.GetOrgMemberAdded()
+		if p == nil {
+			return Envelope{}, payloadMismatch(message)
+		}
+		payload = OrgMemberAdded{OrgID: p.GetOrgId(), UserID: p.GetUserId(), Role: p.GetRole()}
+	case TypeAppAccessGranted, TypeAppAccessRevoked:
+		p := message.GetAppAccess()
+		if p == nil {
+			return Envelope{}, payloadMismatch(message)
+		}
+		payload = AppAccess{AppID: p.GetAppId(), UserID: p.GetUserId()}
+	case TypeUserSnapshot:
+		p := message.GetUserSnapshot()
+		if p == nil {
+			return Envelope{}, payloadMismatch(message)
+		}
+		payload = UserSnapshot{UserID: p.GetId(), Email: p.GetEmail(), Status: p.GetStatus()}
+	case TypeUserProfileUpdated:
+		p := message.GetUserProfileUpdated()
+		if p == nil {
+			return Envelope{}, payloadMismatch(message)
+		}
+		payload = UserProfileUpdated{
+			UserID:     p.GetUserId(),
+			Changed:    p.GetChanged(),
+			Name:       p.GetName(),
+			Surname:    p.GetSurname(),
+			AvatarHash: p.GetAvatarHash(),
+		}
+	default:
+		return Envelope{}, fmt.Errorf("%w: %q", ErrUnknownType, envelope.Type)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("marshal payload: %w", err)
+	}
+	envelope.Payload = data
+
+	return envelope, nil
+}
+
+// payloadMismatch reports an envelope whose payload doesn't match its
+// type, which would otherwise reach the handler of another event.
+func payloadMismatch(message *eventspb.Envelope) error {
+	return fmt.Errorf("%w: %s event carries %T payload", ErrMalformed, message.GetType(), message.GetPayload())
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CheckCompatible reports whether a payload produced as produced can still
+// be read by a consumer written against contract. Every field of contract
+// must be present in produced with the same JSON kind; new fields are
+// allowed.
+func CheckCompatible(contract []byte, produced []byte) error {
+	var want, got any
+	if err := json.Unmarshal(contract, &want); err != nil {
+		return fmt.Errorf("unmarshal contract: %w", err)
+	}
+	if err := json.Unmarshal(produced, &got); err != nil {
+		return fmt.Errorf("unmarshal produced: %w", err)
+	}
+
+	return compatible("$", want, got)
+}
+
+func compatible(path string, want any, got any) error {
+	switch want := want.(type) {
+	case map[string]any:
+		got, ok := got.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: want object, got %s", path, kind(got))
+		}
+		for field, value := range want {
+			gotValue, ok := got[field]
+			if !ok {
+				return fmt.Errorf("%s.%s: field removed", path, field)
+			}
+			if err := compatible(path+"."+field, value, gotValue); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		got, ok := got.([]any)
+		if !ok {
+			return fmt.Errorf("%s: want array, got %s", path, kind(got))
+		}
+		if len(want) > 0 && len(got) > 0 {
+			return compatible(path+"[0]", want[0], got[0])
+		}
+		return nil
+	default:
+		if kind(want) != kind(got) {
+			return fmt.Errorf("%s: want %s, got %s", path, kind(want), kind(got))
+		}
+		return nil
+	}
+}
+
+func kind(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,128 @@
+// Package events defines the contracts of the events sso publishes and
+// userservice consumes: a versioned envelope and the payload of every
+// event type.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownType        = errors.New("unknown event type")
+	ErrUnsupportedVersion = errors.New("unsupported event version")
+	// ErrMalformed is returned for messages that can't be decoded.
+	ErrMalformed = errors.New("malformed event")
+)
+
+// IsPermanent reports whether err can't go away on retry: the event is
+// malformed or of a type or version the consumer doesn't understand.
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrMalformed) ||
+		errors.Is(err, ErrUnknownType) ||
+		errors.Is(err, ErrUnsupportedVersion) ||
+		errors.Is(err, ErrUnsupportedContentType)
+}
+
+// Envelope wraps the payload of an event with its metadata.
+type Envelope struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Version    int       `json:"version"`
+	OccurredAt time.Time `json:"occurred_at"`
+	// Producer is the service that emitted the event.
+	Producer string `json:"producer"`
+	// CorrelationID ties the event to the request that caused it.
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// New wraps payload in an envelope of the current version of eventType.
+// The correlation id is taken from ctx.
+func New(ctx context.Context, eventType string, producer string, payload any) (Envelope, error) {
+	version, ok := versions[eventType]
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: %q", ErrUnknownType, eventType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	return Envelope{
+		ID:            uuid.NewString(),
+		Type:          eventType,
+		Version:       version,
+		OccurredAt:    time.Now().UTC(),
+		Producer:      producer,
+		CorrelationID: CorrelationID(ctx),
+		Payload:       data,
+	}, nil
+}
+
+// Parse reads an envelope from a message value. Messages written before
+// the envelope existed carry a bare payload; they are wrapped as version 1
+// of key, the event type sso used as the message key.
+func Parse(key string, value []byte) (Envelope, error) {
+	// Legacy payloads have their own "id" field, so the shape is probed
+	// before decoding the whole envelope.
+	var probe struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(value, &probe); err != nil {
+		return Envelope{}, fmt.Errorf("%w: unmarshal envelope: %w", ErrMalformed, err)
+	}
+
+	if probe.Type == "" || probe.Payload == nil {
+		return Envelope{
+			Type:    key,
+			Version: 1,
+			Payload: value,
+		}, nil
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(value, &envelope); err != nil {
+		return Envelope{}, fmt.Errorf("%w: unmarshal envelope: %w", ErrMalformed, err)
+	}
+
+	return envelope, nil
+}
+
+// Decode unmarshals the payload into v. Versions newer than the one this
+// package knows are rejected, since their payload may have changed shape.
+func (e Envelope) Decode(v any) error {
+	version, ok := versions[e.Type]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownType, e.Type)
+	}
+	if e.Version > version {
+		return fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, e.Type, e.Version)
+	}
+
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("%w: unmarshal payload: %w", ErrMalformed, err)
+	}
+
+	return nil
+}
+
+type correlationKey struct{}
+
+// WithCorrelationID returns a context carrying the correlation id.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, correlationID)
+}
+
+// CorrelationID returns the correlation id of ctx, empty if none.
+func CorrelationID(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationKey{}).(string)
+	return correlationID
+}
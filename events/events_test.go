@@ -0,0 +1,423 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	eventspb "events/gen/go/events"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// examples hold a payload of every event type with all fields set, as
+// the producer writes it today.
+var examples = map[string]any{
+	TypeUserCreated: UserCreated{UserID: 42, Email: "user@example.com"},
+	TypeUserInvited: UserInvited{
+		UserID:  42,
+		Email:   "user@example.com",
+		Name:    "Ivan",
+		Surname: "Ivanov",
+	},
+	TypeOrgMemberAdded:   OrgMemberAdded{OrgID: 7, UserID: 42, Role: "admin"},
+	TypeAppAccessGranted: AppAccess{AppID: 1, UserID: 42},
+	TypeAppAccessRevoked: AppAccess{AppID: 1, UserID: 42},
+	TypeUserSnapshot:     UserSnapshot{UserID: 42, Email: "user@example.com", Status: "active"},
+	TypeUserProfileUpdated: UserProfileUpdated{
+		UserID:     42,
+		Changed:    []string{FieldName, FieldAvatar},
+		Name:       "Ivan",
+		Surname:    "Ivanov",
+		AvatarHash: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	},
+}
+
+// TestContracts fails when a producer change would break a consumer
+// written against testdata/<type>.v<version>.json. Breaking changes need a
+// new version and a new contract file.
+func TestContracts(t *testing.T) {
+	for _, eventType := range Types() {
+		t.Run(eventType, func(t *testing.T) {
+			version, _ := Version(eventType)
+
+			example, ok := examples[eventType]
+			if !ok {
+				t.Fatalf("no example payload for %s", eventType)
+			}
+
+			contract, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("%s.v%d.json", eventType, version)))
+			if err != nil {
+				t.Fatalf("no contract for %s v%d: %v", eventType, version, err)
+			}
+
+			produced, err := json.Marshal(example)
+			if err != nil {
+				t.Fatalf("marshal example: %v", err)
+			}
+
+			if err := CheckCompatible(contract, produced); err != nil {
+				t.Errorf("producer breaks %s v%d contract: %v", eventType, version, err)
+			}
+
+			// The consumer side: the current struct must still know every
+			// field of the contract.
+			payload := newPayload(eventType)
+			decoder := json.NewDecoder(bytes.NewReader(contract))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(payload); err != nil {
+				t.Errorf("contract of %s v%d doesn't decode: %v", eventType, version, err)
+			}
+		})
+	}
+}
+
+func newPayload(eventType string) any {
+	switch eventType {
+	case TypeUserCreated:
+		return &UserCreated{}
+	case TypeUserInvited:
+		return &UserInvited{}
+	case TypeOrgMemberAdded:
+		return &OrgMemberAdded{}
+	case TypeUserSnapshot:
+		return &UserSnapshot{}
+	case TypeUserProfileUpdated:
+		return &UserProfileUpdated{}
+	default:
+		return &AppAccess{}
+	}
+}
+
+func TestCheckCompatible(t *testing.T) {
+	contract := []byte(`{"id": 1, "email": "a@b.c", "tags": ["x"]}`)
+
+	tests := []struct {
+		name     string
+		produced string
+		wantErr  bool
+	}{
+		{name: "same", produced: `{"id": 2, "email": "d@e.f", "tags": ["y"]}`},
+		{name: "added field", produced: `{"id": 2, "email": "d@e.f", "tags": [], "name": "n"}`},
+		{name: "removed field", produced: `{"id": 2, "tags": []}`, wantErr: true},
+		{name: "renamed field", produced: `{"user_id": 2, "email": "d@e.f", "tags": []}`, wantErr: true},
+		{name: "changed type", produced: `{"id": "2", "email": "d@e.f", "tags": []}`, wantErr: true},
+		{name: "changed element type", produced: `{"id": 2, "email": "d@e.f", "tags": [1]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckCompatible(contract, []byte(tt.produced))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckCompatible() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	ctx := WithCorrelationID(context.Background(), "req-1")
+
+	envelope, err := New(ctx, TypeUserCreated, "sso", examples[TypeUserCreated])
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+
+	parsed, err := Parse(TypeUserCreated, data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if parsed.ID != envelope.ID || parsed.Version != 1 || parsed.Producer != "sso" || parsed.CorrelationID != "req-1" {
+		t.Errorf("Parse() = %+v, want %+v", parsed, envelope)
+	}
+
+	var payload UserCreated
+	if err := parsed.Decode(&payload); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if payload != examples[TypeUserCreated] {
+		t.Errorf("Decode() = %+v, want %+v", payload, examples[TypeUserCreated])
+	}
+}
+
+func TestParseLegacyPayload(t *testing.T) {
+	parsed, err := Parse(TypeUserCreated, []byte(`{"id": 42, "email": "user@example.com"}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	var payload UserCreated
+	if err := parsed.Decode(&payload); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if payload.UserID != 42 {
+		t.Errorf("Decode() = %+v, want user 42", payload)
+	}
+}
+
+func TestDecodeRejectsNewerVersion(t *testing.T) {
+	envelope := Envelope{Type: TypeUserCreated, Version: 2, Payload: []byte(`{}`)}
+
+	var payload UserCreated
+	if err := envelope.Decode(&payload); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Decode() error = %v, want %v", err, ErrUnsupportedVersion)
+	}
+}
+
+func TestNewRejectsUnknownType(t *testing.T) {
+	if _, err := New(context.Background(), "Unknown", "sso", struct{}{}); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("New() error = %v, want %v", err, ErrUnknownType)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	ctx := WithCorrelationID(context.Background(), "req-1")
+
+	for _, contentType := range []string{ContentTypeJSON, ContentTypeProtobuf} {
+		for _, eventType := range Types() {
+			t.Run(contentType+"/"+eventType, func(t *testing.T) {
+				envelope, err := New(ctx, eventType, "sso", examples[eventType])
+				if err != nil {
+					t.Fatalf("New() error = %v", err)
+				}
+
+				data, err := Marshal(envelope, contentType)
+				if err != nil {
+					t.Fatalf("Marshal() error = %v", err)
+				}
+
+				decoded, err := Unmarshal(contentType, eventType, data)
+				if err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				if decoded.ID != envelope.ID || decoded.Type != eventType ||
+					decoded.CorrelationID != "req-1" || !decoded.OccurredAt.Equal(envelope.OccurredAt) {
+					t.Errorf("Unmarshal() = %+v, want %+v", decoded, envelope)
+				}
+
+				payload := newPayload(eventType)
+				if err := decoded.Decode(payload); err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				want, _ := json.Marshal(examples[eventType])
+				got, _ := json.Marshal(payload)
+				if !bytes.Equal(got, want) {
+					t.Errorf("Decode() = %s, want %s", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestUnmarshalWithoutContentType(t *testing.T) {
+	parsed, err := Unmarshal("", TypeUserCreated, []byte(`{"id": 42, "email": "user@example.com"}`))
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if parsed.Type != TypeUserCreated {
+		t.Errorf("Unmarshal() type = %q, want %q", parsed.Type, TypeUserCreated)
+	}
+}
+
+func TestUnsupportedContentType(t *testing.T) {
+	if _, err := Marshal(Envelope{}, "text/plain"); !errors.Is(err, ErrUnsupportedContentType) {
+		t.Errorf("Marshal() error = %v, want %v", err, ErrUnsupportedContentType)
+	}
+	if _, err := Unmarshal("text/plain", "", nil); !errors.Is(err, ErrUnsupportedContentType) {
+		t.Errorf("Unmarshal() error = %v, want %v", err, ErrUnsupportedContentType)
+	}
+	if _, err := ContentType("avro"); !errors.Is(err, ErrUnsupportedContentType) {
+		t.Errorf("ContentType() error = %v, want %v", err, ErrUnsupportedContentType)
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	if _, err := Unmarshal(ContentTypeJSON, TypeUserCreated, []byte(`{not json`)); !errors.Is(err, ErrMalformed) {
+		t.Errorf("Unmarshal(json) error = %v, want %v", err, ErrMalformed)
+	}
+	if _, err := Unmarshal(ContentTypeProtobuf, TypeUserCreated, []byte{0xff, 0xff}); !errors.Is(err, ErrMalformed) {
+		t.Errorf("Unmarshal(protobuf) error = %v, want %v", err, ErrMalformed)
+	}
+
+	envelope := Envelope{Type: TypeUserCreated, Version: 1, Payload: []byte(`{"id": "42"}`)}
+	var payload UserCreated
+	if err := envelope.Decode(&payload); !errors.Is(err, ErrMalformed) {
+		t.Errorf("Decode() error = %v, want %v", err, ErrMalformed)
+	}
+}
+
+func TestUnmarshalRejectsMismatchedPayload(t *testing.T) {
+	data, err := proto.Marshal(&eventspb.Envelope{
+		Id:      "event-1",
+		Type:    TypeUserCreated,
+		Version: 1,
+		Payload: &eventspb.Envelope_OrgMemberAdded{OrgMemberAdded: &eventspb.OrgMemberAdded{
+			OrgId:  7,
+			UserId: 42,
+			Role:   "admin",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+
+	if _, err := Unmarshal(ContentTypeProtobuf, TypeUserCreated, data); !errors.Is(err, ErrMalformed) {
+		t.Errorf("Unmarshal() error = %v, want %v", err, ErrMalformed)
+	}
+}
+
+func TestPayloadsAreKeyedByUser(t *testing.T) {
+	for eventType, example := range examples {
+		keyed, ok := example.(Keyed)
+		if !ok {
+			t.Errorf("%s payload has no partition key", eventType)
+			continue
+		}
+		if key := keyed.PartitionKey(); key != "42" {
+			t.Errorf("%s partition key = %q, want user id 42", eventType, key)
+		}
+	}
+}
+
+func TestMessageKey(t *testing.T) {
+	tests := []struct {
+		partitionKey string
+		eventID      string
+		wantKey      string
+	}{
+		{partitionKey: "42", eventID: "0b5e", wantKey: "42/0b5e"},
+		{partitionKey: "42", wantKey: "42"},
+		{partitionKey: TypeUserCreated, eventID: "0b5e", wantKey: TypeUserCreated + "/0b5e"},
+	}
+
+	for _, tt := range tests {
+		key := MessageKey(tt.partitionKey, tt.eventID)
+		if string(key) != tt.wantKey {
+			t.Errorf("MessageKey(%q, %q) = %q, want %q", tt.partitionKey, tt.eventID, key, tt.wantKey)
+		}
+		if got := PartitionKeyOf(key); string(got) != tt.partitionKey {
+			t.Errorf("PartitionKeyOf(%q) = %q, want %q", key, got, tt.partitionKey)
+		}
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	ctx := WithCorrelationID(context.Background(), "6f1c2c1e-8f7a-4a58-9a39-0d5f6b1c9e21")
+	first, err := New(ctx, TypeUserCreated, "sso", examples[TypeUserCreated])
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	second, err := New(ctx, TypeUserCreated, "sso", examples[TypeUserCreated])
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	headers := Headers(first, ContentTypeJSON, "")
+	want := map[string]string{
+		HeaderContentType:   ContentTypeJSON,
+		HeaderEventType:     TypeUserCreated,
+		HeaderEventID:       first.ID,
+		HeaderSchemaVersion: "1",
+		HeaderSource:        "sso",
+		HeaderCorrelationID: first.CorrelationID,
+	}
+	for key, value := range want {
+		if headers[key] != value {
+			t.Errorf("header %s = %q, want %q", key, headers[key], value)
+		}
+	}
+
+	// Events of a request share the trace, not the span.
+	traceParent := headers[HeaderTraceParent]
+	if traceParent != "00-6f1c2c1e8f7a4a589a390d5f6b1c9e21-"+traceParent[36:52]+"-01" {
+		t.Errorf("traceparent = %q, want the correlation id as trace id", traceParent)
+	}
+	other := Headers(second, ContentTypeJSON, "")[HeaderTraceParent]
+	if other[:35] != traceParent[:35] || other == traceParent {
+		t.Errorf("traceparents %q and %q of one request don't share only the trace", traceParent, other)
+	}
+
+	if got := NewTraceParent("not-a-uuid", "sso-1"); !ValidTraceParent(got) {
+		t.Errorf("NewTraceParent() = %q, want a valid traceparent", got)
+	}
+
+	// The trace context of the request is propagated as is.
+	incoming := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	if got := Headers(first, ContentTypeJSON, incoming)[HeaderTraceParent]; got != incoming {
+		t.Errorf("traceparent = %q, want the incoming %q", got, incoming)
+	}
+}
+
+func TestValidTraceParent(t *testing.T) {
+	tests := []struct {
+		traceParent string
+		want        bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true},
+		{"", false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidTraceParent(tt.traceParent); got != tt.want {
+			t.Errorf("ValidTraceParent(%q) = %v, want %v", tt.traceParent, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: time.Second},
+		{failures: 1, want: 2 * time.Second},
+		{failures: 3, want: 8 * time.Second},
+		{failures: 4, want: 10 * time.Second},
+		{failures: 100, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Delay(tt.failures); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("decode: %w", ErrMalformed), true},
+		{fmt.Errorf("decode: %w", ErrUnknownType), true},
+		{fmt.Errorf("decode: %w", ErrUnsupportedVersion), true},
+		{fmt.Errorf("decode: %w", ErrUnsupportedContentType), true},
+		{errors.New("database is locked"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPermanent(tt.err); got != tt.want {
+			t.Errorf("IsPermanent(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package events
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Message headers describing the event, besides content-type, event-type
+// and event-id.
+const (
+	// HeaderSchemaVersion carries the envelope version.
+	HeaderSchemaVersion = "schema-version"
+	// HeaderSource names the service that emitted the event.
+	HeaderSource = "source"
+	// HeaderCorrelationID carries the correlation id of the request that
+	// caused the event.
+	HeaderCorrelationID = "correlation-id"
+	// HeaderTraceParent is the W3C trace context of the request that
+	// caused the event, so tracing tools can follow it between services.
+	HeaderTraceParent = "traceparent"
+)
+
+// Headers returns the headers of a message carrying envelope encoded as
+// contentType. traceParent is the trace context of the request that caused
+// the event; without one the event gets a trace derived from its ids.
+func Headers(envelope Envelope, contentType string, traceParent string) map[string]string {
+	if traceParent == "" {
+		traceParent = NewTraceParent(envelope.CorrelationID, envelope.ID)
+	}
+
+	headers := map[string]string{
+		HeaderContentType:   contentType,
+		HeaderEventType:     envelope.Type,
+		HeaderEventID:       envelope.ID,
+		HeaderSchemaVersion: strconv.Itoa(envelope.Version),
+		HeaderSource:        envelope.Producer,
+		HeaderTraceParent:   traceParent,
+	}
+	if envelope.CorrelationID != "" {
+		headers[HeaderCorrelationID] = envelope.CorrelationID
+	}
+
+	return headers
+}
+
+// NewTraceParent returns a W3C traceparent whose trace id is derived from
+// correlationID, so the events of a request share a trace, and whose
+// parent span id is derived from eventID. Without a correlation id the
+// event is a trace of its own.
+func NewTraceParent(correlationID string, eventID string) string {
+	if correlationID == "" {
+		correlationID = eventID
+	}
+
+	var traceID [16]byte
+	if id, err := uuid.Parse(correlationID); err == nil {
+		traceID = id
+	} else {
+		sum := sha256.Sum256([]byte(correlationID))
+		copy(traceID[:], sum[:])
+	}
+	spanID := sha256.Sum256([]byte(eventID))
+
+	return "00-" + hex.EncodeToString(traceID[:]) + "-" + hex.EncodeToString(spanID[:8]) + "-01"
+}
+
+// ValidTraceParent reports whether traceParent is a version 00 W3C
+// traceparent with non-zero trace and parent ids.
+func ValidTraceParent(traceParent string) bool {
+	parts := strings.Split(traceParent, "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return false
+	}
+
+	for i, size := range []int{2, 32, 16, 2} {
+		part := parts[i]
+		if len(part) != size || strings.ToLower(part) != part {
+			return false
+		}
+		decoded, err := hex.DecodeString(part)
+		if err != nil {
+			return false
+		}
+		if (i == 1 || i == 2) && isZero(decoded) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isZero(id []byte) bool {
+	for _, b := range id {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type traceParentKey struct{}
+
+// WithTraceParent returns a context carrying the W3C trace context of the
+// request, which the events written while serving it are published with.
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, traceParent)
+}
+
+// TraceParent returns the trace context of ctx, empty if none.
+func TraceParent(ctx context.Context) string {
+	traceParent, _ := ctx.Value(traceParentKey{}).(string)
+	return traceParent
+}
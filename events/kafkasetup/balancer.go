@@ -0,0 +1,19 @@
+package kafkasetup
+
+import (
+	"events"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// PartitionKeyBalancer hashes the partition key part of message keys, so
+// the events of one user go to the same partition although every event
+// has a key of its own.
+type PartitionKeyBalancer struct {
+	hash kafka.Hash
+}
+
+func (b *PartitionKeyBalancer) Balance(msg kafka.Message, partitions ...int) int {
+	msg.Key = events.PartitionKeyOf(msg.Key)
+	return b.hash.Balance(msg, partitions...)
+}
@@ -0,0 +1,24 @@
+package kafkasetup
+
+import (
+	"events"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+func TestPartitionKeyBalancer(t *testing.T) {
+	balancer := &PartitionKeyBalancer{}
+	partitions := []int{0, 1, 2, 3, 4, 5, 6, 7}
+
+	for _, user := range []string{"1", "2", "42"} {
+		want := balancer.Balance(kafka.Message{Key: []byte(user)}, partitions...)
+		for range 20 {
+			key := events.MessageKey(user, uuid.NewString())
+			if got := balancer.Balance(kafka.Message{Key: key}, partitions...); got != want {
+				t.Fatalf("event %q of user %s went to partition %d, want %d", key, user, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Package kafkasetup holds what both services need to set up Kafka:
+// securing the connections to the brokers and checking the topics.
+package kafkasetup
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// SASL mechanisms the brokers may require.
+const (
+	MechanismPlain       = "PLAIN"
+	MechanismSCRAMSHA256 = "SCRAM-SHA-256"
+	MechanismSCRAMSHA512 = "SCRAM-SHA-512"
+)
+
+// Security holds how to connect to the brokers. The zero value connects
+// over plain TCP without authentication.
+type Security struct {
+	TLS  TLS
+	SASL SASL
+}
+
+// TLS enables TLS when Enabled is set. CAFile replaces the system roots;
+// CertFile and KeyFile are the client certificate, for brokers that
+// authenticate clients by it.
+type TLS struct {
+	Enabled            bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+// SASL authenticates with Mechanism, unless it is empty.
+type SASL struct {
+	Mechanism string
+	Username  string
+	Password  string
+}
+
+// Dialer returns the dialer used for every connection to the brokers,
+// the admin one included, so all of them are secured the same way.
+func (s Security) Dialer() (*kafka.Dialer, error) {
+	tlsConfig, err := s.TLS.config()
+	if err != nil {
+		return nil, fmt.Errorf("kafka TLS: %w", err)
+	}
+
+	mechanism, err := s.SASL.mechanism()
+	if err != nil {
+		return nil, fmt.Errorf("kafka SASL: %w", err)
+	}
+
+	return &kafka.Dialer{
+		Timeout:       10 * time.Second,
+		DualStack:     true,
+		TLS:           tlsConfig,
+		SASLMechanism: mechanism,
+	}, nil
+}
+
+// Transport returns the transport of admin requests made through a
+// kafka.Client, secured like the connections of Dialer.
+func (s Security) Transport() (*kafka.Transport, error) {
+	tlsConfig, err := s.TLS.config()
+	if err != nil {
+		return nil, fmt.Errorf("kafka TLS: %w", err)
+	}
+
+	mechanism, err := s.SASL.mechanism()
+	if err != nil {
+		return nil, fmt.Errorf("kafka SASL: %w", err)
+	}
+
+	return &kafka.Transport{
+		DialTimeout: 10 * time.Second,
+		TLS:         tlsConfig,
+		SASL:        mechanism,
+	}, nil
+}
+
+func (t TLS) config() (*tls.Config, error) {
+	if !t.Enabled {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CAFile != "" {
+		ca, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates in CA file %s", t.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+func (s SASL) mechanism() (sasl.Mechanism, error) {
+	switch strings.ToUpper(s.Mechanism) {
+	case "":
+		return nil, nil
+	case MechanismPlain:
+		return plain.Mechanism{Username: s.Username, Password: s.Password}, nil
+	case MechanismSCRAMSHA256:
+		return scram.Mechanism(scram.SHA256, s.Username, s.Password)
+	case MechanismSCRAMSHA512:
+		return scram.Mechanism(scram.SHA512, s.Username, s.Password)
+	default:
+		return nil, fmt.Errorf("unknown mechanism %q", s.Mechanism)
+	}
+}
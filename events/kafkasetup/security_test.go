@@ -0,0 +1,47 @@
+package kafkasetup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecurity_Dialer(t *testing.T) {
+	dialer, err := Security{}.Dialer()
+	if err != nil {
+		t.Fatalf("zero security: %v", err)
+	}
+	if dialer.TLS != nil || dialer.SASLMechanism != nil {
+		t.Errorf("zero security enabled TLS or SASL")
+	}
+
+	for _, mechanism := range []string{"plain", MechanismSCRAMSHA256, MechanismSCRAMSHA512} {
+		dialer, err := Security{SASL: SASL{Mechanism: mechanism, Username: "sso", Password: "secret"}}.Dialer()
+		if err != nil {
+			t.Fatalf("%s: %v", mechanism, err)
+		}
+		if dialer.SASLMechanism == nil {
+			t.Errorf("%s: no SASL mechanism", mechanism)
+		}
+	}
+
+	if _, err := (Security{SASL: SASL{Mechanism: "GSSAPI"}}).Dialer(); err == nil {
+		t.Errorf("unknown mechanism accepted")
+	}
+
+	dialer, err = Security{TLS: TLS{Enabled: true, ServerName: "kafka"}}.Dialer()
+	if err != nil {
+		t.Fatalf("TLS: %v", err)
+	}
+	if dialer.TLS == nil || dialer.TLS.ServerName != "kafka" {
+		t.Errorf("TLS config = %+v", dialer.TLS)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (Security{TLS: TLS{Enabled: true, CAFile: caFile}}).Dialer(); err == nil {
+		t.Errorf("CA file without certificates accepted")
+	}
+}
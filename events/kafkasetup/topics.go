@@ -0,0 +1,209 @@
+package kafkasetup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// Strictness of EnsureTopics about topics that don't match their spec.
+const (
+	// StrictnessWarn logs the mismatches and goes on.
+	StrictnessWarn = "warn"
+	// StrictnessFail returns them as an error, so the service doesn't
+	// start against topics it wasn't configured for.
+	StrictnessFail = "fail"
+)
+
+var ErrTopicMismatch = errors.New("topics don't match their spec")
+
+const retentionConfig = "retention.ms"
+
+// TopicSpec is a topic the service needs.
+type TopicSpec struct {
+	Name              string
+	Partitions        int
+	ReplicationFactor int
+	// Retention of messages; 0 leaves the broker default.
+	Retention time.Duration
+	// External topics belong to another service: they are only required
+	// to exist, whatever their settings.
+	External bool
+}
+
+// EnsureTopics creates the topics of specs that don't exist and checks
+// the partitions, replication factor and retention of the others.
+// Missing external topics aren't created but reported.
+// Topics aren't altered: a mismatch, like a failure to reach the brokers,
+// is returned with StrictnessFail and logged with StrictnessWarn.
+func EnsureTopics(
+	ctx context.Context,
+	log *slog.Logger,
+	brokers []string,
+	security Security,
+	specs []TopicSpec,
+	strictness string,
+) error {
+	const op = "kafkasetup.EnsureTopics"
+
+	log = log.With(slog.String("op", op))
+
+	if strictness != StrictnessWarn && strictness != StrictnessFail {
+		return fmt.Errorf("%s: unknown strictness %q", op, strictness)
+	}
+
+	problems, err := ensureTopics(ctx, log, brokers, security, specs)
+	if err != nil {
+		if strictness == StrictnessFail {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		log.Warn("failed to check Kafka topics", slog.String("error", err.Error()))
+		return nil
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	if strictness == StrictnessFail {
+		return fmt.Errorf("%s: %w: %s", op, ErrTopicMismatch, strings.Join(problems, "; "))
+	}
+	for _, problem := range problems {
+		log.Warn("Kafka topic doesn't match its spec", slog.String("problem", problem))
+	}
+	return nil
+}
+
+func ensureTopics(
+	ctx context.Context,
+	log *slog.Logger,
+	brokers []string,
+	security Security,
+	specs []TopicSpec,
+) ([]string, error) {
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers provided")
+	}
+
+	transport, err := security.Transport()
+	if err != nil {
+		return nil, err
+	}
+	client := &kafka.Client{Addr: kafka.TCP(brokers...), Transport: transport}
+
+	var owned, existing []TopicSpec
+	configs := make([]kafka.TopicConfig, 0, len(specs))
+	for _, spec := range specs {
+		if spec.External {
+			existing = append(existing, spec)
+			continue
+		}
+		owned = append(owned, spec)
+		config := kafka.TopicConfig{
+			Topic:             spec.Name,
+			NumPartitions:     max(spec.Partitions, 1),
+			ReplicationFactor: max(spec.ReplicationFactor, 1),
+		}
+		if spec.Retention > 0 {
+			config.ConfigEntries = []kafka.ConfigEntry{{
+				ConfigName:  retentionConfig,
+				ConfigValue: strconv.FormatInt(spec.Retention.Milliseconds(), 10),
+			}}
+		}
+		configs = append(configs, config)
+	}
+
+	created := &kafka.CreateTopicsResponse{}
+	if len(configs) > 0 {
+		created, err = client.CreateTopics(ctx, &kafka.CreateTopicsRequest{Topics: configs})
+		if err != nil {
+			return nil, fmt.Errorf("create topics: %w", err)
+		}
+	}
+
+	for _, spec := range owned {
+		switch err := created.Errors[spec.Name]; {
+		case err == nil:
+			log.Info("Kafka topic created", slog.String("topic", spec.Name))
+		case errors.Is(err, kafka.TopicAlreadyExists):
+			existing = append(existing, spec)
+		default:
+			return nil, fmt.Errorf("create topic %s: %w", spec.Name, err)
+		}
+	}
+	if len(existing) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(existing))
+	resources := make([]kafka.DescribeConfigRequestResource, 0, len(existing))
+	for _, spec := range existing {
+		names = append(names, spec.Name)
+		resources = append(resources, kafka.DescribeConfigRequestResource{
+			ResourceType: kafka.ResourceTypeTopic,
+			ResourceName: spec.Name,
+			ConfigNames:  []string{retentionConfig},
+		})
+	}
+
+	metadata, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: names})
+	if err != nil {
+		return nil, fmt.Errorf("read topic metadata: %w", err)
+	}
+	topics := make(map[string]kafka.Topic, len(metadata.Topics))
+	for _, topic := range metadata.Topics {
+		topics[topic.Name] = topic
+	}
+
+	described, err := client.DescribeConfigs(ctx, &kafka.DescribeConfigsRequest{Resources: resources})
+	if err != nil {
+		return nil, fmt.Errorf("describe topics: %w", err)
+	}
+	retentions := make(map[string]string, len(described.Resources))
+	for _, resource := range described.Resources {
+		for _, entry := range resource.ConfigEntries {
+			if entry.ConfigName == retentionConfig {
+				retentions[resource.ResourceName] = entry.ConfigValue
+			}
+		}
+	}
+
+	var problems []string
+	for _, spec := range existing {
+		problems = append(problems, checkTopic(spec, topics[spec.Name], retentions[spec.Name])...)
+	}
+	return problems, nil
+}
+
+// checkTopic compares an existing topic and its retention.ms with spec.
+func checkTopic(spec TopicSpec, topic kafka.Topic, retention string) []string {
+	if topic.Error != nil {
+		return []string{fmt.Sprintf("topic %s: %v", spec.Name, topic.Error)}
+	}
+	if spec.External {
+		return nil
+	}
+
+	var problems []string
+	if want := max(spec.Partitions, 1); len(topic.Partitions) != want {
+		problems = append(problems, fmt.Sprintf(
+			"topic %s has %d partitions, want %d", spec.Name, len(topic.Partitions), want))
+	}
+	if want := max(spec.ReplicationFactor, 1); len(topic.Partitions) > 0 && len(topic.Partitions[0].Replicas) != want {
+		problems = append(problems, fmt.Sprintf(
+			"topic %s has replication factor %d, want %d", spec.Name, len(topic.Partitions[0].Replicas), want))
+	}
+	if spec.Retention > 0 {
+		if want := strconv.FormatInt(spec.Retention.Milliseconds(), 10); retention != want {
+			problems = append(problems, fmt.Sprintf(
+				"topic %s has %s %q, want %s", spec.Name, retentionConfig, retention, want))
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,79 @@
+package kafkasetup
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+func topic(partitions int, replicas int) kafka.Topic {
+	topic := kafka.Topic{Name: "sso_events"}
+	for id := range partitions {
+		topic.Partitions = append(topic.Partitions, kafka.Partition{
+			ID:       id,
+			Replicas: make([]kafka.Broker, replicas),
+		})
+	}
+	return topic
+}
+
+func TestCheckTopic(t *testing.T) {
+	spec := TopicSpec{Name: "sso_events", Partitions: 3, ReplicationFactor: 2, Retention: 24 * time.Hour}
+
+	tests := []struct {
+		name      string
+		spec      TopicSpec
+		topic     kafka.Topic
+		retention string
+		want      int
+	}{
+		{name: "matching", spec: spec, topic: topic(3, 2), retention: "86400000", want: 0},
+		{name: "fewer partitions", spec: spec, topic: topic(1, 2), retention: "86400000", want: 1},
+		{name: "other replication factor", spec: spec, topic: topic(3, 1), retention: "86400000", want: 1},
+		{name: "other retention", spec: spec, topic: topic(3, 2), retention: "604800000", want: 1},
+		{name: "all wrong", spec: spec, topic: topic(1, 1), retention: "-1", want: 3},
+		{
+			name:      "broker default retention",
+			spec:      TopicSpec{Name: "sso_events", Partitions: 3, ReplicationFactor: 2},
+			topic:     topic(3, 2),
+			retention: "604800000",
+			want:      0,
+		},
+		{
+			name:  "metadata error",
+			spec:  spec,
+			topic: kafka.Topic{Name: "sso_events", Error: kafka.UnknownTopicOrPartition},
+			want:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := checkTopic(tt.spec, tt.topic, tt.retention)
+			if len(problems) != tt.want {
+				t.Errorf("checkTopic() = %s, want %d problems", fmt.Sprint(problems), tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTopic_External(t *testing.T) {
+	existing := kafka.Topic{Name: "sso_events", Partitions: []kafka.Partition{{ID: 0}, {ID: 1}, {ID: 2}}}
+	external := TopicSpec{Name: "sso_events", Partitions: 1, External: true}
+
+	if problems := checkTopic(external, existing, ""); len(problems) != 0 {
+		t.Errorf("external topic checked against its settings: %v", problems)
+	}
+
+	missing := kafka.Topic{Name: "sso_events", Error: kafka.UnknownTopicOrPartition}
+	if problems := checkTopic(external, missing, ""); len(problems) != 1 {
+		t.Errorf("missing external topic reported %d problems, want 1", len(problems))
+	}
+
+	owned := TopicSpec{Name: "sso_events", Partitions: 1, ReplicationFactor: 1}
+	if problems := checkTopic(owned, existing, ""); len(problems) != 2 {
+		t.Errorf("owned topic reported %v, want partitions and replication factor", problems)
+	}
+}
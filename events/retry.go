@@ -0,0 +1,23 @@
+package events
+
+import "time"
+
+// RetryPolicy controls how failed events are retried. The delay doubles
+// with every attempt, starting at Backoff and capped at MaxBackoff. What
+// happens after MaxAttempts is up to the caller; zero means no limit.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Delay returns the delay before the next attempt of an event that
+// already failed failures times.
+func (p RetryPolicy) Delay(failures int) time.Duration {
+	delay := p.Backoff
+	for i := 0; i < failures && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxBackoff)
+}
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"bytes"
+	"strconv"
+)
+
+const (
+	TypeUserCreated      = "UserCreated"
+	TypeUserInvited      = "UserInvited"
+	TypeOrgMemberAdded   = "OrgMemberAdded"
+	TypeAppAccessGranted = "AppAccessGranted"
+	TypeAppAccessRevoked = "AppAccessRevoked"
+	// TypeUserSnapshot re-announces an existing user, so consumers can
+	// rebuild their state; see sso's outbox backfill command.
+	TypeUserSnapshot = "UserSnapshot"
+	// TypeUserProfileUpdated is published by userservice.
+	TypeUserProfileUpdated = "UserProfileUpdated"
+)
+
+// Profile fields listed in UserProfileUpdated.Changed.
+const (
+	FieldName    = "name"
+	FieldSurname = "surname"
+	FieldAvatar  = "avatar"
+)
+
+// versions holds the current payload version of every event type. A
+// change that breaks the contract in testdata needs a new version.
+var versions = map[string]int{
+	TypeUserCreated:      1,
+	TypeUserInvited:      1,
+	TypeOrgMemberAdded:   1,
+	TypeAppAccessGranted: 1,
+	TypeAppAccessRevoked: 1,
+	TypeUserSnapshot:     1,
+
+	TypeUserProfileUpdated: 1,
+}
+
+// Version returns the current payload version of eventType.
+func Version(eventType string) (int, bool) {
+	version, ok := versions[eventType]
+	return version, ok
+}
+
+// Types returns every known event type.
+func Types() []string {
+	types := make([]string, 0, len(versions))
+	for eventType := range versions {
+		types = append(types, eventType)
+	}
+	return types
+}
+
+// Keyed is implemented by payloads that belong to a user. Events with the
+// same partition key are published to the same partition, so they are
+// consumed in the order they were produced.
+type Keyed interface {
+	PartitionKey() string
+}
+
+// keySeparator separates the partition key from the event id in a
+// message key.
+const keySeparator = "/"
+
+// MessageKey returns the message key of an event: its partition key and
+// id. Brokers must partition by PartitionKeyOf the message key, not by the
+// whole key.
+func MessageKey(partitionKey string, eventID string) []byte {
+	if eventID == "" {
+		return []byte(partitionKey)
+	}
+	return []byte(partitionKey + keySeparator + eventID)
+}
+
+// PartitionKeyOf returns the partition key part of a message key. Keys
+// without an event id are returned whole.
+func PartitionKeyOf(key []byte) []byte {
+	partitionKey, _, _ := bytes.Cut(key, []byte(keySeparator))
+	return partitionKey
+}
+
+func userKey(userID int64) string {
+	return strconv.FormatInt(userID, 10)
+}
+
+type UserCreated struct {
+	UserID int64  `json:"id"`
+	Email  string `json:"email"`
+}
+
+// UserInvited is emitted for users created by an invitation, before they
+// accept it.
+type UserInvited struct {
+	UserID  int64  `json:"id"`
+	Email   string `json:"email"`
+	Name    string `json:"name,omitempty"`
+	Surname string `json:"surname,omitempty"`
+}
+
+type OrgMemberAdded struct {
+	OrgID  int64  `json:"org_id"`
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// AppAccess is the payload of AppAccessGranted and AppAccessRevoked.
+type AppAccess struct {
+	AppID  int64 `json:"app_id"`
+	UserID int64 `json:"user_id"`
+}
+
+// UserSnapshot is the state of an sso user at the time of a backfill. It
+// has no name or surname: sso doesn't keep them past the UserInvited event.
+type UserSnapshot struct {
+	UserID int64  `json:"id"`
+	Email  string `json:"email"`
+	Status string `json:"status"`
+}
+
+// UserProfileUpdated carries the profile after an update and the fields
+// the update changed. The avatar is sent as the hex SHA-256 of its bytes,
+// empty when the user has none.
+type UserProfileUpdated struct {
+	UserID     int64    `json:"user_id"`
+	Changed    []string `json:"changed"`
+	Name       string   `json:"name"`
+	Surname    string   `json:"surname"`
+	AvatarHash string   `json:"avatar_hash"`
+}
+
+func (p UserCreated) PartitionKey() string        { return userKey(p.UserID) }
+func (p UserInvited) PartitionKey() string        { return userKey(p.UserID) }
+func (p OrgMemberAdded) PartitionKey() string     { return userKey(p.UserID) }
+func (p AppAccess) PartitionKey() string          { return userKey(p.UserID) }
+func (p UserSnapshot) PartitionKey() string       { return userKey(p.UserID) }
+func (p UserProfileUpdated) PartitionKey() string { return userKey(p.UserID) }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sso/internal/storage/sqlite"
+	"strconv"
+	"strings"
+)
+
+// outbox is an admin tool for the sso event outbox.
+//
+//	outbox --storage-path=./storage/sso.db requeue [--ids=1,2,3]
+//	outbox --storage-path=./storage/sso.db backfill [--from=ID] [--to=ID] [--batch=500]
+func main() {
+	var storagePath string
+
+	flag.StringVar(&storagePath, "storage-path", "", "path to storage")
+	flag.Parse()
+
+	if storagePath == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: outbox --storage-path=PATH requeue [--ids=ID,...]")
+		fmt.Fprintln(os.Stderr, "       outbox --storage-path=PATH backfill [--from=ID] [--to=ID] [--batch=N]")
+		os.Exit(2)
+	}
+
+	storage, err := sqlite.New(storagePath)
+	if err != nil {
+		panic(err)
+	}
+
+	switch flag.Arg(0) {
+	case "requeue":
+		requeue(storage, flag.Args()[1:])
+	case "backfill":
+		backfill(storage, flag.Args()[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		os.Exit(2)
+	}
+}
+
+// requeue returns failed events to the outbox, all of them unless --ids
+// is given.
+func requeue(storage *sqlite.Storage, args []string) {
+	var ids string
+
+	flags := flag.NewFlagSet("requeue", flag.ExitOnError)
+	flags.StringVar(&ids, "ids", "", "comma-separated ids of failed events to requeue")
+	_ = flags.Parse(args)
+
+	var eventIDs []int64
+	for _, id := range strings.Split(ids, ",") {
+		if id == "" {
+			continue
+		}
+		eventID, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("invalid event id %q", id))
+		}
+		eventIDs = append(eventIDs, eventID)
+	}
+
+	requeued, err := storage.RequeueFailedEvents(context.Background(), eventIDs)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Requeued %d events\n", requeued)
+}
+
+// backfill puts a UserSnapshot event of every user with an id in
+// [--from, --to] into the outbox, so consumers that lost their state can
+// rebuild it. The running sso relays the events.
+func backfill(storage *sqlite.Storage, args []string) {
+	var (
+		from  int64
+		to    int64
+		batch int
+	)
+
+	flags := flag.NewFlagSet("backfill", flag.ExitOnError)
+	flags.Int64Var(&from, "from", 1, "first user id")
+	flags.Int64Var(&to, "to", 0, "last user id, 0 for all")
+	flags.IntVar(&batch, "batch", 500, "users per transaction")
+	_ = flags.Parse(args)
+
+	total := 0
+	for afterID := from - 1; ; {
+		lastID, snapshotted, err := storage.SnapshotUsers(context.Background(), afterID, to, batch)
+		if err != nil {
+			panic(err)
+		}
+		if snapshotted == 0 {
+			break
+		}
+		total += snapshotted
+		afterID = lastID
+	}
+
+	fmt.Printf("Backfilled %d users\n", total)
+}
@@ -3,13 +3,23 @@ package main
 import (
 	"context"
 	"errors"
+	"events"
+	"events/kafkasetup"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	grpcapp "sso/internal/app/grpc"
 	"sso/internal/config"
+	"sso/internal/lib/bus"
 	kafkaproducer "sso/internal/lib/kafka"
+	ldapclient "sso/internal/lib/ldap"
+	"sso/internal/lib/membus"
+	"sso/internal/lib/metrics"
+	natsbus "sso/internal/lib/nats"
+	eventcleaner "sso/internal/services/event-cleaner"
 	eventsender "sso/internal/services/event-sender"
+	outboxmonitor "sso/internal/services/outbox-monitor"
 	"sso/internal/storage/sqlite"
 	"sync"
 	"syscall"
@@ -39,24 +49,67 @@ func main() {
 		exitCode = 1
 		return
 	}
-	kafkaProducer, err := kafkaproducer.New(
-		log, cfg.Kafka.Brokers, cfg.Kafka.Topic, cfg.Kafka.DialAdress)
+	eventPublisher, deadLetterPublisher, err := setupPublishers(log, cfg)
 	if err != nil {
-		log.Error("failed to create storage", slog.String("error", err.Error()))
-		os.Exit(exitCode)
+		log.Error("failed to connect to message bus", slog.String("error", err.Error()))
+		exitCode = 1
+		return
+	}
+
+	eventProducer, err := bus.NewProducer(log, eventPublisher, cfg.Bus.Encoding)
+	if err != nil {
+		log.Error("failed to create event producer", slog.String("error", err.Error()))
+		exitCode = 1
+		return
+	}
+	defer func() {
+		if err := eventProducer.Close(); err != nil {
+			log.Error("bus close error", slog.String("err", err.Error()))
+			exitCode = 1
+		}
+	}()
+
+	deadLetterProducer, err := bus.NewProducer(log, deadLetterPublisher, cfg.Bus.Encoding)
+	if err != nil {
+		log.Error("failed to create dead letter producer", slog.String("error", err.Error()))
+		exitCode = 1
 		return
 	}
 	defer func() {
-		if err := kafkaProducer.Close(); err != nil {
-			log.Error("Kafka close error", slog.String("err", err.Error()))
+		if err := deadLetterProducer.Close(); err != nil {
+			log.Error("bus close error", slog.String("err", err.Error()))
 			exitCode = 1
 		}
 	}()
 
-	eventSender := eventsender.New(log, storage, kafkaProducer)
+	eventSender := eventsender.New(
+		log,
+		storage,
+		eventProducer,
+		deadLetterProducer,
+		relayID(cfg.EventSender.RelayID),
+		cfg.EventSender.BatchSize,
+		cfg.EventSender.Lease,
+		events.RetryPolicy{
+			MaxAttempts: cfg.EventSender.MaxAttempts,
+			Backoff:     cfg.EventSender.RetryBackoff,
+			MaxBackoff:  cfg.EventSender.MaxRetryBackoff,
+		},
+	)
+	eventCleaner := eventcleaner.New(
+		log,
+		storage,
+		cfg.EventCleaner.Retention,
+		cfg.EventCleaner.BatchSize,
+		cfg.EventCleaner.BatchPause,
+		cfg.EventCleaner.Archive,
+	)
+
+	outboxMonitor := outboxmonitor.New(log, storage)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	var wg sync.WaitGroup
-	wg.Add(1)
+	wg.Add(3)
 	defer func() {
 		cancel()
 		wg.Wait()
@@ -64,7 +117,7 @@ func main() {
 
 	go func() {
 		defer wg.Done()
-		if err := eventSender.StartProcessingEvents(ctx, 5*time.Second); err != nil {
+		if err := eventSender.StartProcessingEvents(ctx, cfg.EventSender.PollInterval); err != nil {
 			if errors.Is(err, context.Canceled) {
 				log.Info("Event sender stopped")
 				return
@@ -75,12 +128,53 @@ func main() {
 		}
 	}()
 
+	go func() {
+		defer wg.Done()
+		if err := eventCleaner.StartCleaning(ctx, cfg.EventCleaner.Interval); err != nil {
+			if errors.Is(err, context.Canceled) {
+				log.Info("Event cleaner stopped")
+				return
+			}
+			log.Error("Event cleaner stopped with error", slog.String("error", err.Error()))
+			exitCode = 1
+			return
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		if err := outboxMonitor.StartMonitoring(ctx, cfg.Metrics.BacklogInterval); err != nil &&
+			!errors.Is(err, context.Canceled) {
+			log.Error("Outbox monitor stopped with error", slog.String("error", err.Error()))
+		}
+	}()
+
+	go func() {
+		if err := metrics.Listen(cfg.Metrics.Host, cfg.Metrics.Port); err != nil {
+			log.Error("failed to start metrics server", slog.String("error", err.Error()))
+		}
+	}()
+
 	application, err := grpcapp.New(
 		log,
 		grpcapp.AppConfig{
-			GrpcPort:    cfg.GRPC.Port,
-			StoragePath: cfg.StoragePath,
-			TokenTTL:    cfg.TokenTTL,
+			GrpcPort:            cfg.GRPC.Port,
+			StoragePath:         cfg.StoragePath,
+			TokenTTL:            cfg.TokenTTL,
+			ImpersonationTTL:    cfg.ImpersonationTTL,
+			InvitationTTL:       cfg.InvitationTTL,
+			InvitationSecret:    cfg.InvitationSecret,
+			CredentialVerifiers: cfg.CredentialVerifiers,
+			LDAP: ldapclient.Config{
+				URL:            cfg.LDAP.URL,
+				BindDN:         cfg.LDAP.BindDN,
+				BindPassword:   cfg.LDAP.BindPassword,
+				BaseDN:         cfg.LDAP.BaseDN,
+				UserFilter:     cfg.LDAP.UserFilter,
+				GroupAttribute: cfg.LDAP.GroupAttribute,
+				Timeout:        cfg.LDAP.Timeout,
+			},
+			LDAPGroupRoles: cfg.LDAP.GroupRoles,
 		},
 	)
 	if err != nil {
@@ -112,6 +206,103 @@ func main() {
 	}
 }
 
+// setupPublishers connects to the configured message bus and returns the
+// publishers of the event topic and the dead-letter topic.
+func setupPublishers(log *slog.Logger, cfg *config.Config) (bus.Publisher, bus.Publisher, error) {
+	switch cfg.Bus.Driver {
+	case bus.DriverKafka:
+		security := kafkaSecurity(cfg.Kafka)
+		if err := ensureTopics(log, cfg.Kafka, security); err != nil {
+			return nil, nil, err
+		}
+		events, err := kafkaproducer.New(
+			log, cfg.Kafka.Brokers, cfg.Kafka.Topic, cfg.Kafka.DialAdress, security, cfg.Kafka.Idempotent)
+		if err != nil {
+			return nil, nil, err
+		}
+		deadLetters, err := kafkaproducer.New(
+			log, cfg.Kafka.Brokers, cfg.Kafka.DeadLetterTopic, cfg.Kafka.DialAdress, security, cfg.Kafka.Idempotent)
+		if err != nil {
+			_ = events.Close()
+			return nil, nil, err
+		}
+		return events, deadLetters, nil
+	case bus.DriverNATS:
+		events, err := natsbus.NewPublisher(log, cfg.NATS.URL, cfg.NATS.Subject)
+		if err != nil {
+			return nil, nil, err
+		}
+		deadLetters, err := natsbus.NewPublisher(log, cfg.NATS.URL, cfg.NATS.DeadLetterSubject)
+		if err != nil {
+			_ = events.Close()
+			return nil, nil, err
+		}
+		return events, deadLetters, nil
+	case bus.DriverMemory:
+		log.Warn("using the in-memory bus, events don't leave the process")
+		memory := membus.New(log)
+		return memory.Publisher(cfg.Kafka.Topic), memory.Publisher(cfg.Kafka.DeadLetterTopic), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown bus driver %q", cfg.Bus.Driver)
+	}
+}
+
+// ensureTopics provisions the event and dead-letter topics. Events are
+// partitioned by user, so the dead-letter topic gets as many partitions.
+func ensureTopics(log *slog.Logger, cfg config.KafkaConfig, security kafkasetup.Security) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return kafkasetup.EnsureTopics(ctx, log, cfg.Brokers, security, []kafkasetup.TopicSpec{
+		{
+			Name:              cfg.Topic,
+			Partitions:        cfg.Partitions,
+			ReplicationFactor: cfg.ReplicationFactor,
+			Retention:         cfg.Retention,
+		},
+		{
+			Name:              cfg.DeadLetterTopic,
+			Partitions:        cfg.Partitions,
+			ReplicationFactor: cfg.ReplicationFactor,
+			Retention:         cfg.DeadLetterRetention,
+		},
+	}, cfg.TopicStrictness)
+}
+
+// kafkaSecurity maps the TLS and SASL settings of the config.
+func kafkaSecurity(cfg config.KafkaConfig) kafkasetup.Security {
+	return kafkasetup.Security{
+		TLS: kafkasetup.TLS{
+			Enabled:            cfg.TLS.Enabled,
+			CAFile:             cfg.TLS.CAFile,
+			CertFile:           cfg.TLS.CertFile,
+			KeyFile:            cfg.TLS.KeyFile,
+			ServerName:         cfg.TLS.ServerName,
+			InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+		},
+		SASL: kafkasetup.SASL{
+			Mechanism: cfg.SASL.Mechanism,
+			Username:  cfg.SASL.Username,
+			Password:  cfg.SASL.Password,
+		},
+	}
+}
+
+// relayID returns the configured outbox relay id, or one derived from
+// the hostname and pid so replicas never share it.
+func relayID(configured string) string {
+	if configured != "" {
+		return configured
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "sso"
+	}
+
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
 func setupLogger(env string) *slog.Logger {
 	var log *slog.Logger
 
@@ -0,0 +1,1033 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v6.32.1
+// source: ssoadmin/ssoadmin.proto
+
+package ssoadminv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ImpersonateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AppId         int32                  `protobuf:"varint,2,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImpersonateRequest) Reset() {
+	*x = ImpersonateRequest{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImpersonateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImpersonateRequest) ProtoMessage() {}
+
+func (x *ImpersonateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImpersonateRequest.ProtoReflect.Descriptor instead.
+func (*ImpersonateRequest) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ImpersonateRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ImpersonateRequest) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *ImpersonateRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ImpersonateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImpersonateResponse) Reset() {
+	*x = ImpersonateResponse{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImpersonateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImpersonateResponse) ProtoMessage() {}
+
+func (x *ImpersonateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImpersonateResponse.ProtoReflect.Descriptor instead.
+func (*ImpersonateResponse) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ImpersonateResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type CreateOrganizationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateOrganizationRequest) Reset() {
+	*x = CreateOrganizationRequest{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateOrganizationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateOrganizationRequest) ProtoMessage() {}
+
+func (x *CreateOrganizationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateOrganizationRequest.ProtoReflect.Descriptor instead.
+func (*CreateOrganizationRequest) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateOrganizationRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type CreateOrganizationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrgId         int64                  `protobuf:"varint,1,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateOrganizationResponse) Reset() {
+	*x = CreateOrganizationResponse{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateOrganizationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateOrganizationResponse) ProtoMessage() {}
+
+func (x *CreateOrganizationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateOrganizationResponse.ProtoReflect.Descriptor instead.
+func (*CreateOrganizationResponse) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateOrganizationResponse) GetOrgId() int64 {
+	if x != nil {
+		return x.OrgId
+	}
+	return 0
+}
+
+// InviteMember invites a registered user to the organization of the caller's
+// token. The user joins it by calling AcceptMembership.
+type InviteMemberRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InviteMemberRequest) Reset() {
+	*x = InviteMemberRequest{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InviteMemberRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InviteMemberRequest) ProtoMessage() {}
+
+func (x *InviteMemberRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InviteMemberRequest.ProtoReflect.Descriptor instead.
+func (*InviteMemberRequest) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *InviteMemberRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *InviteMemberRequest) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+type InviteMemberResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InviteMemberResponse) Reset() {
+	*x = InviteMemberResponse{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InviteMemberResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InviteMemberResponse) ProtoMessage() {}
+
+func (x *InviteMemberResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InviteMemberResponse.ProtoReflect.Descriptor instead.
+func (*InviteMemberResponse) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *InviteMemberResponse) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+// InviteUser creates a pending account in the organization of the caller's
+// token and returns the invitation token to deliver to the user.
+type InviteUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Surname       string                 `protobuf:"bytes,3,opt,name=surname,proto3" json:"surname,omitempty"`
+	Role          string                 `protobuf:"bytes,4,opt,name=role,proto3" json:"role,omitempty"`
+	AppIds        []int32                `protobuf:"varint,5,rep,packed,name=app_ids,json=appIds,proto3" json:"app_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InviteUserRequest) Reset() {
+	*x = InviteUserRequest{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InviteUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InviteUserRequest) ProtoMessage() {}
+
+func (x *InviteUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InviteUserRequest.ProtoReflect.Descriptor instead.
+func (*InviteUserRequest) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *InviteUserRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *InviteUserRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *InviteUserRequest) GetSurname() string {
+	if x != nil {
+		return x.Surname
+	}
+	return ""
+}
+
+func (x *InviteUserRequest) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *InviteUserRequest) GetAppIds() []int32 {
+	if x != nil {
+		return x.AppIds
+	}
+	return nil
+}
+
+type InviteUserResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InviteUserResponse) Reset() {
+	*x = InviteUserResponse{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InviteUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InviteUserResponse) ProtoMessage() {}
+
+func (x *InviteUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InviteUserResponse.ProtoReflect.Descriptor instead.
+func (*InviteUserResponse) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *InviteUserResponse) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *InviteUserResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type AcceptInvitationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcceptInvitationRequest) Reset() {
+	*x = AcceptInvitationRequest{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcceptInvitationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcceptInvitationRequest) ProtoMessage() {}
+
+func (x *AcceptInvitationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcceptInvitationRequest.ProtoReflect.Descriptor instead.
+func (*AcceptInvitationRequest) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AcceptInvitationRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *AcceptInvitationRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type AcceptInvitationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcceptInvitationResponse) Reset() {
+	*x = AcceptInvitationResponse{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcceptInvitationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcceptInvitationResponse) ProtoMessage() {}
+
+func (x *AcceptInvitationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcceptInvitationResponse.ProtoReflect.Descriptor instead.
+func (*AcceptInvitationResponse) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AcceptInvitationResponse) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type AcceptMembershipRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrgId         int64                  `protobuf:"varint,1,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcceptMembershipRequest) Reset() {
+	*x = AcceptMembershipRequest{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcceptMembershipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcceptMembershipRequest) ProtoMessage() {}
+
+func (x *AcceptMembershipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcceptMembershipRequest.ProtoReflect.Descriptor instead.
+func (*AcceptMembershipRequest) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AcceptMembershipRequest) GetOrgId() int64 {
+	if x != nil {
+		return x.OrgId
+	}
+	return 0
+}
+
+type AcceptMembershipResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcceptMembershipResponse) Reset() {
+	*x = AcceptMembershipResponse{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcceptMembershipResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcceptMembershipResponse) ProtoMessage() {}
+
+func (x *AcceptMembershipResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcceptMembershipResponse.ProtoReflect.Descriptor instead.
+func (*AcceptMembershipResponse) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{11}
+}
+
+// App access grants are only checked at login to apps marked as restricted.
+// Only admins of the organization that owns the app may change them.
+type GrantAppAccessRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AppId         int32                  `protobuf:"varint,2,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrantAppAccessRequest) Reset() {
+	*x = GrantAppAccessRequest{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrantAppAccessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrantAppAccessRequest) ProtoMessage() {}
+
+func (x *GrantAppAccessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrantAppAccessRequest.ProtoReflect.Descriptor instead.
+func (*GrantAppAccessRequest) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GrantAppAccessRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *GrantAppAccessRequest) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+type GrantAppAccessResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrantAppAccessResponse) Reset() {
+	*x = GrantAppAccessResponse{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrantAppAccessResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrantAppAccessResponse) ProtoMessage() {}
+
+func (x *GrantAppAccessResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrantAppAccessResponse.ProtoReflect.Descriptor instead.
+func (*GrantAppAccessResponse) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{13}
+}
+
+type RevokeAppAccessRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AppId         int32                  `protobuf:"varint,2,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeAppAccessRequest) Reset() {
+	*x = RevokeAppAccessRequest{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeAppAccessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeAppAccessRequest) ProtoMessage() {}
+
+func (x *RevokeAppAccessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeAppAccessRequest.ProtoReflect.Descriptor instead.
+func (*RevokeAppAccessRequest) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RevokeAppAccessRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *RevokeAppAccessRequest) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+type RevokeAppAccessResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeAppAccessResponse) Reset() {
+	*x = RevokeAppAccessResponse{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeAppAccessResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeAppAccessResponse) ProtoMessage() {}
+
+func (x *RevokeAppAccessResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeAppAccessResponse.ProtoReflect.Descriptor instead.
+func (*RevokeAppAccessResponse) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{15}
+}
+
+// SetAppRestricted makes the app accept only users granted access to it.
+type SetAppRestrictedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         int32                  `protobuf:"varint,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	Restricted    bool                   `protobuf:"varint,2,opt,name=restricted,proto3" json:"restricted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetAppRestrictedRequest) Reset() {
+	*x = SetAppRestrictedRequest{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetAppRestrictedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetAppRestrictedRequest) ProtoMessage() {}
+
+func (x *SetAppRestrictedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetAppRestrictedRequest.ProtoReflect.Descriptor instead.
+func (*SetAppRestrictedRequest) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SetAppRestrictedRequest) GetAppId() int32 {
+	if x != nil {
+		return x.AppId
+	}
+	return 0
+}
+
+func (x *SetAppRestrictedRequest) GetRestricted() bool {
+	if x != nil {
+		return x.Restricted
+	}
+	return false
+}
+
+type SetAppRestrictedResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetAppRestrictedResponse) Reset() {
+	*x = SetAppRestrictedResponse{}
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetAppRestrictedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetAppRestrictedResponse) ProtoMessage() {}
+
+func (x *SetAppRestrictedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ssoadmin_ssoadmin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetAppRestrictedResponse.ProtoReflect.Descriptor instead.
+func (*SetAppRestrictedResponse) Descriptor() ([]byte, []int) {
+	return file_ssoadmin_ssoadmin_proto_rawDescGZIP(), []int{17}
+}
+
+var File_ssoadmin_ssoadmin_proto protoreflect.FileDescriptor
+
+const file_ssoadmin_ssoadmin_proto_rawDesc = "" +
+	"\n" +
+	"\x17ssoadmin/ssoadmin.proto\x12\bssoadmin\"\\\n" +
+	"\x12ImpersonateRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x15\n" +
+	"\x06app_id\x18\x02 \x01(\x05R\x05appId\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"+\n" +
+	"\x13ImpersonateResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"/\n" +
+	"\x19CreateOrganizationRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"3\n" +
+	"\x1aCreateOrganizationResponse\x12\x15\n" +
+	"\x06org_id\x18\x01 \x01(\x03R\x05orgId\"?\n" +
+	"\x13InviteMemberRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x12\n" +
+	"\x04role\x18\x02 \x01(\tR\x04role\"/\n" +
+	"\x14InviteMemberResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\"\x84\x01\n" +
+	"\x11InviteUserRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\asurname\x18\x03 \x01(\tR\asurname\x12\x12\n" +
+	"\x04role\x18\x04 \x01(\tR\x04role\x12\x17\n" +
+	"\aapp_ids\x18\x05 \x03(\x05R\x06appIds\"C\n" +
+	"\x12InviteUserResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\"K\n" +
+	"\x17AcceptInvitationRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"3\n" +
+	"\x18AcceptInvitationResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\"0\n" +
+	"\x17AcceptMembershipRequest\x12\x15\n" +
+	"\x06org_id\x18\x01 \x01(\x03R\x05orgId\"\x1a\n" +
+	"\x18AcceptMembershipResponse\"G\n" +
+	"\x15GrantAppAccessRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x15\n" +
+	"\x06app_id\x18\x02 \x01(\x05R\x05appId\"\x18\n" +
+	"\x16GrantAppAccessResponse\"H\n" +
+	"\x16RevokeAppAccessRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x15\n" +
+	"\x06app_id\x18\x02 \x01(\x05R\x05appId\"\x19\n" +
+	"\x17RevokeAppAccessResponse\"P\n" +
+	"\x17SetAppRestrictedRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\x05R\x05appId\x12\x1e\n" +
+	"\n" +
+	"restricted\x18\x02 \x01(\bR\n" +
+	"restricted\"\x1a\n" +
+	"\x18SetAppRestrictedResponse2\xd4\x04\n" +
+	"\x05Admin\x12J\n" +
+	"\vImpersonate\x12\x1c.ssoadmin.ImpersonateRequest\x1a\x1d.ssoadmin.ImpersonateResponse\x12_\n" +
+	"\x12CreateOrganization\x12#.ssoadmin.CreateOrganizationRequest\x1a$.ssoadmin.CreateOrganizationResponse\x12M\n" +
+	"\fInviteMember\x12\x1d.ssoadmin.InviteMemberRequest\x1a\x1e.ssoadmin.InviteMemberResponse\x12G\n" +
+	"\n" +
+	"InviteUser\x12\x1b.ssoadmin.InviteUserRequest\x1a\x1c.ssoadmin.InviteUserResponse\x12S\n" +
+	"\x0eGrantAppAccess\x12\x1f.ssoadmin.GrantAppAccessRequest\x1a .ssoadmin.GrantAppAccessResponse\x12V\n" +
+	"\x0fRevokeAppAccess\x12 .ssoadmin.RevokeAppAccessRequest\x1a!.ssoadmin.RevokeAppAccessResponse\x12Y\n" +
+	"\x10SetAppRestricted\x12!.ssoadmin.SetAppRestrictedRequest\x1a\".ssoadmin.SetAppRestrictedResponse2\xc2\x01\n" +
+	"\n" +
+	"Invitation\x12Y\n" +
+	"\x10AcceptInvitation\x12!.ssoadmin.AcceptInvitationRequest\x1a\".ssoadmin.AcceptInvitationResponse\x12Y\n" +
+	"\x10AcceptMembership\x12!.ssoadmin.AcceptMembershipRequest\x1a\".ssoadmin.AcceptMembershipResponseB Z\x1esso/gen/go/ssoadmin;ssoadminv1b\x06proto3"
+
+var (
+	file_ssoadmin_ssoadmin_proto_rawDescOnce sync.Once
+	file_ssoadmin_ssoadmin_proto_rawDescData []byte
+)
+
+func file_ssoadmin_ssoadmin_proto_rawDescGZIP() []byte {
+	file_ssoadmin_ssoadmin_proto_rawDescOnce.Do(func() {
+		file_ssoadmin_ssoadmin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ssoadmin_ssoadmin_proto_rawDesc), len(file_ssoadmin_ssoadmin_proto_rawDesc)))
+	})
+	return file_ssoadmin_ssoadmin_proto_rawDescData
+}
+
+var file_ssoadmin_ssoadmin_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_ssoadmin_ssoadmin_proto_goTypes = []any{
+	(*ImpersonateRequest)(nil),         // 0: ssoadmin.ImpersonateRequest
+	(*ImpersonateResponse)(nil),        // 1: ssoadmin.ImpersonateResponse
+	(*CreateOrganizationRequest)(nil),  // 2: ssoadmin.CreateOrganizationRequest
+	(*CreateOrganizationResponse)(nil), // 3: ssoadmin.CreateOrganizationResponse
+	(*InviteMemberRequest)(nil),        // 4: ssoadmin.InviteMemberRequest
+	(*InviteMemberResponse)(nil),       // 5: ssoadmin.InviteMemberResponse
+	(*InviteUserRequest)(nil),          // 6: ssoadmin.InviteUserRequest
+	(*InviteUserResponse)(nil),         // 7: ssoadmin.InviteUserResponse
+	(*AcceptInvitationRequest)(nil),    // 8: ssoadmin.AcceptInvitationRequest
+	(*AcceptInvitationResponse)(nil),   // 9: ssoadmin.AcceptInvitationResponse
+	(*AcceptMembershipRequest)(nil),    // 10: ssoadmin.AcceptMembershipRequest
+	(*AcceptMembershipResponse)(nil),   // 11: ssoadmin.AcceptMembershipResponse
+	(*GrantAppAccessRequest)(nil),      // 12: ssoadmin.GrantAppAccessRequest
+	(*GrantAppAccessResponse)(nil),     // 13: ssoadmin.GrantAppAccessResponse
+	(*RevokeAppAccessRequest)(nil),     // 14: ssoadmin.RevokeAppAccessRequest
+	(*RevokeAppAccessResponse)(nil),    // 15: ssoadmin.RevokeAppAccessResponse
+	(*SetAppRestrictedRequest)(nil),    // 16: ssoadmin.SetAppRestrictedRequest
+	(*SetAppRestrictedResponse)(nil),   // 17: ssoadmin.SetAppRestrictedResponse
+}
+var file_ssoadmin_ssoadmin_proto_depIdxs = []int32{
+	0,  // 0: ssoadmin.Admin.Impersonate:input_type -> ssoadmin.ImpersonateRequest
+	2,  // 1: ssoadmin.Admin.CreateOrganization:input_type -> ssoadmin.CreateOrganizationRequest
+	4,  // 2: ssoadmin.Admin.InviteMember:input_type -> ssoadmin.InviteMemberRequest
+	6,  // 3: ssoadmin.Admin.InviteUser:input_type -> ssoadmin.InviteUserRequest
+	12, // 4: ssoadmin.Admin.GrantAppAccess:input_type -> ssoadmin.GrantAppAccessRequest
+	14, // 5: ssoadmin.Admin.RevokeAppAccess:input_type -> ssoadmin.RevokeAppAccessRequest
+	16, // 6: ssoadmin.Admin.SetAppRestricted:input_type -> ssoadmin.SetAppRestrictedRequest
+	8,  // 7: ssoadmin.Invitation.AcceptInvitation:input_type -> ssoadmin.AcceptInvitationRequest
+	10, // 8: ssoadmin.Invitation.AcceptMembership:input_type -> ssoadmin.AcceptMembershipRequest
+	1,  // 9: ssoadmin.Admin.Impersonate:output_type -> ssoadmin.ImpersonateResponse
+	3,  // 10: ssoadmin.Admin.CreateOrganization:output_type -> ssoadmin.CreateOrganizationResponse
+	5,  // 11: ssoadmin.Admin.InviteMember:output_type -> ssoadmin.InviteMemberResponse
+	7,  // 12: ssoadmin.Admin.InviteUser:output_type -> ssoadmin.InviteUserResponse
+	13, // 13: ssoadmin.Admin.GrantAppAccess:output_type -> ssoadmin.GrantAppAccessResponse
+	15, // 14: ssoadmin.Admin.RevokeAppAccess:output_type -> ssoadmin.RevokeAppAccessResponse
+	17, // 15: ssoadmin.Admin.SetAppRestricted:output_type -> ssoadmin.SetAppRestrictedResponse
+	9,  // 16: ssoadmin.Invitation.AcceptInvitation:output_type -> ssoadmin.AcceptInvitationResponse
+	11, // 17: ssoadmin.Invitation.AcceptMembership:output_type -> ssoadmin.AcceptMembershipResponse
+	9,  // [9:18] is the sub-list for method output_type
+	0,  // [0:9] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_ssoadmin_ssoadmin_proto_init() }
+func file_ssoadmin_ssoadmin_proto_init() {
+	if File_ssoadmin_ssoadmin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ssoadmin_ssoadmin_proto_rawDesc), len(file_ssoadmin_ssoadmin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_ssoadmin_ssoadmin_proto_goTypes,
+		DependencyIndexes: file_ssoadmin_ssoadmin_proto_depIdxs,
+		MessageInfos:      file_ssoadmin_ssoadmin_proto_msgTypes,
+	}.Build()
+	File_ssoadmin_ssoadmin_proto = out.File
+	file_ssoadmin_ssoadmin_proto_goTypes = nil
+	file_ssoadmin_ssoadmin_proto_depIdxs = nil
+}
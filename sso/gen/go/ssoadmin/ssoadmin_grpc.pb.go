@@ -0,0 +1,501 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.32.1
+// source: ssoadmin/ssoadmin.proto
+
+package ssoadminv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Admin_Impersonate_FullMethodName        = "/ssoadmin.Admin/Impersonate"
+	Admin_CreateOrganization_FullMethodName = "/ssoadmin.Admin/CreateOrganization"
+	Admin_InviteMember_FullMethodName       = "/ssoadmin.Admin/InviteMember"
+	Admin_InviteUser_FullMethodName         = "/ssoadmin.Admin/InviteUser"
+	Admin_GrantAppAccess_FullMethodName     = "/ssoadmin.Admin/GrantAppAccess"
+	Admin_RevokeAppAccess_FullMethodName    = "/ssoadmin.Admin/RevokeAppAccess"
+	Admin_SetAppRestricted_FullMethodName   = "/ssoadmin.Admin/SetAppRestricted"
+)
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Admin RPCs require an admin token in the "authorization" metadata.
+type AdminClient interface {
+	Impersonate(ctx context.Context, in *ImpersonateRequest, opts ...grpc.CallOption) (*ImpersonateResponse, error)
+	CreateOrganization(ctx context.Context, in *CreateOrganizationRequest, opts ...grpc.CallOption) (*CreateOrganizationResponse, error)
+	InviteMember(ctx context.Context, in *InviteMemberRequest, opts ...grpc.CallOption) (*InviteMemberResponse, error)
+	InviteUser(ctx context.Context, in *InviteUserRequest, opts ...grpc.CallOption) (*InviteUserResponse, error)
+	GrantAppAccess(ctx context.Context, in *GrantAppAccessRequest, opts ...grpc.CallOption) (*GrantAppAccessResponse, error)
+	RevokeAppAccess(ctx context.Context, in *RevokeAppAccessRequest, opts ...grpc.CallOption) (*RevokeAppAccessResponse, error)
+	SetAppRestricted(ctx context.Context, in *SetAppRestrictedRequest, opts ...grpc.CallOption) (*SetAppRestrictedResponse, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) Impersonate(ctx context.Context, in *ImpersonateRequest, opts ...grpc.CallOption) (*ImpersonateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImpersonateResponse)
+	err := c.cc.Invoke(ctx, Admin_Impersonate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) CreateOrganization(ctx context.Context, in *CreateOrganizationRequest, opts ...grpc.CallOption) (*CreateOrganizationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateOrganizationResponse)
+	err := c.cc.Invoke(ctx, Admin_CreateOrganization_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) InviteMember(ctx context.Context, in *InviteMemberRequest, opts ...grpc.CallOption) (*InviteMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InviteMemberResponse)
+	err := c.cc.Invoke(ctx, Admin_InviteMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) InviteUser(ctx context.Context, in *InviteUserRequest, opts ...grpc.CallOption) (*InviteUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InviteUserResponse)
+	err := c.cc.Invoke(ctx, Admin_InviteUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GrantAppAccess(ctx context.Context, in *GrantAppAccessRequest, opts ...grpc.CallOption) (*GrantAppAccessResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GrantAppAccessResponse)
+	err := c.cc.Invoke(ctx, Admin_GrantAppAccess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) RevokeAppAccess(ctx context.Context, in *RevokeAppAccessRequest, opts ...grpc.CallOption) (*RevokeAppAccessResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeAppAccessResponse)
+	err := c.cc.Invoke(ctx, Admin_RevokeAppAccess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) SetAppRestricted(ctx context.Context, in *SetAppRestrictedRequest, opts ...grpc.CallOption) (*SetAppRestrictedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetAppRestrictedResponse)
+	err := c.cc.Invoke(ctx, Admin_SetAppRestricted_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+// All implementations must embed UnimplementedAdminServer
+// for forward compatibility.
+//
+// Admin RPCs require an admin token in the "authorization" metadata.
+type AdminServer interface {
+	Impersonate(context.Context, *ImpersonateRequest) (*ImpersonateResponse, error)
+	CreateOrganization(context.Context, *CreateOrganizationRequest) (*CreateOrganizationResponse, error)
+	InviteMember(context.Context, *InviteMemberRequest) (*InviteMemberResponse, error)
+	InviteUser(context.Context, *InviteUserRequest) (*InviteUserResponse, error)
+	GrantAppAccess(context.Context, *GrantAppAccessRequest) (*GrantAppAccessResponse, error)
+	RevokeAppAccess(context.Context, *RevokeAppAccessRequest) (*RevokeAppAccessResponse, error)
+	SetAppRestricted(context.Context, *SetAppRestrictedRequest) (*SetAppRestrictedResponse, error)
+	mustEmbedUnimplementedAdminServer()
+}
+
+// UnimplementedAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServer struct{}
+
+func (UnimplementedAdminServer) Impersonate(context.Context, *ImpersonateRequest) (*ImpersonateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Impersonate not implemented")
+}
+func (UnimplementedAdminServer) CreateOrganization(context.Context, *CreateOrganizationRequest) (*CreateOrganizationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateOrganization not implemented")
+}
+func (UnimplementedAdminServer) InviteMember(context.Context, *InviteMemberRequest) (*InviteMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InviteMember not implemented")
+}
+func (UnimplementedAdminServer) InviteUser(context.Context, *InviteUserRequest) (*InviteUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InviteUser not implemented")
+}
+func (UnimplementedAdminServer) GrantAppAccess(context.Context, *GrantAppAccessRequest) (*GrantAppAccessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GrantAppAccess not implemented")
+}
+func (UnimplementedAdminServer) RevokeAppAccess(context.Context, *RevokeAppAccessRequest) (*RevokeAppAccessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeAppAccess not implemented")
+}
+func (UnimplementedAdminServer) SetAppRestricted(context.Context, *SetAppRestrictedRequest) (*SetAppRestrictedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAppRestricted not implemented")
+}
+func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
+func (UnimplementedAdminServer) testEmbeddedByValue()               {}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Admin_ServiceDesc, srv)
+}
+
+func _Admin_Impersonate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImpersonateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Impersonate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_Impersonate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Impersonate(ctx, req.(*ImpersonateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_CreateOrganization_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateOrganizationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).CreateOrganization(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_CreateOrganization_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).CreateOrganization(ctx, req.(*CreateOrganizationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_InviteMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InviteMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).InviteMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_InviteMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).InviteMember(ctx, req.(*InviteMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_InviteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InviteUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).InviteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_InviteUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).InviteUser(ctx, req.(*InviteUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GrantAppAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GrantAppAccessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GrantAppAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GrantAppAccess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GrantAppAccess(ctx, req.(*GrantAppAccessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_RevokeAppAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeAppAccessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).RevokeAppAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_RevokeAppAccess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).RevokeAppAccess(ctx, req.(*RevokeAppAccessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_SetAppRestricted_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetAppRestrictedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).SetAppRestricted(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_SetAppRestricted_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).SetAppRestricted(ctx, req.(*SetAppRestrictedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Admin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ssoadmin.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Impersonate",
+			Handler:    _Admin_Impersonate_Handler,
+		},
+		{
+			MethodName: "CreateOrganization",
+			Handler:    _Admin_CreateOrganization_Handler,
+		},
+		{
+			MethodName: "InviteMember",
+			Handler:    _Admin_InviteMember_Handler,
+		},
+		{
+			MethodName: "InviteUser",
+			Handler:    _Admin_InviteUser_Handler,
+		},
+		{
+			MethodName: "GrantAppAccess",
+			Handler:    _Admin_GrantAppAccess_Handler,
+		},
+		{
+			MethodName: "RevokeAppAccess",
+			Handler:    _Admin_RevokeAppAccess_Handler,
+		},
+		{
+			MethodName: "SetAppRestricted",
+			Handler:    _Admin_SetAppRestricted_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "ssoadmin/ssoadmin.proto",
+}
+
+const (
+	Invitation_AcceptInvitation_FullMethodName = "/ssoadmin.Invitation/AcceptInvitation"
+	Invitation_AcceptMembership_FullMethodName = "/ssoadmin.Invitation/AcceptMembership"
+)
+
+// InvitationClient is the client API for Invitation service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Invitation RPCs are called by invited users. AcceptInvitation needs no
+// token; AcceptMembership requires the invited user's own token in the
+// "authorization" metadata.
+type InvitationClient interface {
+	AcceptInvitation(ctx context.Context, in *AcceptInvitationRequest, opts ...grpc.CallOption) (*AcceptInvitationResponse, error)
+	AcceptMembership(ctx context.Context, in *AcceptMembershipRequest, opts ...grpc.CallOption) (*AcceptMembershipResponse, error)
+}
+
+type invitationClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewInvitationClient(cc grpc.ClientConnInterface) InvitationClient {
+	return &invitationClient{cc}
+}
+
+func (c *invitationClient) AcceptInvitation(ctx context.Context, in *AcceptInvitationRequest, opts ...grpc.CallOption) (*AcceptInvitationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AcceptInvitationResponse)
+	err := c.cc.Invoke(ctx, Invitation_AcceptInvitation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *invitationClient) AcceptMembership(ctx context.Context, in *AcceptMembershipRequest, opts ...grpc.CallOption) (*AcceptMembershipResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AcceptMembershipResponse)
+	err := c.cc.Invoke(ctx, Invitation_AcceptMembership_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InvitationServer is the server API for Invitation service.
+// All implementations must embed UnimplementedInvitationServer
+// for forward compatibility.
+//
+// Invitation RPCs are called by invited users. AcceptInvitation needs no
+// token; AcceptMembership requires the invited user's own token in the
+// "authorization" metadata.
+type InvitationServer interface {
+	AcceptInvitation(context.Context, *AcceptInvitationRequest) (*AcceptInvitationResponse, error)
+	AcceptMembership(context.Context, *AcceptMembershipRequest) (*AcceptMembershipResponse, error)
+	mustEmbedUnimplementedInvitationServer()
+}
+
+// UnimplementedInvitationServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedInvitationServer struct{}
+
+func (UnimplementedInvitationServer) AcceptInvitation(context.Context, *AcceptInvitationRequest) (*AcceptInvitationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptInvitation not implemented")
+}
+func (UnimplementedInvitationServer) AcceptMembership(context.Context, *AcceptMembershipRequest) (*AcceptMembershipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptMembership not implemented")
+}
+func (UnimplementedInvitationServer) mustEmbedUnimplementedInvitationServer() {}
+func (UnimplementedInvitationServer) testEmbeddedByValue()                    {}
+
+// UnsafeInvitationServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to InvitationServer will
+// result in compilation errors.
+type UnsafeInvitationServer interface {
+	mustEmbedUnimplementedInvitationServer()
+}
+
+func RegisterInvitationServer(s grpc.ServiceRegistrar, srv InvitationServer) {
+	// If the following call pancis, it indicates UnimplementedInvitationServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Invitation_ServiceDesc, srv)
+}
+
+func _Invitation_AcceptInvitation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcceptInvitationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InvitationServer).AcceptInvitation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Invitation_AcceptInvitation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InvitationServer).AcceptInvitation(ctx, req.(*AcceptInvitationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Invitation_AcceptMembership_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcceptMembershipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InvitationServer).AcceptMembership(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Invitation_AcceptMembership_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InvitationServer).AcceptMembership(ctx, req.(*AcceptMembershipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Invitation_ServiceDesc is the grpc.ServiceDesc for Invitation service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Invitation_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ssoadmin.Invitation",
+	HandlerType: (*InvitationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AcceptInvitation",
+			Handler:    _Invitation_AcceptInvitation_Handler,
+		},
+		{
+			MethodName: "AcceptMembership",
+			Handler:    _Invitation_AcceptMembership_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "ssoadmin/ssoadmin.proto",
+}
@@ -4,8 +4,13 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	admingrpc "sso/internal/grpc/admin"
 	authgrpc "sso/internal/grpc/auth"
+	ldapclient "sso/internal/lib/ldap"
+	"sso/internal/middleware"
+	"sso/internal/services/admin"
 	"sso/internal/services/auth"
+	"sso/internal/services/invitation"
 	"sso/internal/storage/sqlite"
 	"time"
 
@@ -19,9 +24,15 @@ type App struct {
 }
 
 type AppConfig struct {
-	GrpcPort    int
-	StoragePath string
-	TokenTTL    time.Duration
+	GrpcPort            int
+	StoragePath         string
+	TokenTTL            time.Duration
+	ImpersonationTTL    time.Duration
+	InvitationTTL       time.Duration
+	InvitationSecret    string
+	CredentialVerifiers []string
+	LDAP                ldapclient.Config
+	LDAPGroupRoles      map[string]string
 }
 
 func New(log *slog.Logger, appConfig AppConfig) (*App, error) {
@@ -30,10 +41,20 @@ func New(log *slog.Logger, appConfig AppConfig) (*App, error) {
 		return &App{}, fmt.Errorf("create storage: %w", err)
 	}
 
-	authService := auth.New(log, storage, storage, storage, appConfig.TokenTTL)
-	gRPCServer := grpc.NewServer()
+	verifiers, err := newCredentialVerifiers(appConfig, storage)
+	if err != nil {
+		return &App{}, fmt.Errorf("create credential verifiers: %w", err)
+	}
+
+	authService := auth.New(log, storage, storage, storage, storage, appConfig.TokenTTL, verifiers...)
+	adminService := admin.New(
+		log, storage, storage, storage, storage, storage, appConfig.ImpersonationTTL, appConfig.InvitationTTL)
+	invitationService := invitation.New(
+		log, storage, storage, storage, appConfig.InvitationSecret, appConfig.InvitationTTL)
+	gRPCServer := grpc.NewServer(grpc.UnaryInterceptor(middleware.CorrelationInterceptor()))
 
 	authgrpc.Register(gRPCServer, authService)
+	admingrpc.Register(gRPCServer, adminService, invitationService)
 
 	return &App{
 		log:        log,
@@ -42,6 +63,27 @@ func New(log *slog.Logger, appConfig AppConfig) (*App, error) {
 	}, nil
 }
 
+func newCredentialVerifiers(appConfig AppConfig, storage *sqlite.Storage) ([]auth.CredentialVerifier, error) {
+	verifiers := make([]auth.CredentialVerifier, 0, len(appConfig.CredentialVerifiers))
+
+	for _, name := range appConfig.CredentialVerifiers {
+		switch name {
+		case "local":
+			verifiers = append(verifiers, auth.NewLocalVerifier(storage))
+		case "ldap":
+			directory, err := ldapclient.New(appConfig.LDAP)
+			if err != nil {
+				return nil, fmt.Errorf("create ldap client: %w", err)
+			}
+			verifiers = append(verifiers, auth.NewLDAPVerifier(directory, storage, appConfig.LDAPGroupRoles))
+		default:
+			return nil, fmt.Errorf("unknown credential verifier %q", name)
+		}
+	}
+
+	return verifiers, nil
+}
+
 func (a *App) Run() error {
 	const op = "grpcapp.Run"
 
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"flag"
 	"os"
 	"time"
@@ -9,11 +10,23 @@ import (
 )
 
 type Config struct {
-	Env         string        `yaml:"env"`
-	StoragePath string        `yaml:"storage_path"`
-	TokenTTL    time.Duration `yaml:"token_ttl"`
-	GRPC        GRPCConfig    `yaml:"grpc"`
-	Kafka       KafkaConfig   `yaml:"kafka"`
+	Env              string        `yaml:"env"`
+	StoragePath      string        `yaml:"storage_path"`
+	TokenTTL         time.Duration `yaml:"token_ttl"`
+	ImpersonationTTL time.Duration `yaml:"impersonation_ttl" env-default:"15m"`
+	InvitationTTL    time.Duration `yaml:"invitation_ttl" env-default:"72h"`
+	// InvitationSecret signs invitation tokens. Required.
+	InvitationSecret string             `yaml:"invitation_secret" env:"INVITATION_SECRET"`
+	GRPC             GRPCConfig         `yaml:"grpc"`
+	Bus              BusConfig          `yaml:"bus"`
+	Kafka            KafkaConfig        `yaml:"kafka"`
+	NATS             NATSConfig         `yaml:"nats"`
+	EventSender      EventSenderConfig  `yaml:"event_sender"`
+	EventCleaner     EventCleanerConfig `yaml:"event_cleaner"`
+	Metrics          MetricsConfig      `yaml:"metrics"`
+	// CredentialVerifiers are tried in order at login ("local", "ldap").
+	CredentialVerifiers []string   `yaml:"credential_verifiers" env-default:"local"`
+	LDAP                LDAPConfig `yaml:"ldap"`
 }
 
 type GRPCConfig struct {
@@ -21,10 +34,107 @@ type GRPCConfig struct {
 	Timeout time.Duration `yaml:"timeout"`
 }
 
+// BusConfig selects the message broker events are published to.
+type BusConfig struct {
+	// Driver is "kafka", "nats" or "memory". The memory bus keeps events
+	// in the process, for tests and running without a broker.
+	Driver string `yaml:"driver" env:"BUS_DRIVER" env-default:"kafka"`
+	// Encoding of published events: "json" or "protobuf".
+	Encoding string `yaml:"encoding" env:"BUS_ENCODING" env-default:"json"`
+}
+
 type KafkaConfig struct {
-	Brokers    []string `yaml:"brokers"`
-	Topic      string   `yaml:"topic"`
-	DialAdress string   `yaml:"dial_address"`
+	Brokers         []string `yaml:"brokers"`
+	Topic           string   `yaml:"topic"`
+	DeadLetterTopic string   `yaml:"dead_letter_topic" env-default:"sso_events_dlq"`
+	DialAdress      string   `yaml:"dial_address"`
+	// Partitions is the partition count of topics sso creates.
+	Partitions        int `yaml:"partitions" env-default:"3"`
+	ReplicationFactor int `yaml:"replication_factor" env-default:"1"`
+	// Retention of the event and dead-letter topics; 0 leaves the broker
+	// default.
+	Retention           time.Duration `yaml:"retention" env-default:"0"`
+	DeadLetterRetention time.Duration `yaml:"dead_letter_retention" env-default:"0"`
+	// TopicStrictness is "warn" or "fail": whether topics that don't
+	// match these settings are logged or stop the startup.
+	TopicStrictness string `yaml:"topic_strictness" env:"KAFKA_TOPIC_STRICTNESS" env-default:"warn"`
+	// Idempotent disables retries inside the Kafka writer, leaving them
+	// to the outbox relay, whose retries keep the event id.
+	Idempotent bool            `yaml:"idempotent" env-default:"false"`
+	TLS        KafkaTLSConfig  `yaml:"tls"`
+	SASL       KafkaSASLConfig `yaml:"sasl"`
+}
+
+// KafkaTLSConfig enables TLS to the brokers. CAFile replaces the system
+// roots; CertFile and KeyFile are the client certificate.
+type KafkaTLSConfig struct {
+	Enabled            bool   `yaml:"enabled" env:"KAFKA_TLS_ENABLED"`
+	CAFile             string `yaml:"ca_file" env:"KAFKA_TLS_CA_FILE"`
+	CertFile           string `yaml:"cert_file" env:"KAFKA_TLS_CERT_FILE"`
+	KeyFile            string `yaml:"key_file" env:"KAFKA_TLS_KEY_FILE"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// KafkaSASLConfig authenticates to the brokers. Mechanism is PLAIN,
+// SCRAM-SHA-256 or SCRAM-SHA-512; empty disables SASL.
+type KafkaSASLConfig struct {
+	Mechanism string `yaml:"mechanism" env:"KAFKA_SASL_MECHANISM"`
+	Username  string `yaml:"username" env:"KAFKA_SASL_USERNAME"`
+	Password  string `yaml:"password" env:"KAFKA_SASL_PASSWORD"`
+}
+
+// NATSConfig configures the NATS JetStream bus. Every subject gets a
+// stream named after it.
+type NATSConfig struct {
+	URL               string `yaml:"url" env-default:"nats://localhost:4222"`
+	Subject           string `yaml:"subject" env-default:"sso_events"`
+	DeadLetterSubject string `yaml:"dead_letter_subject" env-default:"sso_events_dlq"`
+}
+
+// EventSenderConfig tunes the outbox relay.
+type EventSenderConfig struct {
+	// RelayID identifies this replica in outbox leases. Defaults to
+	// the hostname and pid.
+	RelayID      string        `yaml:"relay_id" env:"EVENT_SENDER_RELAY_ID"`
+	BatchSize    int           `yaml:"batch_size" env-default:"100"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"5s"`
+	Lease        time.Duration `yaml:"lease" env-default:"30s"`
+	// MaxAttempts is the number of publish attempts before an event is
+	// dead-lettered.
+	MaxAttempts     int           `yaml:"max_attempts" env-default:"10"`
+	RetryBackoff    time.Duration `yaml:"retry_backoff" env-default:"1s"`
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff" env-default:"10m"`
+}
+
+// EventCleanerConfig tunes the retention of sent outbox events.
+type EventCleanerConfig struct {
+	Retention  time.Duration `yaml:"retention" env-default:"168h"`
+	Interval   time.Duration `yaml:"interval" env-default:"1h"`
+	BatchSize  int           `yaml:"batch_size" env-default:"500"`
+	BatchPause time.Duration `yaml:"batch_pause" env-default:"100ms"`
+	// Archive moves purged events to messages_archive instead of
+	// deleting them.
+	Archive bool `yaml:"archive" env-default:"false"`
+}
+
+type MetricsConfig struct {
+	Port int    `yaml:"port" env-default:"8083"`
+	Host string `yaml:"host" env-default:"localhost"`
+	// BacklogInterval is how often the outbox backlog is measured.
+	BacklogInterval time.Duration `yaml:"backlog_interval" env-default:"15s"`
+}
+
+type LDAPConfig struct {
+	URL            string        `yaml:"url"`
+	BindDN         string        `yaml:"bind_dn"`
+	BindPassword   string        `yaml:"bind_password" env:"LDAP_BIND_PASSWORD"`
+	BaseDN         string        `yaml:"base_dn"`
+	UserFilter     string        `yaml:"user_filter" env-default:"(mail=%s)"`
+	GroupAttribute string        `yaml:"group_attribute" env-default:"memberOf"`
+	Timeout        time.Duration `yaml:"timeout" env-default:"5s"`
+	// GroupRoles maps directory group DNs to local roles.
+	GroupRoles map[string]string `yaml:"group_roles"`
 }
 
 func MustLoad() *Config {
@@ -48,9 +158,34 @@ func MustLoadByPath(configPath string) *Config {
 		panic("Failed to read config: " + err.Error())
 	}
 
+	if err := cfg.validate(); err != nil {
+		panic("Invalid config: " + err.Error())
+	}
+
 	return &cfg
 }
 
+// validate rejects settings the services can't run with.
+func (c *Config) validate() error {
+	if c.InvitationSecret == "" {
+		return errors.New("invitation_secret is required")
+	}
+
+	if c.EventSender.BatchSize <= 0 {
+		return errors.New("event_sender.batch_size must be positive")
+	}
+
+	if c.EventSender.PollInterval <= 0 {
+		return errors.New("event_sender.poll_interval must be positive")
+	}
+
+	if c.EventSender.Lease <= 0 {
+		return errors.New("event_sender.lease must be positive")
+	}
+
+	return nil
+}
+
 func fetchConfigPath() string {
 	var res string
 
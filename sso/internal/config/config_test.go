@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	return path
+}
+
+func TestMustLoadByPath_Validates(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantPanic bool
+	}{
+		{
+			name:   "defaults",
+			config: "invitation_secret: secret\n",
+		},
+		{
+			name:      "no invitation secret",
+			config:    "env: local\n",
+			wantPanic: true,
+		},
+		{
+			// cleanenv replaces zero values with the env-default.
+			name:   "zero batch size",
+			config: "invitation_secret: secret\nevent_sender:\n  batch_size: 0\n",
+		},
+		{
+			name:      "negative batch size",
+			config:    "event_sender:\n  batch_size: -1\n",
+			wantPanic: true,
+		},
+		{
+			name:      "negative lease",
+			config:    "event_sender:\n  lease: -1s\n",
+			wantPanic: true,
+		},
+		{
+			name:      "negative poll interval",
+			config:    "event_sender:\n  poll_interval: -1s\n",
+			wantPanic: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.config)
+
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Errorf("MustLoadByPath() panic = %v, want panic %v", r, tt.wantPanic)
+				}
+			}()
+
+			MustLoadByPath(path)
+		})
+	}
+}
+
+func TestValidate_RejectsZeroBatchSize(t *testing.T) {
+	cfg := Config{
+		InvitationSecret: "secret",
+		EventSender:      EventSenderConfig{PollInterval: time.Second, Lease: time.Second},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("validate() with zero batch size error = nil, want error")
+	}
+}
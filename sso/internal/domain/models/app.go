@@ -4,4 +4,8 @@ type App struct {
 	ID     int64
 	Name   string
 	Secret string
+	// OrgID is the organization whose admins manage access to the app.
+	OrgID int64
+	// Restricted apps only accept users granted access to them.
+	Restricted bool
 }
@@ -1,7 +1,26 @@
 package models
 
+import "time"
+
 type Event struct {
 	ID      int64  `db:"id"`
 	Type    string `db:"event_type"`
 	Payload string `db:"payload"`
+	// PartitionKey is the message key; empty for events not tied to a
+	// user.
+	PartitionKey string `db:"partition_key"`
+	// TraceParent is the W3C trace context of the request that wrote the
+	// event; empty if it had none.
+	TraceParent string `db:"trace_parent"`
+	// Attempts is the number of failed publish attempts so far.
+	Attempts  int    `db:"attempts"`
+	LastError string `db:"last_error"`
+}
+
+// EventFailure is a failed publish attempt of an outbox event.
+type EventFailure struct {
+	EventID int64
+	Error   string
+	// RetryIn is the delay before the next attempt.
+	RetryIn time.Duration
 }
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Impersonation is an audit record of a token issued to Actor for User.
+type Impersonation struct {
+	ActorID   int64
+	OrgID     int64
+	UserID    int64
+	AppID     int64
+	Reason    string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Invitation onboards a pending user into an organization.
+type Invitation struct {
+	ID        int64
+	UserID    int64
+	OrgID     int64
+	Email     string
+	Role      string
+	AppIDs    []int64
+	InvitedBy int64
+	ExpiresAt time.Time
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+const (
+	// DefaultOrgID is the organization legacy and directory users belong
+	// to. Its admins are the platform admins.
+	DefaultOrgID int64 = 1
+)
+
+type Organization struct {
+	ID   int64
+	Name string
+}
+
+type Membership struct {
+	OrgID  int64
+	UserID int64
+	Role   string
+}
+
+// MembershipInvitation invites a registered user into an organization.
+// The user becomes a member only after accepting it.
+type MembershipInvitation struct {
+	OrgID     int64
+	UserID    int64
+	Role      string
+	InvitedBy int64
+	ExpiresAt time.Time
+}
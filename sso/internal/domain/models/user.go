@@ -1,7 +1,15 @@
 package models
 
+const (
+	UserStatusActive  = "active"
+	UserStatusPending = "pending"
+)
+
 type User struct {
 	ID       int64
 	Email    string
 	PassHash []byte
+	Status   string
+	// Directory is set for users provisioned from the LDAP directory.
+	Directory bool
 }
@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"context"
+	ssoadminv1 "sso/gen/go/ssoadmin"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// invitationAPI serves the invited side of invitations: the invitation
+// token or the invited user's own token proves who the caller is.
+type invitationAPI struct {
+	ssoadminv1.UnimplementedInvitationServer
+	admin       Admin
+	invitations Invitations
+}
+
+func (s *invitationAPI) AcceptInvitation(
+	ctx context.Context,
+	req *ssoadminv1.AcceptInvitationRequest,
+) (*ssoadminv1.AcceptInvitationResponse, error) {
+	if err := validateAcceptInvitation(req); err != nil {
+		return nil, err
+	}
+
+	userID, err := s.invitations.AcceptInvitation(ctx, req.GetToken(), req.GetPassword())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &ssoadminv1.AcceptInvitationResponse{
+		UserId: userID,
+	}, nil
+}
+
+func (s *invitationAPI) AcceptMembership(
+	ctx context.Context,
+	req *ssoadminv1.AcceptMembershipRequest,
+) (*ssoadminv1.AcceptMembershipResponse, error) {
+	if req.GetOrgId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "org_id is required")
+	}
+
+	caller, err := authenticate(ctx, s.admin)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.admin.AcceptMembership(ctx, caller.UserID, req.GetOrgId()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &ssoadminv1.AcceptMembershipResponse{}, nil
+}
+
+func validateAcceptInvitation(req *ssoadminv1.AcceptInvitationRequest) error {
+	if req.GetToken() == "" {
+		return status.Error(codes.InvalidArgument, "token is empty")
+	}
+
+	if req.GetPassword() == "" {
+		return status.Error(codes.InvalidArgument, "password is empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,370 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	ssoadminv1 "sso/gen/go/ssoadmin"
+	"sso/internal/lib/jwt"
+	"sso/internal/services/admin"
+	"sso/internal/services/invitation"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	emptyValue = 0
+)
+
+type Admin interface {
+	Caller(ctx context.Context, token string) (jwt.Claims, error)
+	Impersonate(
+		ctx context.Context,
+		actorID int64,
+		orgID int64,
+		userID int64,
+		appID int,
+		reason string,
+	) (token string, err error)
+	CreateOrganization(ctx context.Context, ownerID int64, name string) (orgID int64, err error)
+	InviteMember(
+		ctx context.Context,
+		actorID int64,
+		orgID int64,
+		email string,
+		role string,
+	) (userID int64, err error)
+	AcceptMembership(ctx context.Context, userID int64, orgID int64) error
+	GrantAppAccess(ctx context.Context, actorID int64, orgID int64, userID int64, appID int) error
+	RevokeAppAccess(ctx context.Context, actorID int64, orgID int64, userID int64, appID int) error
+	SetAppRestricted(ctx context.Context, actorID int64, orgID int64, appID int, restricted bool) error
+}
+
+type Invitations interface {
+	InviteUser(
+		ctx context.Context,
+		actorID int64,
+		orgID int64,
+		email string,
+		name string,
+		surname string,
+		role string,
+		appIDs []int64,
+	) (userID int64, token string, err error)
+	AcceptInvitation(ctx context.Context, token string, password string) (userID int64, err error)
+}
+
+type serverAPI struct {
+	ssoadminv1.UnimplementedAdminServer
+	admin       Admin
+	invitations Invitations
+}
+
+func Register(gRPC *grpc.Server, admin Admin, invitations Invitations) {
+	ssoadminv1.RegisterAdminServer(gRPC, &serverAPI{admin: admin, invitations: invitations})
+	ssoadminv1.RegisterInvitationServer(gRPC, &invitationAPI{admin: admin, invitations: invitations})
+}
+
+func (s *serverAPI) Impersonate(
+	ctx context.Context,
+	req *ssoadminv1.ImpersonateRequest,
+) (*ssoadminv1.ImpersonateResponse, error) {
+	if err := validateImpersonate(req); err != nil {
+		return nil, err
+	}
+
+	caller, err := authenticate(ctx, s.admin)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.admin.Impersonate(
+		ctx,
+		caller.UserID,
+		caller.OrgID,
+		req.GetUserId(),
+		int(req.GetAppId()),
+		req.GetReason(),
+	)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &ssoadminv1.ImpersonateResponse{
+		Token: token,
+	}, nil
+}
+
+func (s *serverAPI) CreateOrganization(
+	ctx context.Context,
+	req *ssoadminv1.CreateOrganizationRequest,
+) (*ssoadminv1.CreateOrganizationResponse, error) {
+	if err := validateCreateOrganization(req); err != nil {
+		return nil, err
+	}
+
+	caller, err := authenticate(ctx, s.admin)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := s.admin.CreateOrganization(ctx, caller.UserID, req.GetName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &ssoadminv1.CreateOrganizationResponse{
+		OrgId: orgID,
+	}, nil
+}
+
+func (s *serverAPI) InviteMember(
+	ctx context.Context,
+	req *ssoadminv1.InviteMemberRequest,
+) (*ssoadminv1.InviteMemberResponse, error) {
+	if err := validateInviteMember(req); err != nil {
+		return nil, err
+	}
+
+	caller, err := authenticate(ctx, s.admin)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := s.admin.InviteMember(ctx, caller.UserID, caller.OrgID, req.GetEmail(), req.GetRole())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &ssoadminv1.InviteMemberResponse{
+		UserId: userID,
+	}, nil
+}
+
+func (s *serverAPI) InviteUser(
+	ctx context.Context,
+	req *ssoadminv1.InviteUserRequest,
+) (*ssoadminv1.InviteUserResponse, error) {
+	if err := validateInviteUser(req); err != nil {
+		return nil, err
+	}
+
+	caller, err := authenticate(ctx, s.admin)
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs := make([]int64, 0, len(req.GetAppIds()))
+	for _, appID := range req.GetAppIds() {
+		appIDs = append(appIDs, int64(appID))
+	}
+
+	userID, token, err := s.invitations.InviteUser(
+		ctx,
+		caller.UserID,
+		caller.OrgID,
+		req.GetEmail(),
+		req.GetName(),
+		req.GetSurname(),
+		req.GetRole(),
+		appIDs,
+	)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &ssoadminv1.InviteUserResponse{
+		UserId: userID,
+		Token:  token,
+	}, nil
+}
+
+func (s *serverAPI) GrantAppAccess(
+	ctx context.Context,
+	req *ssoadminv1.GrantAppAccessRequest,
+) (*ssoadminv1.GrantAppAccessResponse, error) {
+	if err := validateAppAccess(req.GetUserId(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+
+	caller, err := authenticate(ctx, s.admin)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.admin.GrantAppAccess(ctx, caller.UserID, caller.OrgID, req.GetUserId(), int(req.GetAppId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &ssoadminv1.GrantAppAccessResponse{}, nil
+}
+
+func (s *serverAPI) RevokeAppAccess(
+	ctx context.Context,
+	req *ssoadminv1.RevokeAppAccessRequest,
+) (*ssoadminv1.RevokeAppAccessResponse, error) {
+	if err := validateAppAccess(req.GetUserId(), req.GetAppId()); err != nil {
+		return nil, err
+	}
+
+	caller, err := authenticate(ctx, s.admin)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.admin.RevokeAppAccess(ctx, caller.UserID, caller.OrgID, req.GetUserId(), int(req.GetAppId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &ssoadminv1.RevokeAppAccessResponse{}, nil
+}
+
+func (s *serverAPI) SetAppRestricted(
+	ctx context.Context,
+	req *ssoadminv1.SetAppRestrictedRequest,
+) (*ssoadminv1.SetAppRestrictedResponse, error) {
+	if req.GetAppId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "app id is 0")
+	}
+
+	caller, err := authenticate(ctx, s.admin)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.admin.SetAppRestricted(ctx, caller.UserID, caller.OrgID, int(req.GetAppId()), req.GetRestricted())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &ssoadminv1.SetAppRestrictedResponse{}, nil
+}
+
+// authenticate authenticates the request by the bearer token in its metadata.
+func authenticate(ctx context.Context, adminService Admin) (jwt.Claims, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return jwt.Claims{}, status.Error(codes.Unauthenticated, "missing metadata")
+	}
+
+	authHeaders, ok := md["authorization"]
+	if !ok || len(authHeaders) == 0 {
+		return jwt.Claims{}, status.Error(codes.Unauthenticated, "missing authorization header")
+	}
+
+	tokenStr := strings.TrimPrefix(authHeaders[0], "Bearer ")
+	if tokenStr == "" {
+		return jwt.Claims{}, status.Error(codes.Unauthenticated, "invalid authorization header")
+	}
+
+	claims, err := adminService.Caller(ctx, tokenStr)
+	if err != nil {
+		return jwt.Claims{}, toStatus(err)
+	}
+
+	return claims, nil
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, admin.ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, "invalid token")
+	case errors.Is(err, admin.ErrPermissionDenied), errors.Is(err, invitation.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, "permission denied")
+	case errors.Is(err, admin.ErrAccessDenied):
+		return status.Error(codes.PermissionDenied, "user has no access to the app")
+	case errors.Is(err, admin.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, admin.ErrAppNotFound), errors.Is(err, invitation.ErrAppNotFound):
+		return status.Error(codes.NotFound, "app not found")
+	case errors.Is(err, admin.ErrOrgExists):
+		return status.Error(codes.AlreadyExists, "organization already exists")
+	case errors.Is(err, admin.ErrAlreadyMember):
+		return status.Error(codes.AlreadyExists, "user is already a member")
+	case errors.Is(err, admin.ErrAccessExists):
+		return status.Error(codes.AlreadyExists, "app access already granted")
+	case errors.Is(err, admin.ErrAccessNotFound):
+		return status.Error(codes.NotFound, "app access not found")
+	case errors.Is(err, invitation.ErrUserExists):
+		return status.Error(codes.AlreadyExists, "user already exists")
+	case errors.Is(err, admin.ErrInvalidRole), errors.Is(err, invitation.ErrInvalidRole):
+		return status.Error(codes.InvalidArgument, "invalid role")
+	case errors.Is(err, invitation.ErrInvalidInvitation):
+		return status.Error(codes.InvalidArgument, "invalid invitation")
+	case errors.Is(err, admin.ErrInvitationNotFound):
+		return status.Error(codes.NotFound, "invitation not found")
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func validateImpersonate(req *ssoadminv1.ImpersonateRequest) error {
+	if req.GetUserId() == emptyValue {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	if req.GetAppId() == emptyValue {
+		return status.Error(codes.InvalidArgument, "app id is 0")
+	}
+
+	if req.GetReason() == "" {
+		return status.Error(codes.InvalidArgument, "reason is empty")
+	}
+
+	return nil
+}
+
+func validateCreateOrganization(req *ssoadminv1.CreateOrganizationRequest) error {
+	if req.GetName() == "" {
+		return status.Error(codes.InvalidArgument, "name is empty")
+	}
+
+	return nil
+}
+
+func validateInviteMember(req *ssoadminv1.InviteMemberRequest) error {
+	if req.GetEmail() == "" {
+		return status.Error(codes.InvalidArgument, "email is empty")
+	}
+
+	if req.GetRole() == "" {
+		return status.Error(codes.InvalidArgument, "role is empty")
+	}
+
+	return nil
+}
+
+func validateInviteUser(req *ssoadminv1.InviteUserRequest) error {
+	if req.GetEmail() == "" {
+		return status.Error(codes.InvalidArgument, "email is empty")
+	}
+
+	if req.GetRole() == "" {
+		return status.Error(codes.InvalidArgument, "role is empty")
+	}
+
+	for _, appID := range req.GetAppIds() {
+		if appID == emptyValue {
+			return status.Error(codes.InvalidArgument, "app id is 0")
+		}
+	}
+
+	return nil
+}
+
+func validateAppAccess(userID int64, appID int32) error {
+	if userID == emptyValue {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	if appID == emptyValue {
+		return status.Error(codes.InvalidArgument, "app id is 0")
+	}
+
+	return nil
+}
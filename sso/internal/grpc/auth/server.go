@@ -5,15 +5,21 @@ import (
 	"errors"
 	"sso/internal/services/auth"
 	"sso/internal/storage"
+	"strconv"
 
 	ssov1 "github.com/MarkovMaksim2/protos/gen/go/sso"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 const (
 	emptyValue = 0
+
+	// orgIDHeader selects the organization for requests whose messages
+	// have no org_id field.
+	orgIDHeader = "x-org-id"
 )
 
 type Auth interface {
@@ -22,13 +28,14 @@ type Auth interface {
 		email string,
 		password string,
 		appID int,
+		orgID int64,
 	) (token string, err error)
 	RegisterNewUser(
 		ctx context.Context,
 		email string,
 		password string,
 	) (userID int64, err error)
-	IsAdmin(ctx context.Context, userID int64) (bool, error)
+	IsAdmin(ctx context.Context, userID int64, orgID int64) (bool, error)
 }
 
 type serverAPI struct {
@@ -44,17 +51,29 @@ func (s *serverAPI) Login(ctx context.Context, req *ssov1.LoginRequest) (*ssov1.
 	if err := validateLogin(req); err != nil {
 		return nil, err
 	}
+	orgID, err := orgIDFromMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	token, err := s.auth.Login(
 		ctx,
 		req.GetEmail(),
 		req.GetPassword(),
 		int(req.GetAppId()),
+		orgID,
 	)
 
 	if err != nil {
 		if errors.Is(err, auth.ErrInvalidCredentials) {
 			return nil, status.Error(codes.InvalidArgument, "invalid credentials")
 		}
+		if errors.Is(err, auth.ErrNotMember) {
+			return nil, status.Error(codes.PermissionDenied, "not a member of the organization")
+		}
+		if errors.Is(err, auth.ErrAccessDenied) {
+			return nil, status.Error(codes.PermissionDenied, "no access to the app")
+		}
 		return nil, status.Error(codes.Internal, "internal error")
 	}
 	return &ssov1.LoginResponse{
@@ -85,8 +104,12 @@ func (s *serverAPI) IsAdmin(ctx context.Context, req *ssov1.IsAdminRequest) (*ss
 	if err := validateIsAdmin(req); err != nil {
 		return nil, err
 	}
+	orgID, err := orgIDFromMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	isAdmin, err := s.auth.IsAdmin(ctx, req.GetUserId())
+	isAdmin, err := s.auth.IsAdmin(ctx, req.GetUserId(), orgID)
 
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
@@ -100,6 +123,27 @@ func (s *serverAPI) IsAdmin(ctx context.Context, req *ssov1.IsAdminRequest) (*ss
 	}, nil
 }
 
+// orgIDFromMetadata returns the organization requested by the client,
+// or zero when none is requested.
+func orgIDFromMetadata(ctx context.Context) (int64, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return emptyValue, nil
+	}
+
+	values := md.Get(orgIDHeader)
+	if len(values) == 0 || values[0] == "" {
+		return emptyValue, nil
+	}
+
+	orgID, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil || orgID <= emptyValue {
+		return emptyValue, status.Error(codes.InvalidArgument, "invalid org id")
+	}
+
+	return orgID, nil
+}
+
 func validateLogin(req *ssov1.LoginRequest) error {
 	if req.GetEmail() == "" {
 		return status.Error(codes.InvalidArgument, "email is empty")
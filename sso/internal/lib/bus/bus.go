@@ -0,0 +1,26 @@
+// Package bus decouples the outbox relay from the message broker. The
+// broker is picked by configuration: Kafka, NATS JetStream, or an
+// in-process bus for tests and running sso without a broker.
+package bus
+
+import "context"
+
+const (
+	DriverKafka  = "kafka"
+	DriverNATS   = "nats"
+	DriverMemory = "memory"
+)
+
+// Message is what every broker carries: an optional key, which keeps
+// messages with the same key in order, the value and headers.
+type Message struct {
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+}
+
+// Publisher writes messages to a single topic.
+type Publisher interface {
+	Publish(ctx context.Context, messages ...Message) error
+	Close() error
+}
@@ -0,0 +1,178 @@
+package bus
+
+import (
+	"context"
+	"events"
+	"fmt"
+	"log/slog"
+	"sso/internal/domain/models"
+	"strconv"
+)
+
+// producer is the source of the events sso publishes.
+const producer = "sso"
+
+// HeaderOutboxID carries the outbox row id of a dead letter. It is not the
+// event id, which travels in events.HeaderEventID.
+const HeaderOutboxID = "outbox-id"
+
+// Producer publishes outbox events through a Publisher.
+type Producer struct {
+	log         *slog.Logger
+	publisher   Publisher
+	contentType string
+}
+
+// NewProducer returns a producer encoding events as encoding, "json" or
+// "protobuf"; consumers pick the decoder by the content-type header, so
+// the encoding can be switched at any time.
+func NewProducer(log *slog.Logger, publisher Publisher, encoding string) (*Producer, error) {
+	contentType, err := events.ContentType(encoding)
+	if err != nil {
+		return nil, fmt.Errorf("event encoding: %w", err)
+	}
+
+	return &Producer{
+		log:         log,
+		publisher:   publisher,
+		contentType: contentType,
+	}, nil
+}
+
+// SendEvents publishes events with a single write. Each event is keyed by
+// its partition key, or by its type if it has none, and its envelope id.
+// The id also travels in the event-id header; it stays the same however
+// many times the event is published, so consumers drop the duplicates a
+// relay crash between publishing and marking the event sent causes. The
+// other headers carry the type, schema version, source, correlation id
+// and the trace context of the request that wrote the event.
+func (p *Producer) SendEvents(ctx context.Context, outbox []models.Event) error {
+	const op = "bus.SendEvents"
+
+	log := p.log.With(slog.String("op", op))
+
+	messages := make([]Message, 0, len(outbox))
+	for _, event := range outbox {
+		value, contentType, envelope, err := p.encode(event)
+		if err != nil {
+			log.Error("failed to encode event",
+				slog.Int64("outbox_id", event.ID),
+				slog.String("error", err.Error()))
+			return fmt.Errorf("encode event %d: %w", event.ID, err)
+		}
+
+		messages = append(messages, Message{
+			Key:     messageKey(event, envelope.ID),
+			Value:   value,
+			Headers: events.Headers(envelope, contentType, event.TraceParent),
+		})
+	}
+
+	if err := p.publisher.Publish(ctx, messages...); err != nil {
+		log.Error("failed to send messages", slog.Any("error", err))
+		return fmt.Errorf("send messages: %w", err)
+	}
+
+	for _, message := range messages {
+		log.Debug("event published",
+			slog.String("event_id", message.Headers[events.HeaderEventID]),
+			slog.String("event_type", message.Headers[events.HeaderEventType]),
+			slog.String("correlation_id", message.Headers[events.HeaderCorrelationID]),
+		)
+	}
+	return nil
+}
+
+// SendDeadLetters publishes events that ran out of attempts. The attempt
+// count and last error travel in the message headers. Events that can't be
+// encoded are dead-lettered as stored in the outbox.
+func (p *Producer) SendDeadLetters(ctx context.Context, outbox []models.Event) error {
+	const op = "bus.SendDeadLetters"
+
+	log := p.log.With(slog.String("op", op))
+
+	messages := make([]Message, 0, len(outbox))
+	for _, event := range outbox {
+		value, contentType, envelope, err := p.encode(event)
+		if err != nil {
+			value, contentType = []byte(event.Payload), events.ContentTypeJSON
+			envelope = events.Envelope{ID: outboxEventID(event), Type: event.Type, Producer: producer}
+		}
+
+		headers := events.Headers(envelope, contentType, event.TraceParent)
+		headers[HeaderOutboxID] = strconv.FormatInt(event.ID, 10)
+		headers["attempts"] = strconv.Itoa(event.Attempts)
+		headers["last_error"] = event.LastError
+
+		messages = append(messages, Message{
+			Key:     messageKey(event, envelope.ID),
+			Value:   value,
+			Headers: headers,
+		})
+	}
+
+	if err := p.publisher.Publish(ctx, messages...); err != nil {
+		log.Error("failed to send dead letters", slog.Any("error", err))
+		return fmt.Errorf("send dead letters: %w", err)
+	}
+
+	log.Info("dead letters sent", slog.Int("count", len(messages)))
+	return nil
+}
+
+func (p *Producer) Close() error {
+	return p.publisher.Close()
+}
+
+// encode returns the message value of an outbox event, its content type
+// and its envelope. The outbox holds JSON, which is sent as is. Events
+// saved before the envelope get an id and source here.
+func (p *Producer) encode(event models.Event) ([]byte, string, events.Envelope, error) {
+	envelope, err := events.Parse(event.Type, []byte(event.Payload))
+	if err != nil {
+		return nil, "", events.Envelope{}, err
+	}
+
+	if envelope.ID == "" {
+		envelope.ID = outboxEventID(event)
+	}
+	if envelope.Producer == "" {
+		envelope.Producer = producer
+	}
+
+	if p.contentType == events.ContentTypeJSON {
+		return []byte(event.Payload), events.ContentTypeJSON, envelope, nil
+	}
+
+	value, err := events.Marshal(envelope, p.contentType)
+	if err != nil {
+		return nil, "", events.Envelope{}, err
+	}
+
+	return value, p.contentType, envelope, nil
+}
+
+// EventID returns the id an outbox event is published with: the id of
+// its envelope, or one derived from its outbox row for events saved
+// before the envelope.
+func EventID(event models.Event) string {
+	envelope, err := events.Parse(event.Type, []byte(event.Payload))
+	if err != nil || envelope.ID == "" {
+		return outboxEventID(event)
+	}
+	return envelope.ID
+}
+
+// outboxEventID identifies events saved before the envelope, which have
+// no id of their own, by their outbox row.
+func outboxEventID(event models.Event) string {
+	return "sso-" + strconv.FormatInt(event.ID, 10)
+}
+
+func messageKey(event models.Event, eventID string) []byte {
+	partitionKey := event.PartitionKey
+	if partitionKey == "" {
+		partitionKey = event.Type
+	}
+	return events.MessageKey(partitionKey, eventID)
+}
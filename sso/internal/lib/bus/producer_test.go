@@ -0,0 +1,128 @@
+package bus_test
+
+import (
+	"context"
+	"encoding/json"
+	"events"
+	"io"
+	"log/slog"
+	"sso/internal/domain/models"
+	"sso/internal/lib/bus"
+	"sso/internal/lib/membus"
+	"testing"
+)
+
+func TestProducer_SendEvents(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	envelope, err := events.New(context.Background(), events.TypeUserCreated, "sso",
+		events.UserCreated{UserID: 42, Email: "user@example.com"})
+	if err != nil {
+		t.Fatalf("create envelope: %v", err)
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	outbox := []models.Event{
+		{ID: 1, Type: events.TypeUserCreated, Payload: string(payload), PartitionKey: "42", TraceParent: traceParent},
+		{ID: 2, Type: events.TypeUserCreated, Payload: string(payload), PartitionKey: "42"},
+	}
+
+	for _, encoding := range []string{"json", "protobuf"} {
+		t.Run(encoding, func(t *testing.T) {
+			memory := membus.New(log)
+			messages := memory.Subscribe("events", len(outbox))
+
+			producer, err := bus.NewProducer(log, memory.Publisher("events"), encoding)
+			if err != nil {
+				t.Fatalf("NewProducer() error = %v", err)
+			}
+			if err := producer.SendEvents(context.Background(), outbox); err != nil {
+				t.Fatalf("SendEvents() error = %v", err)
+			}
+
+			message := <-messages
+			if want := "42/" + envelope.ID; string(message.Key) != want {
+				t.Errorf("key = %q, want %q", message.Key, want)
+			}
+			if message.Headers[events.HeaderEventID] != envelope.ID {
+				t.Errorf("event id header = %q, want %q", message.Headers[events.HeaderEventID], envelope.ID)
+			}
+			if message.Headers[events.HeaderEventType] != events.TypeUserCreated {
+				t.Errorf("event type header = %q", message.Headers[events.HeaderEventType])
+			}
+			if message.Headers[events.HeaderSchemaVersion] != "1" || message.Headers[events.HeaderSource] != "sso" {
+				t.Errorf("schema version and source headers = %q, %q, want 1 and sso",
+					message.Headers[events.HeaderSchemaVersion], message.Headers[events.HeaderSource])
+			}
+			if message.Headers[events.HeaderTraceParent] != traceParent {
+				t.Errorf("traceparent header = %q, want %q", message.Headers[events.HeaderTraceParent], traceParent)
+			}
+
+			// Events written outside a traced request get a trace of
+			// their own.
+			untraced := <-messages
+			if want := events.NewTraceParent(envelope.CorrelationID, envelope.ID); untraced.Headers[events.HeaderTraceParent] != want {
+				t.Errorf("traceparent header = %q, want %q", untraced.Headers[events.HeaderTraceParent], want)
+			}
+
+			decoded, err := events.Unmarshal(message.Headers[events.HeaderContentType], "", message.Value)
+			if err != nil {
+				t.Fatalf("decode message: %v", err)
+			}
+			if decoded.ID != envelope.ID {
+				t.Errorf("decoded event %q, want %q", decoded.ID, envelope.ID)
+			}
+		})
+	}
+}
+
+func TestNewProducer_RejectsUnknownEncoding(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	if _, err := bus.NewProducer(log, membus.New(log).Publisher("events"), "avro"); err == nil {
+		t.Error("NewProducer() error = nil, want unsupported encoding")
+	}
+}
+
+func TestProducer_SendDeadLetters(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	envelope, err := events.New(context.Background(), events.TypeUserCreated, "sso",
+		events.UserCreated{UserID: 42, Email: "user@example.com"})
+	if err != nil {
+		t.Fatalf("create envelope: %v", err)
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+
+	memory := membus.New(log)
+	messages := memory.Subscribe("dlq", 1)
+	producer, err := bus.NewProducer(log, memory.Publisher("dlq"), "json")
+	if err != nil {
+		t.Fatalf("NewProducer() error = %v", err)
+	}
+
+	outbox := []models.Event{{
+		ID: 7, Type: events.TypeUserCreated, Payload: string(payload), PartitionKey: "42",
+		Attempts: 5, LastError: "broker down",
+	}}
+	if err := producer.SendDeadLetters(context.Background(), outbox); err != nil {
+		t.Fatalf("SendDeadLetters() error = %v", err)
+	}
+
+	message := <-messages
+	if got := message.Headers[bus.HeaderOutboxID]; got != "7" {
+		t.Errorf("outbox id header = %q, want 7", got)
+	}
+	if got := message.Headers[events.HeaderEventID]; got != envelope.ID {
+		t.Errorf("event id header = %q, want %q", got, envelope.ID)
+	}
+	if message.Headers["attempts"] != "5" || message.Headers["last_error"] != "broker down" {
+		t.Errorf("attempts and last error headers = %q, %q", message.Headers["attempts"], message.Headers["last_error"])
+	}
+}
@@ -1,18 +1,42 @@
 package jwt
 
 import (
+	"errors"
+	"fmt"
 	"sso/internal/domain/models"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func NewToken(user models.User, app models.App, duration time.Duration) (string, error) {
+const (
+	invitationTokenType = "invitation"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Claims are the identity claims carried by tokens issued by sso.
+type Claims struct {
+	UserID int64
+	Email  string
+	AppID  int64
+	// OrgID is the organization the token is scoped to, zero if none.
+	OrgID int64
+	// ActorID is the user acting on behalf of UserID, zero if none.
+	ActorID int64
+}
+
+func NewToken(user models.User, app models.App, orgID int64, duration time.Duration) (string, error) {
 	jwtString := jwt.New(jwt.SigningMethodHS256)
 	claims := jwtString.Claims.(jwt.MapClaims)
 	claims["uid"] = user.ID
 	claims["app_id"] = app.ID
 	claims["email"] = user.Email
+	if orgID != 0 {
+		claims["org_id"] = orgID
+	}
 	claims["exp"] = time.Now().Add(duration).Unix()
 	token, err := jwtString.SignedString([]byte(app.Secret))
 	if err != nil {
@@ -20,3 +44,145 @@ func NewToken(user models.User, app models.App, duration time.Duration) (string,
 	}
 	return token, nil
 }
+
+// NewImpersonationToken issues a token for user with an "act" claim
+// identifying the actor that requested it.
+func NewImpersonationToken(
+	user models.User,
+	actor models.User,
+	app models.App,
+	orgID int64,
+	duration time.Duration,
+) (string, error) {
+	jwtString := jwt.New(jwt.SigningMethodHS256)
+	claims := jwtString.Claims.(jwt.MapClaims)
+	claims["uid"] = user.ID
+	claims["app_id"] = app.ID
+	claims["email"] = user.Email
+	if orgID != 0 {
+		claims["org_id"] = orgID
+	}
+	claims["act"] = map[string]any{
+		"uid":   actor.ID,
+		"email": actor.Email,
+	}
+	claims["exp"] = time.Now().Add(duration).Unix()
+	token, err := jwtString.SignedString([]byte(app.Secret))
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ParseToken verifies a token signed with the secret of the app named in
+// its app_id claim and returns its claims.
+func ParseToken(tokenString string, appSecret func(appID int64) (string, error)) (Claims, error) {
+	var claims Claims
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
+		mapClaims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		appID, ok := mapClaims["app_id"].(float64)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+
+		secret, err := appSecret(int64(appID))
+		if err != nil {
+			return nil, err
+		}
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return claims, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return claims, ErrInvalidToken
+	}
+
+	uid, ok := mapClaims["uid"].(float64)
+	if !ok {
+		return claims, ErrInvalidToken
+	}
+	claims.UserID = int64(uid)
+	claims.AppID = int64(mapClaims["app_id"].(float64))
+	claims.Email, _ = mapClaims["email"].(string)
+	if orgID, ok := mapClaims["org_id"].(float64); ok {
+		claims.OrgID = int64(orgID)
+	}
+
+	if act, ok := mapClaims["act"].(map[string]any); ok {
+		if actorID, ok := act["uid"].(float64); ok {
+			claims.ActorID = int64(actorID)
+		}
+	}
+
+	return claims, nil
+}
+
+// NewInvitationToken signs the invitation with the sso invitation secret.
+func NewInvitationToken(invitation models.Invitation, secret string) (string, error) {
+	jwtString := jwt.New(jwt.SigningMethodHS256)
+	claims := jwtString.Claims.(jwt.MapClaims)
+	claims["typ"] = invitationTokenType
+	claims["inv"] = invitation.ID
+	claims["uid"] = invitation.UserID
+	claims["email"] = invitation.Email
+	claims["org_id"] = invitation.OrgID
+	claims["role"] = invitation.Role
+	claims["app_ids"] = invitation.AppIDs
+	claims["exp"] = invitation.ExpiresAt.Unix()
+	token, err := jwtString.SignedString([]byte(secret))
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ParseInvitationToken verifies an invitation token and returns the
+// invitation it was issued for.
+func ParseInvitationToken(tokenString string, secret string) (models.Invitation, error) {
+	var invitation models.Invitation
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return invitation, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || mapClaims["typ"] != invitationTokenType {
+		return invitation, ErrInvalidToken
+	}
+
+	invitationID, ok := mapClaims["inv"].(float64)
+	if !ok {
+		return invitation, ErrInvalidToken
+	}
+	invitation.ID = int64(invitationID)
+	if uid, ok := mapClaims["uid"].(float64); ok {
+		invitation.UserID = int64(uid)
+	}
+	if orgID, ok := mapClaims["org_id"].(float64); ok {
+		invitation.OrgID = int64(orgID)
+	}
+	invitation.Email, _ = mapClaims["email"].(string)
+	invitation.Role, _ = mapClaims["role"].(string)
+	if appIDs, ok := mapClaims["app_ids"].([]any); ok {
+		for _, appID := range appIDs {
+			if appID, ok := appID.(float64); ok {
+				invitation.AppIDs = append(invitation.AppIDs, int64(appID))
+			}
+		}
+	}
+	if exp, err := token.Claims.GetExpirationTime(); err == nil && exp != nil {
+		invitation.ExpiresAt = exp.Time
+	}
+
+	return invitation, nil
+}
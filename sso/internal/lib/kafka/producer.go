@@ -2,8 +2,10 @@ package kafkaproducer
 
 import (
 	"context"
+	"events/kafkasetup"
 	"fmt"
 	"log/slog"
+	"sso/internal/lib/bus"
 	"time"
 
 	kafka "github.com/segmentio/kafka-go"
@@ -14,11 +16,20 @@ type Producer struct {
 	writer *kafka.Writer
 }
 
+// New returns a producer writing to topic, which EnsureTopics is expected
+// to have provisioned. Every connection is secured as security says.
+//
+// kafka-go has no idempotent producer, so a write it retries after a
+// timeout may land twice. With idempotent set, every write is attempted
+// once; failed events are retried by the outbox relay instead and keep
+// their event id, which consumers deduplicate by.
 func New(
 	log *slog.Logger,
 	brokers []string,
 	topic string,
-	dialAdress string) (*Producer, error) {
+	dialAdress string,
+	security kafkasetup.Security,
+	idempotent bool) (*Producer, error) {
 	if len(brokers) == 0 {
 		return nil, fmt.Errorf("no Kafka brokers provided")
 	}
@@ -26,28 +37,30 @@ func New(
 		return nil, fmt.Errorf("no Kafka topic provided")
 	}
 
-	conn, err := kafka.Dial("tcp", dialAdress)
+	dialer, err := security.Dialer()
 	if err != nil {
-		log.Error("failed to dial Kafka")
-		return nil, fmt.Errorf("dial Kafka: %w", err)
+		return nil, err
 	}
-	defer conn.Close()
 
-	err = conn.CreateTopics(kafka.TopicConfig{
-		Topic:             topic,
-		NumPartitions:     1,
-		ReplicationFactor: 1,
-	})
+	conn, err := dialer.Dial("tcp", dialAdress)
 	if err != nil {
-		log.Error("failed to create topic:", slog.String("error", err.Error()))
+		log.Error("failed to dial Kafka")
+		return nil, fmt.Errorf("dial Kafka: %w", err)
 	}
+	conn.Close()
 
-	writer := kafka.NewWriter(kafka.WriterConfig{
+	config := kafka.WriterConfig{
 		Brokers:      brokers,
 		Topic:        topic,
-		Balancer:     &kafka.LeastBytes{},
+		Balancer:     &kafkasetup.PartitionKeyBalancer{},
 		BatchTimeout: 10 * time.Millisecond,
-	})
+		RequiredAcks: int(kafka.RequireAll),
+		Dialer:       dialer,
+	}
+	if idempotent {
+		config.MaxAttempts = 1
+	}
+	writer := kafka.NewWriter(config)
 
 	log.Info("Kafka producer initialized", slog.String("topic", topic))
 
@@ -57,24 +70,40 @@ func New(
 	}, nil
 }
 
-func (p *Producer) Send(ctx context.Context, key, value []byte) error {
-	const op = "kafkaproducer.Send"
+// Send writes a single message with headers, which carry the metadata of
+// the event (see events.Headers).
+func (p *Producer) Send(ctx context.Context, key, value []byte, headers map[string]string) error {
+	return p.Publish(ctx, bus.Message{Key: key, Value: value, Headers: headers})
+}
+
+// Publish writes messages with a single write.
+func (p *Producer) Publish(ctx context.Context, messages ...bus.Message) error {
+	const op = "kafkaproducer.Publish"
 
 	log := p.log.With(slog.String("op", op))
-	err := p.writer.WriteMessages(ctx, kafka.Message{
-		Key:   key,
-		Value: value,
-		Time:  time.Now(),
-	})
-	if err != nil {
-		log.Error("failed to send Kafka message", slog.Any("error", err))
-		return fmt.Errorf("send message: %w", err)
+
+	now := time.Now()
+	kafkaMessages := make([]kafka.Message, 0, len(messages))
+	for _, message := range messages {
+		headers := make([]kafka.Header, 0, len(message.Headers))
+		for key, value := range message.Headers {
+			headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+		}
+
+		kafkaMessages = append(kafkaMessages, kafka.Message{
+			Key:     message.Key,
+			Value:   message.Value,
+			Headers: headers,
+			Time:    now,
+		})
+	}
+
+	if err := p.writer.WriteMessages(ctx, kafkaMessages...); err != nil {
+		log.Error("failed to send Kafka messages", slog.Any("error", err))
+		return fmt.Errorf("send messages: %w", err)
 	}
 
-	log.Debug("Kafka message sent",
-		slog.String("key", string(key)),
-		slog.Int("value_size", len(value)),
-	)
+	log.Debug("Kafka messages sent", slog.Int("count", len(kafkaMessages)))
 	return nil
 }
 
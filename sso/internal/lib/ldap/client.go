@@ -0,0 +1,116 @@
+package ldapclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+var (
+	ErrNoURL              = errors.New("no LDAP url provided")
+	ErrNoBaseDN           = errors.New("no LDAP base dn provided")
+	ErrUserNotFound       = errors.New("user not found in directory")
+	ErrInvalidCredentials = errors.New("invalid directory credentials")
+)
+
+type Config struct {
+	URL            string
+	BindDN         string
+	BindPassword   string
+	BaseDN         string
+	UserFilter     string
+	GroupAttribute string
+	Timeout        time.Duration
+}
+
+// Entry is a directory user that passed a simple bind.
+type Entry struct {
+	DN     string
+	Email  string
+	Groups []string
+}
+
+type Client struct {
+	cfg Config
+}
+
+func New(cfg Config) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, ErrNoURL
+	}
+	if cfg.BaseDN == "" {
+		return nil, ErrNoBaseDN
+	}
+
+	return &Client{cfg: cfg}, nil
+}
+
+// Authenticate looks the user up by email with the service account
+// and then binds as the found entry with the given password.
+func (c *Client) Authenticate(ctx context.Context, email, password string) (Entry, error) {
+	const op = "ldapclient.Authenticate"
+
+	// An empty password turns a simple bind into an unauthenticated one.
+	if password == "" {
+		return Entry{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	dialer := &net.Dialer{Timeout: c.cfg.Timeout}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+
+	conn, err := ldap.DialURL(c.cfg.URL, ldap.DialWithDialer(dialer))
+	if err != nil {
+		return Entry{}, fmt.Errorf("%s: dial: %w", op, err)
+	}
+	defer conn.Close()
+	conn.SetTimeout(c.cfg.Timeout)
+
+	if c.cfg.BindDN != "" {
+		if err := conn.Bind(c.cfg.BindDN, c.cfg.BindPassword); err != nil {
+			return Entry{}, fmt.Errorf("%s: service bind: %w", op, err)
+		}
+	}
+
+	res, err := conn.Search(ldap.NewSearchRequest(
+		c.cfg.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2,
+		int(c.cfg.Timeout.Seconds()),
+		false,
+		fmt.Sprintf(c.cfg.UserFilter, ldap.EscapeFilter(email)),
+		[]string{c.cfg.GroupAttribute},
+		nil,
+	))
+	if err != nil {
+		return Entry{}, fmt.Errorf("%s: search: %w", op, err)
+	}
+
+	switch len(res.Entries) {
+	case 0:
+		return Entry{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+	case 1:
+	default:
+		return Entry{}, fmt.Errorf("%s: %d entries match %q", op, len(res.Entries), email)
+	}
+
+	entry := res.Entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return Entry{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}
+		return Entry{}, fmt.Errorf("%s: user bind: %w", op, err)
+	}
+
+	return Entry{
+		DN:     entry.DN,
+		Email:  email,
+		Groups: entry.GetAttributeValues(c.cfg.GroupAttribute),
+	}, nil
+}
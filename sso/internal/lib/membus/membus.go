@@ -0,0 +1,78 @@
+// Package membus is an in-process message bus. It lets sso run without a
+// broker and lets tests observe what would have been published.
+package membus
+
+import (
+	"context"
+	"log/slog"
+	"sso/internal/lib/bus"
+	"sync"
+)
+
+// Bus delivers messages published to a topic to every subscriber of the
+// topic. Messages of topics nobody subscribed to are dropped.
+type Bus struct {
+	log         *slog.Logger
+	mu          sync.RWMutex
+	subscribers map[string][]chan bus.Message
+}
+
+func New(log *slog.Logger) *Bus {
+	return &Bus{
+		log:         log,
+		subscribers: make(map[string][]chan bus.Message),
+	}
+}
+
+// Subscribe returns a channel receiving the messages published to topic
+// from now on. Publishers block while it is full.
+func (b *Bus) Subscribe(topic string, buffer int) <-chan bus.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	messages := make(chan bus.Message, buffer)
+	b.subscribers[topic] = append(b.subscribers[topic], messages)
+	return messages
+}
+
+// Publisher returns a publisher writing to topic.
+func (b *Bus) Publisher(topic string) *Publisher {
+	return &Publisher{bus: b, topic: topic}
+}
+
+type Publisher struct {
+	bus   *Bus
+	topic string
+}
+
+func (p *Publisher) Publish(ctx context.Context, messages ...bus.Message) error {
+	const op = "membus.Publish"
+
+	p.bus.mu.RLock()
+	defer p.bus.mu.RUnlock()
+
+	subscribers := p.bus.subscribers[p.topic]
+	if len(subscribers) == 0 {
+		p.bus.log.With(slog.String("op", op)).
+			Debug("no subscribers, dropping messages",
+				slog.String("topic", p.topic),
+				slog.Int("count", len(messages)))
+		return nil
+	}
+
+	for _, message := range messages {
+		for _, subscriber := range subscribers {
+			select {
+			case subscriber <- message:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *Publisher) Close() error {
+	return nil
+}
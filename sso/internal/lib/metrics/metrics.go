@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var outboxPurged = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "sso",
+		Subsystem: "outbox",
+		Name:      "purged_total",
+		Help:      "Sent outbox events removed by the retention job.",
+	},
+	[]string{"mode"},
+)
+
+var outboxPurgeRuns = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "sso",
+		Subsystem: "outbox",
+		Name:      "purge_runs_total",
+		Help:      "Retention job runs by result.",
+	},
+	[]string{"result"},
+)
+
+// ObservePurge records rows removed from the outbox, mode is "delete" or
+// "archive".
+func ObservePurge(mode string, rows int64) {
+	outboxPurged.WithLabelValues(mode).Add(float64(rows))
+}
+
+// ObservePurgeRun records a retention run, result is "ok" or "error".
+func ObservePurgeRun(result string) {
+	outboxPurgeRuns.WithLabelValues(result).Inc()
+}
+
+var outboxBacklog = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "sso",
+		Subsystem: "outbox",
+		Name:      "backlog",
+		Help:      "Outbox events not sent yet.",
+	},
+)
+
+var outboxOldestUnsentAge = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "sso",
+		Subsystem: "outbox",
+		Name:      "oldest_unsent_age_seconds",
+		Help:      "Age of the oldest outbox event not sent yet, 0 when there is none.",
+	},
+)
+
+var eventsPublished = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "sso",
+		Subsystem: "outbox",
+		Name:      "events_published_total",
+		Help:      "Outbox events published, by event type.",
+	},
+	[]string{"type"},
+)
+
+var eventsFailed = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "sso",
+		Subsystem: "outbox",
+		Name:      "events_failed_total",
+		Help:      "Failed publish attempts of outbox events, by event type.",
+	},
+	[]string{"type"},
+)
+
+var eventsDeadLettered = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "sso",
+		Subsystem: "outbox",
+		Name:      "events_dead_lettered_total",
+		Help:      "Outbox events that ran out of attempts, by event type.",
+	},
+	[]string{"type"},
+)
+
+// ObserveBacklog records the number of unsent outbox events and the age
+// of the oldest one.
+func ObserveBacklog(unsent int64, oldestAge time.Duration) {
+	outboxBacklog.Set(float64(unsent))
+	outboxOldestUnsentAge.Set(oldestAge.Seconds())
+}
+
+// ObservePublished records a published event of eventType. The event id
+// is kept as an exemplar, linking the metric to the logs of the event.
+func ObservePublished(eventType string, eventID string) {
+	incWithEventID(eventsPublished.WithLabelValues(eventType), eventID)
+}
+
+// ObserveFailed records a failed publish attempt of an event of
+// eventType.
+func ObserveFailed(eventType string, eventID string) {
+	incWithEventID(eventsFailed.WithLabelValues(eventType), eventID)
+}
+
+// ObserveDeadLettered records an event of eventType sent to the
+// dead-letter topic.
+func ObserveDeadLettered(eventType string, eventID string) {
+	incWithEventID(eventsDeadLettered.WithLabelValues(eventType), eventID)
+}
+
+func incWithEventID(counter prometheus.Counter, eventID string) {
+	counter.(prometheus.ExemplarAdder).AddWithExemplar(1, prometheus.Labels{"event_id": eventID})
+}
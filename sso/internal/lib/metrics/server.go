@@ -0,0 +1,19 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func Listen(host string, port int) error {
+	mux := http.NewServeMux()
+	// OpenMetrics carries the exemplars holding event ids.
+	mux.Handle("/metrics", promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	}))
+
+	return http.ListenAndServe(fmt.Sprintf("%s:%d", host, port), mux)
+}
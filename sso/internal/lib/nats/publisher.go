@@ -0,0 +1,108 @@
+package natsbus
+
+import (
+	"context"
+	"events"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sso/internal/lib/bus"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// HeaderKey carries the message key, which NATS has no place for.
+const HeaderKey = "bus-key"
+
+var invalidStreamChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// Publisher writes messages to a JetStream subject.
+type Publisher struct {
+	log     *slog.Logger
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	subject string
+}
+
+// NewPublisher connects to url and returns a publisher writing to
+// subject. The stream holding subject is created if needed; it is named
+// after the subject.
+func NewPublisher(log *slog.Logger, url string, subject string) (*Publisher, error) {
+	if subject == "" {
+		return nil, fmt.Errorf("no NATS subject provided")
+	}
+
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("open JetStream: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     StreamName(subject),
+		Subjects: []string{subject},
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create stream: %w", err)
+	}
+
+	log.Info("NATS publisher initialized", slog.String("subject", subject))
+
+	return &Publisher{
+		log:     log,
+		conn:    conn,
+		js:      js,
+		subject: subject,
+	}, nil
+}
+
+// Publish writes messages one by one, waiting for every acknowledgment so
+// their order is kept.
+func (p *Publisher) Publish(ctx context.Context, messages ...bus.Message) error {
+	for _, message := range messages {
+		msg := nats.NewMsg(p.subject)
+		msg.Data = message.Value
+		for key, value := range message.Headers {
+			msg.Header.Set(key, value)
+		}
+		if len(message.Key) > 0 {
+			msg.Header.Set(HeaderKey, string(message.Key))
+		}
+		// JetStream drops a message whose id it saw within the
+		// stream's duplicate window.
+		if eventID := message.Headers[events.HeaderEventID]; eventID != "" {
+			msg.Header.Set(jetstream.MsgIDHeader, eventID)
+		}
+
+		if _, err := p.js.PublishMsg(ctx, msg); err != nil {
+			return fmt.Errorf("publish to %s: %w", p.subject, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Publisher) Close() error {
+	const op = "natsbus.Close"
+
+	p.log.With(slog.String("op", op)).
+		Info("closing NATS publisher")
+	return p.conn.Drain()
+}
+
+// StreamName returns the name of the stream holding subject.
+func StreamName(subject string) string {
+	return strings.ToUpper(invalidStreamChars.ReplaceAllString(subject, "_"))
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"events"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	correlationIDHeader = "x-correlation-id"
+	traceParentHeader   = "traceparent"
+)
+
+// CorrelationInterceptor puts the correlation id and the W3C trace context
+// of the request into the context, so events written while serving it can
+// be traced back and join the caller's trace. A new correlation id is
+// generated when the caller doesn't send one; a missing or malformed
+// traceparent is left out.
+func CorrelationInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		correlationID, traceParent := "", ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(correlationIDHeader); len(values) > 0 {
+				correlationID = values[0]
+			}
+			if values := md.Get(traceParentHeader); len(values) > 0 && events.ValidTraceParent(values[0]) {
+				traceParent = values[0]
+			}
+		}
+		if correlationID == "" {
+			correlationID = uuid.NewString()
+		}
+
+		ctx = events.WithCorrelationID(ctx, correlationID)
+		if traceParent != "" {
+			ctx = events.WithTraceParent(ctx, traceParent)
+		}
+
+		return handler(ctx, req)
+	}
+}
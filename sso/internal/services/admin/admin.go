@@ -0,0 +1,490 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sso/internal/domain/models"
+	"sso/internal/lib/jwt"
+	"sso/internal/storage"
+	"time"
+)
+
+var (
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrAppNotFound        = errors.New("app not found")
+	ErrOrgExists          = errors.New("organization already exists")
+	ErrAlreadyMember      = errors.New("user is already a member")
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrAccessExists       = errors.New("app access already granted")
+	ErrAccessNotFound     = errors.New("app access not found")
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrAccessDenied       = errors.New("user has no access to the app")
+)
+
+type Admin struct {
+	log              *slog.Logger
+	userProvider     UserProvider
+	appProvider      AppProvider
+	orgManager       OrgManager
+	auditSaver       AuditSaver
+	accessManager    AccessManager
+	impersonationTTL time.Duration
+	invitationTTL    time.Duration
+}
+
+type UserProvider interface {
+	User(ctx context.Context, email string) (models.User, error)
+	UserByID(ctx context.Context, userID int64) (models.User, error)
+	IsAdmin(ctx context.Context, userID int64, orgID int64) (bool, error)
+}
+
+type OrgManager interface {
+	CreateOrganization(ctx context.Context, name string, ownerID int64) (int64, error)
+	CreateMembershipInvitation(ctx context.Context, invitation models.MembershipInvitation) error
+	AcceptMembershipInvitation(ctx context.Context, orgID int64, userID int64) (models.Membership, error)
+	OrgUser(ctx context.Context, orgID int64, userID int64) (models.User, error)
+}
+
+type AppProvider interface {
+	App(ctx context.Context, appID int64) (models.App, error)
+}
+
+type AccessManager interface {
+	GrantAppAccess(ctx context.Context, appID int64, userID int64) error
+	RevokeAppAccess(ctx context.Context, appID int64, userID int64) error
+	HasAppAccess(ctx context.Context, appID int64, userID int64) (bool, error)
+	SetAppRestricted(ctx context.Context, appID int64, restricted bool) error
+}
+
+type AuditSaver interface {
+	SaveImpersonation(ctx context.Context, impersonation models.Impersonation) error
+}
+
+// New returns a new Admin service. Membership invitations expire after
+// invitationTTL.
+func New(
+	log *slog.Logger,
+	userProvider UserProvider,
+	appProvider AppProvider,
+	orgManager OrgManager,
+	auditSaver AuditSaver,
+	accessManager AccessManager,
+	impersonationTTL time.Duration,
+	invitationTTL time.Duration,
+) *Admin {
+	return &Admin{
+		log:              log,
+		userProvider:     userProvider,
+		appProvider:      appProvider,
+		orgManager:       orgManager,
+		auditSaver:       auditSaver,
+		accessManager:    accessManager,
+		impersonationTTL: impersonationTTL,
+		invitationTTL:    invitationTTL,
+	}
+}
+
+// Caller verifies a token issued by sso and returns the claims of its
+// owner. Impersonation tokens are rejected: admin actions must be taken
+// under the admin's own identity.
+func (a *Admin) Caller(ctx context.Context, token string) (jwt.Claims, error) {
+	const op = "admin.Caller"
+
+	claims, err := jwt.ParseToken(token, func(appID int64) (string, error) {
+		app, err := a.appProvider.App(ctx, appID)
+		if err != nil {
+			return "", err
+		}
+		return app.Secret, nil
+	})
+	if err != nil {
+		if errors.Is(err, jwt.ErrInvalidToken) {
+			return jwt.Claims{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return jwt.Claims{}, fmt.Errorf("%s: parse token: %w", op, err)
+	}
+
+	if claims.ActorID != 0 {
+		return jwt.Claims{}, fmt.Errorf("%s: impersonation token: %w", op, ErrPermissionDenied)
+	}
+
+	return claims, nil
+}
+
+// Impersonate issues a short-lived token for userID that carries actorID
+// in its "act" claim. Only admins of orgID may impersonate its members,
+// and every issued token is recorded in the audit trail. Users only join
+// an organization by accepting an invitation to it, so an admin can't
+// pull a user into their organization to impersonate them.
+func (a *Admin) Impersonate(
+	ctx context.Context,
+	actorID int64,
+	orgID int64,
+	userID int64,
+	appID int,
+	reason string,
+) (string, error) {
+	const op = "admin.Impersonate"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actor_id", actorID),
+		slog.Int64("org_id", orgID),
+		slog.Int64("user_id", userID),
+	)
+
+	log.Info("impersonating user")
+
+	if err := a.requireAdmin(ctx, actorID, orgID); err != nil {
+		log.Warn("impersonation denied", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	actor, err := a.userProvider.UserByID(ctx, actorID)
+	if err != nil {
+		log.Error("failed to get actor", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: get actor: %w", op, err)
+	}
+
+	user, err := a.orgManager.OrgUser(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", slog.String("error", err.Error()))
+			return "", fmt.Errorf("%s: get user: %w", op, ErrUserNotFound)
+		}
+		log.Error("failed to get user", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: get user: %w", op, err)
+	}
+
+	app, err := a.appProvider.App(ctx, int64(appID))
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", slog.String("error", err.Error()))
+			return "", fmt.Errorf("%s: get app: %w", op, ErrAppNotFound)
+		}
+		log.Error("failed to get app", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: get app: %w", op, err)
+	}
+
+	// The token must not open an app the user can't log in to.
+	if app.Restricted {
+		hasAccess, err := a.accessManager.HasAppAccess(ctx, app.ID, user.ID)
+		if err != nil {
+			log.Error("failed to check app access", slog.String("error", err.Error()))
+			return "", fmt.Errorf("%s: check app access: %w", op, err)
+		}
+		if !hasAccess {
+			log.Warn("user has no access to the app", slog.Int64("app_id", app.ID))
+			return "", fmt.Errorf("%s: %w", op, ErrAccessDenied)
+		}
+	}
+
+	// The audit record is written before the token exists, so no token is
+	// ever handed out without a trace.
+	if err := a.auditSaver.SaveImpersonation(ctx, models.Impersonation{
+		ActorID:   actor.ID,
+		OrgID:     orgID,
+		UserID:    user.ID,
+		AppID:     app.ID,
+		Reason:    reason,
+		ExpiresAt: time.Now().Add(a.impersonationTTL),
+	}); err != nil {
+		log.Error("failed to save impersonation audit", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: save audit: %w", op, err)
+	}
+
+	token, err := jwt.NewImpersonationToken(user, actor, app, orgID, a.impersonationTTL)
+	if err != nil {
+		log.Error("failed to create token", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: create token: %w", op, err)
+	}
+
+	log.Info("impersonation token issued", slog.Int64("app_id", app.ID))
+
+	return token, nil
+}
+
+// CreateOrganization creates an organization administered by ownerID.
+// Only platform admins may create organizations.
+func (a *Admin) CreateOrganization(ctx context.Context, ownerID int64, name string) (int64, error) {
+	const op = "admin.CreateOrganization"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("owner_id", ownerID),
+		slog.String("name", name),
+	)
+
+	log.Info("creating organization")
+
+	if err := a.requireAdmin(ctx, ownerID, models.DefaultOrgID); err != nil {
+		log.Warn("create organization denied", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	orgID, err := a.orgManager.CreateOrganization(ctx, name, ownerID)
+	if err != nil {
+		if errors.Is(err, storage.ErrOrgExists) {
+			log.Warn("organization already exists", slog.String("error", err.Error()))
+			return 0, fmt.Errorf("%s: %w", op, ErrOrgExists)
+		}
+		log.Error("failed to create organization", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("organization created", slog.Int64("org_id", orgID))
+
+	return orgID, nil
+}
+
+// InviteMember invites the registered user with the given email into
+// orgID. The user becomes a member after accepting the invitation with
+// AcceptMembership. Only admins of the organization may invite.
+func (a *Admin) InviteMember(
+	ctx context.Context,
+	actorID int64,
+	orgID int64,
+	email string,
+	role string,
+) (int64, error) {
+	const op = "admin.InviteMember"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actor_id", actorID),
+		slog.Int64("org_id", orgID),
+		slog.String("email", email),
+	)
+
+	log.Info("inviting member")
+
+	if role != models.RoleAdmin && role != models.RoleMember {
+		return 0, fmt.Errorf("%s: %q: %w", op, role, ErrInvalidRole)
+	}
+
+	if err := a.requireAdmin(ctx, actorID, orgID); err != nil {
+		log.Warn("invite denied", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := a.userProvider.User(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", slog.String("error", err.Error()))
+			return 0, fmt.Errorf("%s: get user: %w", op, ErrUserNotFound)
+		}
+		log.Error("failed to get user", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: get user: %w", op, err)
+	}
+
+	if err := a.orgManager.CreateMembershipInvitation(ctx, models.MembershipInvitation{
+		OrgID:     orgID,
+		UserID:    user.ID,
+		Role:      role,
+		InvitedBy: actorID,
+		ExpiresAt: time.Now().Add(a.invitationTTL),
+	}); err != nil {
+		if errors.Is(err, storage.ErrMemberExists) {
+			log.Warn("user is already a member", slog.String("error", err.Error()))
+			return 0, fmt.Errorf("%s: create invitation: %w", op, ErrAlreadyMember)
+		}
+		log.Error("failed to create invitation", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: create invitation: %w", op, err)
+	}
+
+	log.Info("member invited", slog.Int64("user_id", user.ID))
+
+	return user.ID, nil
+}
+
+// AcceptMembership accepts the pending invitation of userID into orgID
+// and makes the user a member with the invited role.
+func (a *Admin) AcceptMembership(ctx context.Context, userID int64, orgID int64) error {
+	const op = "admin.AcceptMembership"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("user_id", userID),
+		slog.Int64("org_id", orgID),
+	)
+
+	log.Info("accepting membership")
+
+	membership, err := a.orgManager.AcceptMembershipInvitation(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvitationNotFound) {
+			log.Warn("invitation not found", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrInvitationNotFound)
+		}
+		if errors.Is(err, storage.ErrMemberExists) {
+			log.Warn("user is already a member", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrAlreadyMember)
+		}
+		log.Error("failed to accept invitation", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: accept invitation: %w", op, err)
+	}
+
+	log.Info("membership accepted", slog.String("role", membership.Role))
+
+	return nil
+}
+
+// GrantAppAccess allows a member of orgID to log in to a restricted app.
+// Only admins of the organization that owns the app may grant access.
+func (a *Admin) GrantAppAccess(ctx context.Context, actorID int64, orgID int64, userID int64, appID int) error {
+	const op = "admin.GrantAppAccess"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actor_id", actorID),
+		slog.Int64("org_id", orgID),
+		slog.Int64("user_id", userID),
+		slog.Int("app_id", appID),
+	)
+
+	log.Info("granting app access")
+
+	if err := a.checkAccessChange(ctx, actorID, orgID, userID, appID); err != nil {
+		log.Warn("grant denied", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.accessManager.GrantAppAccess(ctx, int64(appID), userID); err != nil {
+		if errors.Is(err, storage.ErrAccessExists) {
+			log.Warn("app access already granted", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrAccessExists)
+		}
+		log.Error("failed to grant app access", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: grant app access: %w", op, err)
+	}
+
+	log.Info("app access granted")
+
+	return nil
+}
+
+// RevokeAppAccess removes the access of a member of orgID to an app.
+// Only admins of the organization that owns the app may revoke access.
+func (a *Admin) RevokeAppAccess(ctx context.Context, actorID int64, orgID int64, userID int64, appID int) error {
+	const op = "admin.RevokeAppAccess"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actor_id", actorID),
+		slog.Int64("org_id", orgID),
+		slog.Int64("user_id", userID),
+		slog.Int("app_id", appID),
+	)
+
+	log.Info("revoking app access")
+
+	if err := a.checkAccessChange(ctx, actorID, orgID, userID, appID); err != nil {
+		log.Warn("revoke denied", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.accessManager.RevokeAppAccess(ctx, int64(appID), userID); err != nil {
+		if errors.Is(err, storage.ErrAccessNotFound) {
+			log.Warn("app access not found", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrAccessNotFound)
+		}
+		log.Error("failed to revoke app access", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: revoke app access: %w", op, err)
+	}
+
+	log.Info("app access revoked")
+
+	return nil
+}
+
+// SetAppRestricted restricts the app to users granted access to it, or
+// opens it to everyone. Only admins of the organization that owns the app
+// may change it.
+func (a *Admin) SetAppRestricted(ctx context.Context, actorID int64, orgID int64, appID int, restricted bool) error {
+	const op = "admin.SetAppRestricted"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("actor_id", actorID),
+		slog.Int64("org_id", orgID),
+		slog.Int("app_id", appID),
+		slog.Bool("restricted", restricted),
+	)
+
+	log.Info("changing app restriction")
+
+	if err := a.requireAdmin(ctx, actorID, orgID); err != nil {
+		log.Warn("change denied", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.requireAppOwner(ctx, orgID, appID); err != nil {
+		log.Warn("change denied", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.accessManager.SetAppRestricted(ctx, int64(appID), restricted); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+		log.Error("failed to change app restriction", slog.String("error", err.Error()))
+		return fmt.Errorf("%s: set app restricted: %w", op, err)
+	}
+
+	log.Info("app restriction changed")
+
+	return nil
+}
+
+// checkAccessChange checks that actorID administers orgID, that the
+// target user is a member of it and that the organization owns the app.
+func (a *Admin) checkAccessChange(ctx context.Context, actorID int64, orgID int64, userID int64, appID int) error {
+	if err := a.requireAdmin(ctx, actorID, orgID); err != nil {
+		return err
+	}
+
+	if _, err := a.orgManager.OrgUser(ctx, orgID, userID); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	return a.requireAppOwner(ctx, orgID, appID)
+}
+
+// requireAppOwner checks that the app exists and belongs to orgID.
+func (a *Admin) requireAppOwner(ctx context.Context, orgID int64, appID int) error {
+	app, err := a.appProvider.App(ctx, int64(appID))
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return ErrAppNotFound
+		}
+		return fmt.Errorf("get app: %w", err)
+	}
+	if app.OrgID != orgID {
+		return ErrPermissionDenied
+	}
+
+	return nil
+}
+
+func (a *Admin) requireAdmin(ctx context.Context, userID int64, orgID int64) error {
+	isAdmin, err := a.userProvider.IsAdmin(ctx, userID, orgID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return ErrPermissionDenied
+		}
+		return fmt.Errorf("check admin: %w", err)
+	}
+	if !isAdmin {
+		return ErrPermissionDenied
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sso/internal/domain/models"
+	"sso/internal/storage/sqlite"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+const (
+	testAppID = 1
+)
+
+func newTestAdmin(t *testing.T) (*Admin, *sqlite.Storage) {
+	t.Helper()
+
+	storagePath := filepath.Join(t.TempDir(), "sso.db")
+	m, err := migrate.New("file://../../../migrations", "sqlite3://"+storagePath)
+	if err != nil {
+		t.Fatalf("create migrator: %v", err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+	if srcErr, dbErr := m.Close(); srcErr != nil || dbErr != nil {
+		t.Fatalf("close migrator: %v, %v", srcErr, dbErr)
+	}
+
+	s, err := sqlite.New(storagePath)
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+
+	return newAdmin(s, time.Hour), s
+}
+
+func newAdmin(s *sqlite.Storage, invitationTTL time.Duration) *Admin {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return New(log, s, s, s, s, s, time.Minute, invitationTTL)
+}
+
+func saveUser(t *testing.T, s *sqlite.Storage, email string) int64 {
+	t.Helper()
+
+	userID, err := s.SaveUser(context.Background(), email, []byte("hash"))
+	if err != nil {
+		t.Fatalf("SaveUser(%q) error = %v", email, err)
+	}
+
+	return userID
+}
+
+// saveDefaultOrgUser saves a user in the default organization with the
+// given roles, the way directory users are provisioned.
+func saveDefaultOrgUser(t *testing.T, s *sqlite.Storage, email string, roles ...string) int64 {
+	t.Helper()
+
+	user, err := s.ProvisionUser(context.Background(), email)
+	if err != nil {
+		t.Fatalf("ProvisionUser(%q) error = %v", email, err)
+	}
+	if err := s.SetUserRoles(context.Background(), user.ID, roles); err != nil {
+		t.Fatalf("SetUserRoles() error = %v", err)
+	}
+
+	return user.ID
+}
+
+// newOrgAdmin returns a user that was invited as an admin into an
+// organization created by a platform admin.
+func newOrgAdmin(t *testing.T, a *Admin, s *sqlite.Storage) (userID int64, orgID int64) {
+	t.Helper()
+	ctx := context.Background()
+
+	platformAdminID := saveDefaultOrgUser(t, s, "root@example.com", models.RoleAdmin)
+
+	orgID, err := a.CreateOrganization(ctx, platformAdminID, "acme")
+	if err != nil {
+		t.Fatalf("CreateOrganization() error = %v", err)
+	}
+
+	userID = saveUser(t, s, "admin@acme.com")
+	if _, err := a.InviteMember(ctx, platformAdminID, orgID, "admin@acme.com", models.RoleAdmin); err != nil {
+		t.Fatalf("InviteMember() error = %v", err)
+	}
+	if err := a.AcceptMembership(ctx, userID, orgID); err != nil {
+		t.Fatalf("AcceptMembership() error = %v", err)
+	}
+
+	return userID, orgID
+}
+
+func TestCreateOrganization_RequiresPlatformAdmin(t *testing.T) {
+	a, s := newTestAdmin(t)
+	ctx := context.Background()
+
+	userID := saveUser(t, s, "user@example.com")
+
+	_, err := a.CreateOrganization(ctx, userID, "own")
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("CreateOrganization() by a regular user error = %v, want %v", err, ErrPermissionDenied)
+	}
+}
+
+func TestImpersonate_RequiresAcceptedMembership(t *testing.T) {
+	a, s := newTestAdmin(t)
+	ctx := context.Background()
+
+	adminID, orgID := newOrgAdmin(t, a, s)
+	victimID := saveUser(t, s, "victim@example.com")
+
+	if _, err := a.InviteMember(ctx, adminID, orgID, "victim@example.com", models.RoleMember); err != nil {
+		t.Fatalf("InviteMember() error = %v", err)
+	}
+
+	// A pending invitation gives the inviting admin no power over the
+	// invited user.
+	_, err := a.Impersonate(ctx, adminID, orgID, victimID, testAppID, "support")
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("Impersonate() before acceptance error = %v, want %v", err, ErrUserNotFound)
+	}
+	err = a.GrantAppAccess(ctx, adminID, orgID, victimID, testAppID)
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GrantAppAccess() before acceptance error = %v, want %v", err, ErrUserNotFound)
+	}
+
+	if err := a.AcceptMembership(ctx, victimID, orgID); err != nil {
+		t.Fatalf("AcceptMembership() error = %v", err)
+	}
+
+	token, err := a.Impersonate(ctx, adminID, orgID, victimID, testAppID, "support")
+	if err != nil {
+		t.Fatalf("Impersonate() after acceptance error = %v", err)
+	}
+	if token == "" {
+		t.Error("Impersonate() returned an empty token")
+	}
+}
+
+func TestAcceptMembership(t *testing.T) {
+	tests := []struct {
+		name          string
+		invitationTTL time.Duration
+		invite        bool
+		wantErr       error
+	}{
+		{name: "pending invitation", invitationTTL: time.Hour, invite: true},
+		{name: "expired invitation", invitationTTL: -time.Minute, invite: true, wantErr: ErrInvitationNotFound},
+		{name: "no invitation", invitationTTL: time.Hour, wantErr: ErrInvitationNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, s := newTestAdmin(t)
+			ctx := context.Background()
+
+			adminID, orgID := newOrgAdmin(t, a, s)
+			userID := saveUser(t, s, "user@example.com")
+
+			if tt.invite {
+				inviter := newAdmin(s, tt.invitationTTL)
+				if _, err := inviter.InviteMember(ctx, adminID, orgID, "user@example.com", models.RoleMember); err != nil {
+					t.Fatalf("InviteMember() error = %v", err)
+				}
+			}
+
+			err := a.AcceptMembership(ctx, userID, orgID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AcceptMembership() error = %v, want %v", err, tt.wantErr)
+			}
+
+			_, err = s.OrgUser(ctx, orgID, userID)
+			if isMember := err == nil; isMember != (tt.wantErr == nil) {
+				t.Errorf("user is member = %v, want %v", isMember, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestAppAccess_RequiresAppOwner(t *testing.T) {
+	a, s := newTestAdmin(t)
+	ctx := context.Background()
+
+	// The test app belongs to the default organization, not to acme.
+	adminID, orgID := newOrgAdmin(t, a, s)
+
+	err := a.GrantAppAccess(ctx, adminID, orgID, adminID, testAppID)
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("GrantAppAccess() to another organization's app error = %v, want %v", err, ErrPermissionDenied)
+	}
+
+	err = a.SetAppRestricted(ctx, adminID, orgID, testAppID, false)
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("SetAppRestricted() of another organization's app error = %v, want %v", err, ErrPermissionDenied)
+	}
+}
+
+func TestImpersonate_RestrictedApp(t *testing.T) {
+	a, s := newTestAdmin(t)
+	ctx := context.Background()
+
+	adminID := saveDefaultOrgUser(t, s, "root@example.com", models.RoleAdmin)
+	userID := saveDefaultOrgUser(t, s, "user@example.com", models.RoleMember)
+
+	if err := a.SetAppRestricted(ctx, adminID, models.DefaultOrgID, testAppID, true); err != nil {
+		t.Fatalf("SetAppRestricted() error = %v", err)
+	}
+
+	_, err := a.Impersonate(ctx, adminID, models.DefaultOrgID, userID, testAppID, "support")
+	if !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("Impersonate() without app access error = %v, want %v", err, ErrAccessDenied)
+	}
+
+	if err := a.GrantAppAccess(ctx, adminID, models.DefaultOrgID, userID, testAppID); err != nil {
+		t.Fatalf("GrantAppAccess() error = %v", err)
+	}
+
+	if _, err := a.Impersonate(ctx, adminID, models.DefaultOrgID, userID, testAppID, "support"); err != nil {
+		t.Fatalf("Impersonate() with app access error = %v", err)
+	}
+}
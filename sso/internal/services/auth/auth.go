@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"errors"
+	"events"
 	"fmt"
 	"log/slog"
 	"sso/internal/domain/models"
@@ -22,6 +23,8 @@ var (
 	ErrUserExists         = errors.New("user already exists")
 	ErrAppNotFound        = errors.New("app not found")
 	ErrUserNotFound       = errors.New("user not found")
+	ErrNotMember          = errors.New("user is not a member of the organization")
+	ErrAccessDenied       = errors.New("user has no access to the app")
 )
 
 type Auth struct {
@@ -29,6 +32,8 @@ type Auth struct {
 	userSaver    UserSaver
 	userProvider UserProvider
 	appProvider  AppProvider
+	orgProvider  OrgProvider
+	verifiers    []CredentialVerifier
 	tokenTTL     time.Duration
 }
 
@@ -42,36 +47,54 @@ type UserSaver interface {
 
 type UserProvider interface {
 	User(ctx context.Context, email string) (models.User, error)
-	IsAdmin(ctx context.Context, userID int64) (bool, error)
+	IsAdmin(ctx context.Context, userID int64, orgID int64) (bool, error)
 }
 
 type AppProvider interface {
 	App(ctx context.Context, appID int64) (models.App, error)
+	HasAppAccess(ctx context.Context, appID int64, userID int64) (bool, error)
 }
 
-// New returns a new Auth service.
+type OrgProvider interface {
+	Membership(ctx context.Context, orgID int64, userID int64) (models.Membership, error)
+	DefaultOrganization(ctx context.Context, userID int64) (int64, error)
+}
+
+// New returns a new Auth service. Credentials are checked by the given
+// verifiers in order; without any, the local bcrypt verifier is used.
 func New(
 	log *slog.Logger,
 	userSaver UserSaver,
 	userProvider UserProvider,
 	appProvider AppProvider,
+	orgProvider OrgProvider,
 	tokenTTL time.Duration,
+	verifiers ...CredentialVerifier,
 ) *Auth {
+	if len(verifiers) == 0 {
+		verifiers = []CredentialVerifier{NewLocalVerifier(userProvider)}
+	}
+
 	return &Auth{
 		log:          log,
 		userSaver:    userSaver,
 		userProvider: userProvider,
 		appProvider:  appProvider,
+		orgProvider:  orgProvider,
+		verifiers:    verifiers,
 		tokenTTL:     tokenTTL,
 	}
 }
 
 // Login checks user credentials and returns a JWT token if successful.
+// The token is scoped to orgID, or to the user's default organization
+// when orgID is zero.
 func (a *Auth) Login(
 	ctx context.Context,
 	email string,
 	password string,
 	appID int,
+	orgID int64,
 ) (string, error) {
 	const op = "auth.Login"
 	log := a.log.With(
@@ -81,19 +104,14 @@ func (a *Auth) Login(
 
 	log.Info("user logging in")
 
-	user, err := a.userProvider.User(ctx, email)
+	user, err := a.verifyCredentials(ctx, email, password)
 	if err != nil {
-		if errors.Is(err, storage.ErrUserNotFound) {
-			log.Warn("user not found", slog.String("error", err.Error()))
-			return "", fmt.Errorf("%s: get user: %w", op, ErrInvalidCredentials)
+		if errors.Is(err, ErrInvalidCredentials) {
+			log.Warn("invalid credentials", slog.String("error", err.Error()))
+			return "", fmt.Errorf("%s: verify credentials: %w", op, ErrInvalidCredentials)
 		}
-		log.Error("failed to get user", slog.String("error", err.Error()))
-		return "", fmt.Errorf("%s: get user: %w", op, err)
-	}
-
-	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
-		log.Warn("invalid password", slog.String("error", err.Error()))
-		return "", fmt.Errorf("%s: compare password: %w", op, ErrInvalidCredentials)
+		log.Error("failed to verify credentials", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: verify credentials: %w", op, err)
 	}
 
 	app, err := a.appProvider.App(ctx, int64(appID))
@@ -105,9 +123,35 @@ func (a *Auth) Login(
 		log.Error("failed to get app", slog.String("error", err.Error()))
 		return "", fmt.Errorf("%s: get app: %w", op, err)
 	}
-	log.Info("login successful", slog.Int64("user_id", user.ID), slog.Int64("app_id", app.ID))
 
-	token, err := jwt.NewToken(user, app, a.tokenTTL)
+	if app.Restricted {
+		hasAccess, err := a.appProvider.HasAppAccess(ctx, app.ID, user.ID)
+		if err != nil {
+			log.Error("failed to check app access", slog.String("error", err.Error()))
+			return "", fmt.Errorf("%s: check app access: %w", op, err)
+		}
+		if !hasAccess {
+			log.Warn("user has no access to the app", slog.Int64("app_id", app.ID))
+			return "", fmt.Errorf("%s: %w", op, ErrAccessDenied)
+		}
+	}
+
+	tokenOrgID, err := a.organization(ctx, user.ID, orgID)
+	if err != nil {
+		if errors.Is(err, ErrNotMember) {
+			log.Warn("user is not a member of the organization", slog.Int64("org_id", orgID))
+			return "", fmt.Errorf("%s: get organization: %w", op, err)
+		}
+		log.Error("failed to get organization", slog.String("error", err.Error()))
+		return "", fmt.Errorf("%s: get organization: %w", op, err)
+	}
+	log.Info("login successful",
+		slog.Int64("user_id", user.ID),
+		slog.Int64("app_id", app.ID),
+		slog.Int64("org_id", tokenOrgID),
+	)
+
+	token, err := jwt.NewToken(user, app, tokenOrgID, a.tokenTTL)
 	if err != nil {
 		log.Error("failed to create token", slog.String("error", err.Error()))
 		return "", fmt.Errorf("%s: create token: %w", op, err)
@@ -115,6 +159,61 @@ func (a *Auth) Login(
 	return token, nil
 }
 
+// organization checks that the user is a member of orgID, or picks the
+// user's default organization when orgID is zero. Users that don't
+// belong to any organization get zero.
+func (a *Auth) organization(ctx context.Context, userID int64, orgID int64) (int64, error) {
+	if orgID == 0 {
+		orgID, err := a.orgProvider.DefaultOrganization(ctx, userID)
+		if err != nil {
+			if errors.Is(err, storage.ErrOrgNotFound) {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("default organization: %w", err)
+		}
+		return orgID, nil
+	}
+
+	if _, err := a.orgProvider.Membership(ctx, orgID, userID); err != nil {
+		if errors.Is(err, storage.ErrMemberNotFound) {
+			return 0, ErrNotMember
+		}
+		return 0, fmt.Errorf("membership: %w", err)
+	}
+
+	return orgID, nil
+}
+
+// verifyCredentials tries the verifier chain and returns the user from the
+// first verifier that accepts the credentials. A backend failure doesn't
+// stop the chain, but is reported if no verifier accepts the credentials.
+func (a *Auth) verifyCredentials(
+	ctx context.Context,
+	email string,
+	password string,
+) (models.User, error) {
+	const op = "auth.verifyCredentials"
+
+	var verifyErr error = ErrInvalidCredentials
+	for _, verifier := range a.verifiers {
+		user, err := verifier.Verify(ctx, email, password)
+		if err == nil {
+			return user, nil
+		}
+
+		if !errors.Is(err, ErrInvalidCredentials) {
+			a.log.Error("credential verifier failed",
+				slog.String("op", op),
+				slog.String("verifier", verifier.Name()),
+				slog.String("error", err.Error()),
+			)
+			verifyErr = err
+		}
+	}
+
+	return models.User{}, fmt.Errorf("%s: %w", op, verifyErr)
+}
+
 // RegisterNewUser creates a new user with the given email and password.
 func (a *Auth) RegisterNewUser(
 	ctx context.Context,
@@ -123,9 +222,12 @@ func (a *Auth) RegisterNewUser(
 ) (int64, error) {
 	const op = "auth.RegisterNewUser"
 
+	// The correlation id links the registration to the UserCreated event
+	// and its processing in other services.
 	log := a.log.With(
 		slog.String("op", op),
 		slog.String("email", email),
+		slog.String("correlation_id", events.CorrelationID(ctx)),
 	)
 
 	log.Info("registering new user")
@@ -153,21 +255,37 @@ func (a *Auth) RegisterNewUser(
 	return uid, nil
 }
 
-// IsAdmin checks if the user with the given ID has admin privileges.
+// IsAdmin checks if the user with the given ID is an admin of the
+// organization, or of the user's default organization when orgID is zero.
 func (a *Auth) IsAdmin(
 	ctx context.Context,
 	userID int64,
+	orgID int64,
 ) (bool, error) {
 	const op = "auth.IsAdmin"
 
 	log := a.log.With(
 		slog.String("op", op),
 		slog.Int64("user_id", userID),
+		slog.Int64("org_id", orgID),
 	)
 
 	log.Info("checking admin privileges")
 
-	isAdmin, err := a.userProvider.IsAdmin(ctx, userID)
+	if orgID == 0 {
+		defaultOrgID, err := a.organization(ctx, userID, orgID)
+		if err != nil {
+			log.Error("failed to get default organization", slog.String("error", err.Error()))
+			return false, fmt.Errorf("%s: get organization: %w", op, err)
+		}
+		if defaultOrgID == 0 {
+			log.Info("user is not a member of any organization")
+			return false, nil
+		}
+		orgID = defaultOrgID
+	}
+
+	isAdmin, err := a.userProvider.IsAdmin(ctx, userID, orgID)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			log.Warn("user not found", slog.String("error", err.Error()))
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"testing"
+	"time"
+)
+
+// fakeApps serves a single app and the users granted access to it.
+type fakeApps struct {
+	app    models.App
+	access map[int64]bool
+}
+
+func (f *fakeApps) App(_ context.Context, appID int64) (models.App, error) {
+	if appID != f.app.ID {
+		return models.App{}, storage.ErrAppNotFound
+	}
+	return f.app, nil
+}
+
+func (f *fakeApps) HasAppAccess(_ context.Context, appID int64, userID int64) (bool, error) {
+	return appID == f.app.ID && f.access[userID], nil
+}
+
+// noOrgs is an organization store where nobody belongs anywhere.
+type noOrgs struct{}
+
+func (noOrgs) Membership(context.Context, int64, int64) (models.Membership, error) {
+	return models.Membership{}, storage.ErrMemberNotFound
+}
+
+func (noOrgs) DefaultOrganization(context.Context, int64) (int64, error) {
+	return 0, storage.ErrOrgNotFound
+}
+
+func TestLogin_RestrictedApp(t *testing.T) {
+	tests := []struct {
+		name       string
+		restricted bool
+		granted    bool
+		wantErr    error
+	}{
+		{name: "restricted app without a grant", restricted: true, wantErr: ErrAccessDenied},
+		{name: "restricted app with a grant", restricted: true, granted: true},
+		{name: "unrestricted app without a grant"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeUsers()
+			users.addLocal(t, "user@example.com", "password")
+			userID := users.users["user@example.com"].ID
+
+			apps := &fakeApps{
+				app:    models.App{ID: 2, Name: "billing", Secret: "secret", Restricted: tt.restricted},
+				access: map[int64]bool{userID: tt.granted},
+			}
+			a := New(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, users, apps, noOrgs{}, time.Hour)
+
+			token, err := a.Login(context.Background(), "user@example.com", "password", 2, 0)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Login() error = %v, want %v", err, tt.wantErr)
+				}
+				if token != "" {
+					t.Errorf("Login() issued a token on error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			if token == "" {
+				t.Errorf("Login() returned an empty token")
+			}
+		})
+	}
+}
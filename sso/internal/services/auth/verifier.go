@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sso/internal/domain/models"
+	ldapclient "sso/internal/lib/ldap"
+	"sso/internal/storage"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// CredentialVerifier checks an email/password pair against one backend.
+// It returns ErrInvalidCredentials when the backend rejects or doesn't
+// know the user, so the next verifier in the chain can be tried.
+type CredentialVerifier interface {
+	Name() string
+	Verify(ctx context.Context, email string, password string) (models.User, error)
+}
+
+// LocalVerifier checks passwords against the bcrypt hashes in storage.
+type LocalVerifier struct {
+	userProvider UserProvider
+}
+
+func NewLocalVerifier(userProvider UserProvider) *LocalVerifier {
+	return &LocalVerifier{userProvider: userProvider}
+}
+
+func (v *LocalVerifier) Name() string {
+	return "local"
+}
+
+func (v *LocalVerifier) Verify(ctx context.Context, email string, password string) (models.User, error) {
+	const op = "auth.LocalVerifier.Verify"
+
+	user, err := v.userProvider.User(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return models.User{}, fmt.Errorf("%s: get user: %w", op, ErrInvalidCredentials)
+		}
+		return models.User{}, fmt.Errorf("%s: get user: %w", op, err)
+	}
+
+	if user.Status == models.UserStatusPending {
+		return models.User{}, fmt.Errorf("%s: invitation not accepted: %w", op, ErrInvalidCredentials)
+	}
+
+	// Users provisioned from a directory have no local password.
+	if len(user.PassHash) == 0 {
+		return models.User{}, fmt.Errorf("%s: no local password: %w", op, ErrInvalidCredentials)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
+		return models.User{}, fmt.Errorf("%s: compare password: %w", op, ErrInvalidCredentials)
+	}
+
+	return user, nil
+}
+
+type Directory interface {
+	Authenticate(ctx context.Context, email string, password string) (ldapclient.Entry, error)
+}
+
+type UserProvisioner interface {
+	ProvisionUser(ctx context.Context, email string) (models.User, error)
+	SetUserRoles(ctx context.Context, userID int64, roles []string) error
+}
+
+// LDAPVerifier checks credentials with an LDAP simple bind. Users are
+// created locally on their first successful bind and their roles are
+// synced from directory groups on every login. A bind never signs in as a
+// local account with the same email.
+type LDAPVerifier struct {
+	directory   Directory
+	provisioner UserProvisioner
+	groupRoles  map[string]string
+}
+
+func NewLDAPVerifier(
+	directory Directory,
+	provisioner UserProvisioner,
+	groupRoles map[string]string,
+) *LDAPVerifier {
+	// Group DNs are case-insensitive.
+	roles := make(map[string]string, len(groupRoles))
+	for group, role := range groupRoles {
+		roles[strings.ToLower(group)] = role
+	}
+
+	return &LDAPVerifier{
+		directory:   directory,
+		provisioner: provisioner,
+		groupRoles:  roles,
+	}
+}
+
+func (v *LDAPVerifier) Name() string {
+	return "ldap"
+}
+
+func (v *LDAPVerifier) Verify(ctx context.Context, email string, password string) (models.User, error) {
+	const op = "auth.LDAPVerifier.Verify"
+
+	entry, err := v.directory.Authenticate(ctx, email, password)
+	if err != nil {
+		if errors.Is(err, ldapclient.ErrUserNotFound) || errors.Is(err, ldapclient.ErrInvalidCredentials) {
+			return models.User{}, fmt.Errorf("%s: bind: %w", op, ErrInvalidCredentials)
+		}
+		return models.User{}, fmt.Errorf("%s: bind: %w", op, err)
+	}
+
+	user, err := v.provisioner.ProvisionUser(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotDirectoryUser) {
+			return models.User{}, fmt.Errorf("%s: local account with the same email: %w", op, ErrInvalidCredentials)
+		}
+		return models.User{}, fmt.Errorf("%s: provision user: %w", op, err)
+	}
+
+	// An invited user signs in only after accepting the invitation, the
+	// same as with a local password.
+	if user.Status == models.UserStatusPending {
+		return models.User{}, fmt.Errorf("%s: invitation not accepted: %w", op, ErrInvalidCredentials)
+	}
+
+	if err := v.provisioner.SetUserRoles(ctx, user.ID, v.roles(entry.Groups)); err != nil {
+		return models.User{}, fmt.Errorf("%s: set roles: %w", op, err)
+	}
+
+	return user, nil
+}
+
+func (v *LDAPVerifier) roles(groups []string) []string {
+	seen := make(map[string]struct{}, len(groups))
+	roles := make([]string, 0, len(groups))
+	for _, group := range groups {
+		role, ok := v.groupRoles[strings.ToLower(group)]
+		if !ok {
+			continue
+		}
+		if _, ok := seen[role]; ok {
+			continue
+		}
+		seen[role] = struct{}{}
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	return roles
+}
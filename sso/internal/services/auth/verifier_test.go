@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"sso/internal/domain/models"
+	ldapclient "sso/internal/lib/ldap"
+	"sso/internal/storage"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeUsers is an in-memory user store. It provisions directory users the
+// way storage does: without a local password, and never over a local
+// account.
+type fakeUsers struct {
+	users map[string]models.User
+	roles map[int64][]string
+}
+
+func newFakeUsers() *fakeUsers {
+	return &fakeUsers{users: map[string]models.User{}, roles: map[int64][]string{}}
+}
+
+func (f *fakeUsers) User(_ context.Context, email string) (models.User, error) {
+	user, ok := f.users[email]
+	if !ok {
+		return models.User{}, storage.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (f *fakeUsers) IsAdmin(context.Context, int64, int64) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeUsers) ProvisionUser(_ context.Context, email string) (models.User, error) {
+	if user, ok := f.users[email]; ok {
+		if !user.Directory {
+			return models.User{}, storage.ErrNotDirectoryUser
+		}
+		return user, nil
+	}
+	user := models.User{ID: int64(len(f.users) + 1), Email: email, Status: models.UserStatusActive, Directory: true}
+	f.users[email] = user
+	return user, nil
+}
+
+func (f *fakeUsers) SetUserRoles(_ context.Context, userID int64, roles []string) error {
+	f.roles[userID] = roles
+	return nil
+}
+
+func (f *fakeUsers) addLocal(t *testing.T, email string, password string) {
+	t.Helper()
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	f.users[email] = models.User{
+		ID:       int64(len(f.users) + 1),
+		Email:    email,
+		PassHash: passHash,
+		Status:   models.UserStatusActive,
+	}
+}
+
+// fakeDirectory is an LDAP client with a fixed set of users. A non-nil err
+// makes every bind fail as if the server were unreachable.
+type fakeDirectory struct {
+	passwords map[string]string
+	groups    map[string][]string
+	err       error
+	binds     int
+}
+
+func (d *fakeDirectory) Authenticate(_ context.Context, email string, password string) (ldapclient.Entry, error) {
+	d.binds++
+	if d.err != nil {
+		return ldapclient.Entry{}, d.err
+	}
+
+	want, ok := d.passwords[email]
+	if !ok {
+		return ldapclient.Entry{}, ldapclient.ErrUserNotFound
+	}
+	if password != want {
+		return ldapclient.Entry{}, ldapclient.ErrInvalidCredentials
+	}
+
+	return ldapclient.Entry{DN: "uid=" + email, Email: email, Groups: d.groups[email]}, nil
+}
+
+func newChain(users *fakeUsers, directory *fakeDirectory) *Auth {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return New(log, nil, users, nil, nil, 0,
+		NewLocalVerifier(users),
+		NewLDAPVerifier(directory, users, map[string]string{"CN=Admins,DC=example": "admin"}),
+	)
+}
+
+func TestVerifyCredentials_Chain(t *testing.T) {
+	errUnreachable := errors.New("connection refused")
+
+	tests := []struct {
+		name         string
+		email        string
+		password     string
+		directoryErr error
+		wantErr      error
+		wantBinds    int
+	}{
+		{
+			name:      "local user is accepted without a bind",
+			email:     "local@example.com",
+			password:  "local-password",
+			wantBinds: 0,
+		},
+		{
+			name:         "local user is accepted while the directory is down",
+			email:        "local@example.com",
+			password:     "local-password",
+			directoryErr: errUnreachable,
+			wantBinds:    0,
+		},
+		{
+			name:      "directory bind doesn't sign in as a local account",
+			email:     "local@example.com",
+			password:  "directory-password",
+			wantErr:   ErrInvalidCredentials,
+			wantBinds: 1,
+		},
+		{
+			name:      "directory-only user",
+			email:     "ldap@example.com",
+			password:  "ldap-password",
+			wantBinds: 1,
+		},
+		{
+			name:      "wrong password everywhere",
+			email:     "local@example.com",
+			password:  "wrong",
+			wantErr:   ErrInvalidCredentials,
+			wantBinds: 1,
+		},
+		{
+			name:      "unknown user",
+			email:     "nobody@example.com",
+			password:  "wrong",
+			wantErr:   ErrInvalidCredentials,
+			wantBinds: 1,
+		},
+		{
+			name:         "directory failure is not reported as invalid credentials",
+			email:        "ldap@example.com",
+			password:     "ldap-password",
+			directoryErr: errUnreachable,
+			wantErr:      errUnreachable,
+			wantBinds:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeUsers()
+			users.addLocal(t, "local@example.com", "local-password")
+			directory := &fakeDirectory{
+				passwords: map[string]string{
+					"local@example.com": "directory-password",
+					"ldap@example.com":  "ldap-password",
+				},
+				err: tt.directoryErr,
+			}
+
+			user, err := newChain(users, directory).verifyCredentials(context.Background(), tt.email, tt.password)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("verifyCredentials() error = %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr != ErrInvalidCredentials && errors.Is(err, ErrInvalidCredentials) {
+					t.Errorf("verifyCredentials() error = %v, want it not to be %v", err, ErrInvalidCredentials)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("verifyCredentials() error = %v", err)
+				}
+				if user.Email != tt.email {
+					t.Errorf("verifyCredentials() user = %q, want %q", user.Email, tt.email)
+				}
+			}
+			if directory.binds != tt.wantBinds {
+				t.Errorf("directory binds = %d, want %d", directory.binds, tt.wantBinds)
+			}
+		})
+	}
+}
+
+func TestLDAPVerifier_ProvisionsUserAndSyncsRoles(t *testing.T) {
+	users := newFakeUsers()
+	directory := &fakeDirectory{
+		passwords: map[string]string{"ldap@example.com": "ldap-password"},
+		groups:    map[string][]string{"ldap@example.com": {"cn=admins,dc=example", "cn=staff,dc=example"}},
+	}
+	chain := newChain(users, directory)
+	ctx := context.Background()
+
+	user, err := chain.verifyCredentials(ctx, "ldap@example.com", "ldap-password")
+	if err != nil {
+		t.Fatalf("verifyCredentials() error = %v", err)
+	}
+	if len(user.PassHash) != 0 {
+		t.Errorf("provisioned user has a local password")
+	}
+	if got := users.roles[user.ID]; !reflect.DeepEqual(got, []string{"admin"}) {
+		t.Errorf("roles = %v, want [admin]", got)
+	}
+
+	// The provisioned user has no local password, so the local verifier
+	// must keep passing it on to the directory.
+	again, err := chain.verifyCredentials(ctx, "ldap@example.com", "ldap-password")
+	if err != nil {
+		t.Fatalf("second verifyCredentials() error = %v", err)
+	}
+	if again.ID != user.ID {
+		t.Errorf("second login user id = %d, want %d", again.ID, user.ID)
+	}
+	if directory.binds != 2 {
+		t.Errorf("directory binds = %d, want 2", directory.binds)
+	}
+
+	if _, err := chain.verifyCredentials(ctx, "ldap@example.com", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("verifyCredentials() with empty password error = %v, want %v", err, ErrInvalidCredentials)
+	}
+}
+
+func TestLDAPVerifier_RejectsPendingUser(t *testing.T) {
+	users := newFakeUsers()
+	users.users["invited@example.com"] = models.User{ID: 1, Email: "invited@example.com", Status: models.UserStatusPending}
+	directory := &fakeDirectory{
+		passwords: map[string]string{"invited@example.com": "ldap-password"},
+		groups:    map[string][]string{"invited@example.com": {"cn=admins,dc=example"}},
+	}
+
+	_, err := newChain(users, directory).verifyCredentials(context.Background(), "invited@example.com", "ldap-password")
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("verifyCredentials() of pending user error = %v, want %v", err, ErrInvalidCredentials)
+	}
+	if _, ok := users.roles[1]; ok {
+		t.Errorf("roles of pending user were synced: %v", users.roles[1])
+	}
+}
+
+func TestLDAPVerifier_LeavesLocalAccountRoles(t *testing.T) {
+	users := newFakeUsers()
+	users.addLocal(t, "root@example.com", "local-password")
+	directory := &fakeDirectory{passwords: map[string]string{"root@example.com": "ldap-password"}}
+
+	_, err := newChain(users, directory).verifyCredentials(context.Background(), "root@example.com", "ldap-password")
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("verifyCredentials() of local account error = %v, want %v", err, ErrInvalidCredentials)
+	}
+	if len(users.roles) != 0 {
+		t.Errorf("roles of local account were synced: %v", users.roles)
+	}
+}
@@ -0,0 +1,110 @@
+package eventcleaner
+
+import (
+	"context"
+	"log/slog"
+	"sso/internal/lib/metrics"
+	"time"
+)
+
+const (
+	modeDelete  = "delete"
+	modeArchive = "archive"
+)
+
+type EventPurger interface {
+	PurgeSentEvents(ctx context.Context, olderThan time.Duration, limit int, archive bool) (int64, error)
+}
+
+// Cleaner is the outbox retention job. It removes events that were sent
+// more than retention ago, deleting or archiving them.
+type Cleaner struct {
+	log        *slog.Logger
+	purger     EventPurger
+	retention  time.Duration
+	batchSize  int
+	batchPause time.Duration
+	archive    bool
+}
+
+// New returns a new Cleaner. Batches of batchSize rows are purged with a
+// pause of batchPause between them, so writers aren't starved.
+func New(
+	log *slog.Logger,
+	purger EventPurger,
+	retention time.Duration,
+	batchSize int,
+	batchPause time.Duration,
+	archive bool,
+) *Cleaner {
+	return &Cleaner{
+		log:        log,
+		purger:     purger,
+		retention:  retention,
+		batchSize:  batchSize,
+		batchPause: batchPause,
+		archive:    archive,
+	}
+}
+
+func (c *Cleaner) StartCleaning(ctx context.Context, interval time.Duration) error {
+	const op = "eventcleaner.StartCleaning"
+
+	log := c.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("stopping event cleaning")
+			return ctx.Err()
+		case <-ticker.C:
+			c.purge(ctx)
+		}
+	}
+}
+
+// purge removes expired events batch by batch until none are left.
+func (c *Cleaner) purge(ctx context.Context) {
+	const op = "eventcleaner.purge"
+
+	log := c.log.With(slog.String("op", op), slog.String("mode", c.mode()))
+
+	var total int64
+	for {
+		purged, err := c.purger.PurgeSentEvents(ctx, c.retention, c.batchSize, c.archive)
+		if err != nil {
+			log.Error("failed to purge sent events", slog.String("error", err.Error()))
+			metrics.ObservePurgeRun("error")
+			return
+		}
+
+		total += purged
+		metrics.ObservePurge(c.mode(), purged)
+
+		if purged < int64(c.batchSize) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.batchPause):
+		}
+	}
+
+	metrics.ObservePurgeRun("ok")
+
+	if total > 0 {
+		log.Info("sent events purged", slog.Int64("count", total))
+	}
+}
+
+func (c *Cleaner) mode() string {
+	if c.archive {
+		return modeArchive
+	}
+	return modeDelete
+}
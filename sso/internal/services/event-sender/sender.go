@@ -3,35 +3,69 @@ package eventsender
 import (
 	"context"
 	"errors"
+	"events"
 	"log/slog"
 	"sso/internal/domain/models"
+	"sso/internal/lib/bus"
+	"sso/internal/lib/metrics"
 	"sso/internal/storage"
 	"time"
 )
 
 type EventProcessor interface {
-	GetNewEvent(ctx context.Context) (models.Event, error)
-	MarkEventAsDone(ctx context.Context, eventID int64) error
+	ClaimEvents(ctx context.Context, relayID string, limit int, lease time.Duration) ([]models.Event, error)
+	MarkEventsAsDone(ctx context.Context, relayID string, eventIDs []int64) error
+	ScheduleEventRetries(ctx context.Context, relayID string, failures []models.EventFailure) error
+	MarkEventsAsFailed(ctx context.Context, relayID string, failures []models.EventFailure) error
 }
 
 type EventProducer interface {
-	Send(ctx context.Context, key, value []byte) error
+	SendEvents(ctx context.Context, events []models.Event) error
+}
+
+type DeadLetterProducer interface {
+	SendDeadLetters(ctx context.Context, events []models.Event) error
 }
 
 type Sender struct {
-	EventProcessor EventProcessor
-	EventProducer  EventProducer
-	log            *slog.Logger
+	EventProcessor     EventProcessor
+	EventProducer      EventProducer
+	DeadLetterProducer DeadLetterProducer
+	log                *slog.Logger
+	relayID            string
+	batchSize          int
+	lease              time.Duration
+	retryPolicy        events.RetryPolicy
 }
 
-func New(log *slog.Logger, eventProcessor EventProcessor, eventProducer EventProducer) *Sender {
+// New returns a new Sender. relayID identifies the sender among the
+// replicas sharing the outbox; claimed events are leased to it for lease,
+// which must be longer than publishing a batch takes. After
+// retryPolicy.MaxAttempts an event is dead-lettered and marked failed.
+func New(
+	log *slog.Logger,
+	eventProcessor EventProcessor,
+	eventProducer EventProducer,
+	deadLetterProducer DeadLetterProducer,
+	relayID string,
+	batchSize int,
+	lease time.Duration,
+	retryPolicy events.RetryPolicy,
+) *Sender {
 	return &Sender{
-		EventProcessor: eventProcessor,
-		EventProducer:  eventProducer,
-		log:            log,
+		EventProcessor:     eventProcessor,
+		EventProducer:      eventProducer,
+		DeadLetterProducer: deadLetterProducer,
+		log:                log.With(slog.String("relay_id", relayID)),
+		relayID:            relayID,
+		batchSize:          batchSize,
+		lease:              lease,
+		retryPolicy:        retryPolicy,
 	}
 }
 
+// StartProcessingEvents relays the outbox to the producer. Every
+// handlePeriod it sends batches until the backlog is drained.
 func (s *Sender) StartProcessingEvents(ctx context.Context, handlePeriod time.Duration) error {
 	const op = "eventsender.StartProcessingEvents"
 
@@ -46,43 +80,127 @@ func (s *Sender) StartProcessingEvents(ctx context.Context, handlePeriod time.Du
 			log.Info("stopping event processing")
 			return ctx.Err()
 		case <-ticker.C:
-			s.processNewEvent(ctx)
+			s.drain(ctx)
+		}
+	}
+}
+
+// drain sends batches while full ones keep coming, so a backlog doesn't
+// wait for the next tick.
+func (s *Sender) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		if sent := s.processNewEvents(ctx); sent < s.batchSize {
+			return
 		}
 	}
 }
 
-func (s *Sender) processNewEvent(ctx context.Context) {
-	const op = "eventsender.processNewEvent"
+// processNewEvents sends one batch and returns the number of events sent.
+func (s *Sender) processNewEvents(ctx context.Context) int {
+	const op = "eventsender.processNewEvents"
 
 	log := s.log.With(slog.String("op", op))
-	event, err := s.EventProcessor.GetNewEvent(ctx)
+	events, err := s.EventProcessor.ClaimEvents(ctx, s.relayID, s.batchSize, s.lease)
 	if err != nil {
 		if errors.Is(err, storage.ErrNoNewEvents) {
-			return
+			log.Debug("no new events")
+			return 0
 		}
-		log.Error("failed to get new event", slog.String("error", err.Error()))
-		return
+		log.Error("failed to claim new events", slog.String("error", err.Error()))
+		return 0
 	}
 
-	if event.ID == 0 {
-		log.Debug("no new events")
-		return
+	if err := s.EventProducer.SendEvents(ctx, events); err != nil {
+		log.Error("failed to send events to producer",
+			slog.Int("count", len(events)),
+			slog.String("error", err.Error()))
+		for _, event := range events {
+			metrics.ObserveFailed(event.Type, bus.EventID(event))
+		}
+		s.handleFailure(ctx, events, err)
+		return 0
+	}
+	for _, event := range events {
+		metrics.ObservePublished(event.Type, bus.EventID(event))
 	}
 
-	sendErr := s.EventProducer.Send(ctx, []byte(event.Type), []byte(event.Payload))
-	if sendErr != nil {
-		log.Error("failed to send event to producer",
-			slog.Int64("event_id", event.ID),
-			slog.String("error", sendErr.Error()))
-		return
+	eventIDs := make([]int64, 0, len(events))
+	for _, event := range events {
+		eventIDs = append(eventIDs, event.ID)
 	}
 
-	if err := s.EventProcessor.MarkEventAsDone(ctx, event.ID); err != nil {
+	if err := s.EventProcessor.MarkEventsAsDone(ctx, s.relayID, eventIDs); err != nil {
 		log.Error(
-			"failed to mark event as sent",
-			slog.Int64("event_id", event.ID),
+			"failed to mark events as sent",
+			slog.Int("count", len(events)),
 			slog.String("error", err.Error()),
 		)
-		return
+		return 0
+	}
+
+	log.Debug("events sent", slog.Int("count", len(events)))
+
+	return len(events)
+}
+
+// handleFailure schedules the next attempt of every event in a failed
+// batch. Events that ran out of attempts go to the dead-letter topic
+// instead; if that fails too, they are retried like the rest.
+func (s *Sender) handleFailure(ctx context.Context, events []models.Event, sendErr error) {
+	const op = "eventsender.handleFailure"
+
+	log := s.log.With(slog.String("op", op))
+
+	var (
+		retries     []models.EventFailure
+		deadLetters []models.Event
+	)
+	for _, event := range events {
+		if event.Attempts+1 >= s.retryPolicy.MaxAttempts {
+			event.Attempts++
+			event.LastError = sendErr.Error()
+			deadLetters = append(deadLetters, event)
+			continue
+		}
+		retries = append(retries, models.EventFailure{
+			EventID: event.ID,
+			Error:   sendErr.Error(),
+			RetryIn: s.retryPolicy.Delay(event.Attempts),
+		})
+	}
+
+	if len(deadLetters) > 0 {
+		failures := make([]models.EventFailure, 0, len(deadLetters))
+		for _, event := range deadLetters {
+			failures = append(failures, models.EventFailure{
+				EventID: event.ID,
+				Error:   event.LastError,
+				RetryIn: s.retryPolicy.MaxBackoff,
+			})
+		}
+
+		if err := s.DeadLetterProducer.SendDeadLetters(ctx, deadLetters); err != nil {
+			log.Error("failed to send dead letters",
+				slog.Int("count", len(deadLetters)),
+				slog.String("error", err.Error()))
+			retries = append(retries, failures...)
+		} else if err := s.EventProcessor.MarkEventsAsFailed(ctx, s.relayID, failures); err != nil {
+			log.Error("failed to mark events as failed",
+				slog.Int("count", len(failures)),
+				slog.String("error", err.Error()))
+		} else {
+			for _, event := range deadLetters {
+				metrics.ObserveDeadLettered(event.Type, bus.EventID(event))
+			}
+			log.Warn("events dead-lettered", slog.Int("count", len(failures)))
+		}
+	}
+
+	if len(retries) > 0 {
+		if err := s.EventProcessor.ScheduleEventRetries(ctx, s.relayID, retries); err != nil {
+			log.Error("failed to schedule event retries",
+				slog.Int("count", len(retries)),
+				slog.String("error", err.Error()))
+		}
 	}
 }
@@ -0,0 +1,382 @@
+package eventsender
+
+import (
+	"context"
+	"errors"
+	"events"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sso/internal/domain/models"
+	"sso/internal/lib/bus"
+	"sso/internal/lib/membus"
+	"sso/internal/storage"
+	"sso/internal/storage/sqlite"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+type recordingProducer struct {
+	mu        sync.Mutex
+	published map[int64]int
+}
+
+func (p *recordingProducer) SendEvents(_ context.Context, events []models.Event) error {
+	// Give the other relays a chance to race for the same rows.
+	time.Sleep(time.Millisecond)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, event := range events {
+		p.published[event.ID]++
+	}
+	return nil
+}
+
+func (p *recordingProducer) SendDeadLetters(context.Context, []models.Event) error {
+	return errors.New("unexpected dead letter")
+}
+
+func (p *recordingProducer) total() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.published)
+}
+
+func newTestStorage(t *testing.T) string {
+	t.Helper()
+
+	storagePath := filepath.Join(t.TempDir(), "sso.db")
+	m, err := migrate.New("file://../../../migrations", "sqlite3://"+storagePath)
+	if err != nil {
+		t.Fatalf("create migrator: %v", err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+	if srcErr, dbErr := m.Close(); srcErr != nil || dbErr != nil {
+		t.Fatalf("close migrator: %v, %v", srcErr, dbErr)
+	}
+
+	return storagePath
+}
+
+func newTestSender(t *testing.T, storagePath string, relayID string, producer *recordingProducer) *Sender {
+	t.Helper()
+
+	s, err := sqlite.New(storagePath)
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+
+	return New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		s,
+		producer,
+		producer,
+		relayID,
+		10,
+		time.Minute,
+		events.RetryPolicy{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Minute},
+	)
+}
+
+func TestSender_ConcurrentRelaysPublishOnce(t *testing.T) {
+	const (
+		relays = 4
+		events = 200
+	)
+
+	storagePath := newTestStorage(t)
+	seed, err := sqlite.New(storagePath)
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	for i := range events {
+		if _, err := seed.SaveUser(context.Background(), fmt.Sprintf("user%d@example.com", i), []byte("hash")); err != nil {
+			t.Fatalf("save user: %v", err)
+		}
+	}
+
+	producer := &recordingProducer{published: make(map[int64]int)}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := range relays {
+		sender := newTestSender(t, storagePath, fmt.Sprintf("relay-%d", i), producer)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil && producer.total() < events {
+				sender.drain(ctx)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		t.Fatalf("relays published %d of %d events before timeout", producer.total(), events)
+	}
+	for eventID, count := range producer.published {
+		if count != 1 {
+			t.Errorf("event %d published %d times", eventID, count)
+		}
+	}
+
+	if _, err := seed.ClaimEvents(context.Background(), "check", events, time.Minute); !errors.Is(err, storage.ErrNoNewEvents) {
+		t.Errorf("expected the outbox to be drained, got %v", err)
+	}
+}
+
+func TestSender_ReclaimsExpiredLease(t *testing.T) {
+	storagePath := newTestStorage(t)
+	s, err := sqlite.New(storagePath)
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	if _, err := s.SaveUser(context.Background(), "user@example.com", []byte("hash")); err != nil {
+		t.Fatalf("save user: %v", err)
+	}
+
+	// A relay that claims the event and dies before publishing it.
+	if _, err := s.ClaimEvents(context.Background(), "crashed", 10, time.Second); err != nil {
+		t.Fatalf("claim events: %v", err)
+	}
+
+	producer := &recordingProducer{published: make(map[int64]int)}
+	sender := newTestSender(t, storagePath, "survivor", producer)
+
+	if sent := sender.processNewEvents(context.Background()); sent != 0 {
+		t.Fatalf("leased event was published %d times before the lease expired", sent)
+	}
+
+	time.Sleep(2 * time.Second)
+
+	if sent := sender.processNewEvents(context.Background()); sent != 1 {
+		t.Fatalf("expected the expired lease to be reclaimed, published %d events", sent)
+	}
+}
+
+// crashingOutbox loses the first MarkEventsAsDone, as a relay that
+// crashes right after publishing would.
+type crashingOutbox struct {
+	*sqlite.Storage
+	crashed bool
+}
+
+func (o *crashingOutbox) MarkEventsAsDone(ctx context.Context, relayID string, eventIDs []int64) error {
+	if !o.crashed {
+		o.crashed = true
+		return errors.New("relay crashed")
+	}
+	return o.Storage.MarkEventsAsDone(ctx, relayID, eventIDs)
+}
+
+func TestSender_RepublishesWithStableEventID(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	s, err := sqlite.New(newTestStorage(t))
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	if _, err := s.SaveUser(context.Background(), "user@example.com", []byte("hash")); err != nil {
+		t.Fatalf("save user: %v", err)
+	}
+
+	memory := membus.New(log)
+	messages := memory.Subscribe("events", 2)
+	producer, err := bus.NewProducer(log, memory.Publisher("events"), "json")
+	if err != nil {
+		t.Fatalf("create producer: %v", err)
+	}
+
+	sender := New(log, &crashingOutbox{Storage: s}, producer, producer, "relay", 10, time.Second,
+		events.RetryPolicy{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Minute})
+
+	if sent := sender.processNewEvents(context.Background()); sent != 0 {
+		t.Fatalf("events reported sent although marking them failed: %d", sent)
+	}
+
+	// The event stays leased to the crashed relay until the lease expires.
+	time.Sleep(2 * time.Second)
+
+	if sent := sender.processNewEvents(context.Background()); sent != 1 {
+		t.Fatalf("expected the event to be published again, published %d", sent)
+	}
+	if sent := sender.processNewEvents(context.Background()); sent != 0 {
+		t.Fatalf("event published after it was marked sent")
+	}
+
+	first, second := <-messages, <-messages
+	eventID := first.Headers[events.HeaderEventID]
+	if eventID == "" {
+		t.Fatal("message has no event id")
+	}
+	if second.Headers[events.HeaderEventID] != eventID {
+		t.Errorf("republished event id = %q, want %q", second.Headers[events.HeaderEventID], eventID)
+	}
+	if string(first.Key) != string(second.Key) {
+		t.Errorf("republished key = %q, want %q", second.Key, first.Key)
+	}
+}
+
+// failingProducer fails every publish and records the dead letters. A
+// non-nil deadLetterErr fails dead-lettering too.
+type failingProducer struct {
+	deadLetterErr error
+	deadLetters   []models.Event
+}
+
+func (p *failingProducer) SendEvents(context.Context, []models.Event) error {
+	return errors.New("broker down")
+}
+
+func (p *failingProducer) SendDeadLetters(_ context.Context, events []models.Event) error {
+	if p.deadLetterErr != nil {
+		return p.deadLetterErr
+	}
+	p.deadLetters = append(p.deadLetters, events...)
+	return nil
+}
+
+// fakeOutbox hands out a single event and records what the sender does
+// with it.
+type fakeOutbox struct {
+	event   models.Event
+	retries []models.EventFailure
+	failed  []models.EventFailure
+}
+
+func (o *fakeOutbox) ClaimEvents(context.Context, string, int, time.Duration) ([]models.Event, error) {
+	return []models.Event{o.event}, nil
+}
+
+func (o *fakeOutbox) MarkEventsAsDone(context.Context, string, []int64) error {
+	return errors.New("unexpected success")
+}
+
+func (o *fakeOutbox) ScheduleEventRetries(_ context.Context, _ string, failures []models.EventFailure) error {
+	o.retries = append(o.retries, failures...)
+	return nil
+}
+
+func (o *fakeOutbox) MarkEventsAsFailed(_ context.Context, _ string, failures []models.EventFailure) error {
+	o.failed = append(o.failed, failures...)
+	return nil
+}
+
+func TestSender_HandleFailure(t *testing.T) {
+	policy := events.RetryPolicy{MaxAttempts: 10, Backoff: time.Second, MaxBackoff: 8 * time.Second}
+
+	tests := []struct {
+		name           string
+		attempts       int
+		deadLetterErr  error
+		wantRetryIn    time.Duration
+		wantDeadLetter bool
+	}{
+		{name: "first failure", attempts: 0, wantRetryIn: time.Second},
+		{name: "second failure", attempts: 1, wantRetryIn: 2 * time.Second},
+		{name: "third failure", attempts: 2, wantRetryIn: 4 * time.Second},
+		{name: "reaches max backoff", attempts: 3, wantRetryIn: 8 * time.Second},
+		{name: "stays at max backoff", attempts: 8, wantRetryIn: 8 * time.Second},
+		{name: "last attempt", attempts: 9, wantDeadLetter: true},
+		{
+			name:          "dead letter fails",
+			attempts:      9,
+			deadLetterErr: errors.New("dead-letter topic down"),
+			wantRetryIn:   8 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &fakeOutbox{event: models.Event{ID: 1, Type: "UserCreated", Attempts: tt.attempts}}
+			producer := &failingProducer{deadLetterErr: tt.deadLetterErr}
+			sender := New(slog.New(slog.NewTextHandler(io.Discard, nil)),
+				outbox, producer, producer, "relay", 10, time.Minute, policy)
+
+			if sent := sender.processNewEvents(context.Background()); sent != 0 {
+				t.Fatalf("processNewEvents() = %d, want 0", sent)
+			}
+
+			if tt.wantDeadLetter {
+				if len(producer.deadLetters) != 1 || producer.deadLetters[0].Attempts != policy.MaxAttempts {
+					t.Errorf("dead letters = %+v, want the event after %d attempts", producer.deadLetters, policy.MaxAttempts)
+				}
+				if len(outbox.failed) != 1 || outbox.failed[0].EventID != 1 {
+					t.Errorf("failed = %+v, want event 1", outbox.failed)
+				}
+				if len(outbox.retries) != 0 {
+					t.Errorf("dead-lettered event was retried: %+v", outbox.retries)
+				}
+				return
+			}
+
+			if len(outbox.failed) != 0 {
+				t.Errorf("event marked failed: %+v", outbox.failed)
+			}
+			if len(outbox.retries) != 1 {
+				t.Fatalf("retries = %+v, want one", outbox.retries)
+			}
+			if got := outbox.retries[0]; got.EventID != 1 || got.RetryIn != tt.wantRetryIn || got.Error != "broker down" {
+				t.Errorf("retry = %+v, want event 1 in %v", got, tt.wantRetryIn)
+			}
+		})
+	}
+}
+
+func TestSender_DeadLettersAfterMaxAttempts(t *testing.T) {
+	const maxAttempts = 3
+
+	s, err := sqlite.New(newTestStorage(t))
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := s.SaveUser(ctx, "user@example.com", []byte("hash")); err != nil {
+		t.Fatalf("save user: %v", err)
+	}
+
+	// No backoff, so every pass can claim the event again.
+	producer := &failingProducer{}
+	sender := New(slog.New(slog.NewTextHandler(io.Discard, nil)), s, producer, producer, "relay", 10, time.Minute,
+		events.RetryPolicy{MaxAttempts: maxAttempts})
+
+	for range maxAttempts {
+		sender.processNewEvents(ctx)
+	}
+
+	if len(producer.deadLetters) != 1 {
+		t.Fatalf("dead letters = %d, want 1", len(producer.deadLetters))
+	}
+	if got := producer.deadLetters[0]; got.Attempts != maxAttempts || got.LastError != "broker down" {
+		t.Errorf("dead letter attempts = %d, last error = %q", got.Attempts, got.LastError)
+	}
+	if _, err := s.ClaimEvents(ctx, "check", 10, time.Minute); !errors.Is(err, storage.ErrNoNewEvents) {
+		t.Errorf("failed event is still claimable: %v", err)
+	}
+
+	requeued, err := s.RequeueFailedEvents(ctx, nil)
+	if err != nil {
+		t.Fatalf("RequeueFailedEvents() error = %v", err)
+	}
+	if requeued != 1 {
+		t.Fatalf("RequeueFailedEvents() = %d, want 1", requeued)
+	}
+	claimed, err := s.ClaimEvents(ctx, "check", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim requeued event: %v", err)
+	}
+	if claimed[0].Attempts != 0 {
+		t.Errorf("requeued event attempts = %d, want 0", claimed[0].Attempts)
+	}
+}
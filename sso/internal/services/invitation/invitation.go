@@ -0,0 +1,190 @@
+package invitation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sso/internal/domain/models"
+	"sso/internal/lib/jwt"
+	"sso/internal/storage"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrPermissionDenied  = errors.New("permission denied")
+	ErrInvalidRole       = errors.New("invalid role")
+	ErrAppNotFound       = errors.New("app not found")
+	ErrUserExists        = errors.New("user already exists")
+	ErrInvalidInvitation = errors.New("invalid invitation")
+)
+
+type Invitation struct {
+	log          *slog.Logger
+	userProvider UserProvider
+	appProvider  AppProvider
+	store        Store
+	secret       string
+	ttl          time.Duration
+}
+
+type UserProvider interface {
+	IsAdmin(ctx context.Context, userID int64, orgID int64) (bool, error)
+}
+
+type AppProvider interface {
+	App(ctx context.Context, appID int64) (models.App, error)
+}
+
+type Store interface {
+	CreateInvitation(
+		ctx context.Context,
+		invitation models.Invitation,
+		name string,
+		surname string,
+	) (models.Invitation, error)
+	AcceptInvitation(ctx context.Context, invitationID int64, passHash []byte) (models.Invitation, error)
+}
+
+// New returns a new Invitation service. Invitation tokens are signed
+// with secret and expire after ttl.
+func New(
+	log *slog.Logger,
+	userProvider UserProvider,
+	appProvider AppProvider,
+	store Store,
+	secret string,
+	ttl time.Duration,
+) *Invitation {
+	return &Invitation{
+		log:          log,
+		userProvider: userProvider,
+		appProvider:  appProvider,
+		store:        store,
+		secret:       secret,
+		ttl:          ttl,
+	}
+}
+
+// InviteUser creates a pending user in orgID and returns a signed
+// invitation token for it. Only admins of the organization may invite.
+func (i *Invitation) InviteUser(
+	ctx context.Context,
+	actorID int64,
+	orgID int64,
+	email string,
+	name string,
+	surname string,
+	role string,
+	appIDs []int64,
+) (int64, string, error) {
+	const op = "invitation.InviteUser"
+
+	log := i.log.With(
+		slog.String("op", op),
+		slog.Int64("actor_id", actorID),
+		slog.Int64("org_id", orgID),
+		slog.String("email", email),
+	)
+
+	log.Info("inviting user")
+
+	if role != models.RoleAdmin && role != models.RoleMember {
+		return 0, "", fmt.Errorf("%s: %q: %w", op, role, ErrInvalidRole)
+	}
+
+	isAdmin, err := i.userProvider.IsAdmin(ctx, actorID, orgID)
+	if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+		log.Error("failed to check admin privileges", slog.String("error", err.Error()))
+		return 0, "", fmt.Errorf("%s: check admin: %w", op, err)
+	}
+	if !isAdmin {
+		log.Warn("invite denied")
+		return 0, "", fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+	}
+
+	// Access can only be pre-assigned to apps of the organization.
+	for _, appID := range appIDs {
+		app, err := i.appProvider.App(ctx, appID)
+		if err != nil {
+			if errors.Is(err, storage.ErrAppNotFound) {
+				log.Warn("app not found", slog.Int64("app_id", appID))
+				return 0, "", fmt.Errorf("%s: app %d: %w", op, appID, ErrAppNotFound)
+			}
+			log.Error("failed to get app", slog.String("error", err.Error()))
+			return 0, "", fmt.Errorf("%s: get app: %w", op, err)
+		}
+		if app.OrgID != orgID {
+			log.Warn("app belongs to another organization", slog.Int64("app_id", appID))
+			return 0, "", fmt.Errorf("%s: app %d: %w", op, appID, ErrPermissionDenied)
+		}
+	}
+
+	invitation, err := i.store.CreateInvitation(ctx, models.Invitation{
+		OrgID:     orgID,
+		Email:     email,
+		Role:      role,
+		AppIDs:    appIDs,
+		InvitedBy: actorID,
+		ExpiresAt: time.Now().Add(i.ttl),
+	}, name, surname)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserExists) {
+			log.Warn("user already exists", slog.String("error", err.Error()))
+			return 0, "", fmt.Errorf("%s: create invitation: %w", op, ErrUserExists)
+		}
+		log.Error("failed to create invitation", slog.String("error", err.Error()))
+		return 0, "", fmt.Errorf("%s: create invitation: %w", op, err)
+	}
+
+	token, err := jwt.NewInvitationToken(invitation, i.secret)
+	if err != nil {
+		log.Error("failed to create token", slog.String("error", err.Error()))
+		return 0, "", fmt.Errorf("%s: create token: %w", op, err)
+	}
+
+	log.Info("user invited",
+		slog.Int64("user_id", invitation.UserID),
+		slog.Int64("invitation_id", invitation.ID),
+	)
+
+	return invitation.UserID, token, nil
+}
+
+// AcceptInvitation sets the password of the invited user and activates it.
+func (i *Invitation) AcceptInvitation(ctx context.Context, token string, password string) (int64, error) {
+	const op = "invitation.AcceptInvitation"
+
+	log := i.log.With(slog.String("op", op))
+
+	claims, err := jwt.ParseInvitationToken(token, i.secret)
+	if err != nil {
+		log.Warn("invalid invitation token", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, ErrInvalidInvitation)
+	}
+
+	log = log.With(slog.Int64("invitation_id", claims.ID))
+	log.Info("accepting invitation")
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Error("failed to hash password", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: hash password: %w", op, err)
+	}
+
+	invitation, err := i.store.AcceptInvitation(ctx, claims.ID, passHash)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvitationNotFound) {
+			log.Warn("invitation not found", slog.String("error", err.Error()))
+			return 0, fmt.Errorf("%s: %w", op, ErrInvalidInvitation)
+		}
+		log.Error("failed to accept invitation", slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: accept invitation: %w", op, err)
+	}
+
+	log.Info("invitation accepted", slog.Int64("user_id", invitation.UserID))
+
+	return invitation.UserID, nil
+}
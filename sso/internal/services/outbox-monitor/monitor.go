@@ -0,0 +1,58 @@
+package outboxmonitor
+
+import (
+	"context"
+	"log/slog"
+	"sso/internal/lib/metrics"
+	"time"
+)
+
+type BacklogReader interface {
+	OutboxBacklog(ctx context.Context) (unsent int64, oldestAge time.Duration, err error)
+}
+
+// Monitor exports the size of the outbox backlog and the age of its
+// oldest event, which tell how far behind the relay is.
+type Monitor struct {
+	log    *slog.Logger
+	reader BacklogReader
+}
+
+func New(log *slog.Logger, reader BacklogReader) *Monitor {
+	return &Monitor{
+		log:    log,
+		reader: reader,
+	}
+}
+
+func (m *Monitor) StartMonitoring(ctx context.Context, interval time.Duration) error {
+	const op = "outboxmonitor.StartMonitoring"
+
+	log := m.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.observe(ctx, log)
+
+		select {
+		case <-ctx.Done():
+			log.Info("stopping outbox monitoring")
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Monitor) observe(ctx context.Context, log *slog.Logger) {
+	unsent, oldestAge, err := m.reader.OutboxBacklog(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error("failed to read outbox backlog", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	metrics.ObserveBacklog(unsent, oldestAge)
+}
@@ -0,0 +1,189 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"events"
+	"fmt"
+	"sso/internal/storage"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// GrantAppAccess allows userID to log in to a restricted app.
+func (s *Storage) GrantAppAccess(ctx context.Context, appID int64, userID int64) (err error) {
+	const op = "storage.sqlite.GrantAppAccess"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	granted, err := s.grantAppAccess(ctx, tx, appID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !granted {
+		return fmt.Errorf("%s: %w", op, storage.ErrAccessExists)
+	}
+
+	return nil
+}
+
+// grantAppAccess inserts an app access grant together with its
+// AppAccessGranted event. It reports false if the grant already exists.
+func (s *Storage) grantAppAccess(ctx context.Context, tx *sql.Tx, appID int64, userID int64) (bool, error) {
+	const op = "storage.sqlite.grantAppAccess"
+
+	query, args, err := sq.Insert("app_access").
+		Columns("app_id", "user_id").
+		Values(appID, userID).
+		Suffix("ON CONFLICT DO NOTHING").
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if inserted == 0 {
+		return false, nil
+	}
+
+	if err := s.saveAccessEvent(ctx, tx, events.TypeAppAccessGranted, appID, userID); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+// RevokeAppAccess removes the access grant of userID to the app.
+func (s *Storage) RevokeAppAccess(ctx context.Context, appID int64, userID int64) (err error) {
+	const op = "storage.sqlite.RevokeAppAccess"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	query, args, err := sq.Delete("app_access").
+		Where(sq.Eq{"app_id": appID, "user_id": userID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAccessNotFound)
+	}
+
+	if err := s.saveAccessEvent(ctx, tx, events.TypeAppAccessRevoked, appID, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// HasAppAccess reports whether userID was granted access to the app.
+func (s *Storage) HasAppAccess(ctx context.Context, appID int64, userID int64) (bool, error) {
+	const op = "storage.sqlite.HasAppAccess"
+
+	query, args, err := sq.Select("1").
+		From("app_access").
+		Where(sq.Eq{"app_id": appID, "user_id": userID}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var found int
+	if err := stmt.QueryRowContext(ctx, args...).Scan(&found); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+// SetAppRestricted makes the app accept only users granted access to it,
+// or opens it to everyone.
+func (s *Storage) SetAppRestricted(ctx context.Context, appID int64, restricted bool) error {
+	const op = "storage.sqlite.SetAppRestricted"
+
+	query, args, err := sq.Update("apps").
+		Set("restricted", restricted).
+		Where(sq.Eq{"id": appID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) saveAccessEvent(ctx context.Context, tx *sql.Tx, eventType string, appID int64, userID int64) error {
+	if err := s.SaveEvent(ctx, tx, eventType, events.AppAccess{
+		AppID:  appID,
+		UserID: userID,
+	}); err != nil {
+		return fmt.Errorf("save event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"events"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type userSnapshot struct {
+	id     int64
+	email  string
+	status string
+}
+
+// SnapshotUsers writes a UserSnapshot event to the outbox for each of up
+// to limit users with ids after afterID, and up to toID unless it is 0, in
+// id order. It returns the id of the last user snapshotted and how many
+// were, so callers page through the users in short transactions.
+func (s *Storage) SnapshotUsers(
+	ctx context.Context,
+	afterID int64,
+	toID int64,
+	limit int,
+) (lastID int64, snapshotted int, err error) {
+	const op = "storage.sqlite.SnapshotUsers"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	selectUsers := sq.Select("id", "email", "status").
+		From("users").
+		Where(sq.Gt{"id": afterID}).
+		OrderBy("id").
+		Limit(uint64(limit))
+	if toID > 0 {
+		selectUsers = selectUsers.Where(sq.LtOrEq{"id": toID})
+	}
+
+	query, args, err := selectUsers.ToSql()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var users []userSnapshot
+	for rows.Next() {
+		var user userSnapshot
+		if err := rows.Scan(&user.id, &user.email, &user.status); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		users = append(users, user)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, user := range users {
+		if err := s.SaveEvent(ctx, tx, events.TypeUserSnapshot, events.UserSnapshot{
+			UserID: user.id,
+			Email:  user.email,
+			Status: user.status,
+		}); err != nil {
+			return 0, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		lastID = user.id
+	}
+
+	return lastID, len(users), nil
+}
@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"events"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSnapshotUsers(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for i := range 5 {
+		if _, err := s.SaveUser(ctx, fmt.Sprintf("user%d@example.com", i), []byte("hash")); err != nil {
+			t.Fatalf("save user: %v", err)
+		}
+	}
+	// Drop the UserCreated events, so only snapshots are left.
+	if _, err := s.db.Exec(`DELETE FROM messages`); err != nil {
+		t.Fatalf("clear outbox: %v", err)
+	}
+
+	var snapshotted []int64
+	for afterID := int64(1); ; {
+		lastID, count, err := s.SnapshotUsers(ctx, afterID, 4, 2)
+		if err != nil {
+			t.Fatalf("SnapshotUsers() error = %v", err)
+		}
+		if count == 0 {
+			break
+		}
+		afterID = lastID
+		snapshotted = append(snapshotted, lastID)
+	}
+	if fmt.Sprint(snapshotted) != "[3 4]" {
+		t.Errorf("pages ended at users %v, want [3 4]", snapshotted)
+	}
+
+	claimed, err := s.ClaimEvents(ctx, "test", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimEvents() error = %v", err)
+	}
+	if len(claimed) != 3 {
+		t.Fatalf("outbox holds %d events, want snapshots of users 2-4", len(claimed))
+	}
+	for i, event := range claimed {
+		if event.Type != events.TypeUserSnapshot || event.PartitionKey != fmt.Sprint(i+2) {
+			t.Errorf("event %d = %s keyed %q, want snapshot of user %d", i, event.Type, event.PartitionKey, i+2)
+		}
+	}
+}
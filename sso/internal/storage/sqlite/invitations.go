@@ -0,0 +1,236 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"events"
+	"fmt"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// CreateInvitation creates a pending user and its invitation. A UserInvited
+// event carrying name and surname lets userservice pre-create the profile.
+// A pending user whose invitation expired is invited again.
+func (s *Storage) CreateInvitation(
+	ctx context.Context,
+	invitation models.Invitation,
+	name string,
+	surname string,
+) (created models.Invitation, err error) {
+	const op = "storage.sqlite.CreateInvitation"
+
+	appIDs, err := json.Marshal(invitation.AppIDs)
+	if err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: marshal app ids: %w", op, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	invitation.UserID, err = s.insertUser(ctx, tx, invitation.Email, []byte{}, models.UserStatusPending)
+	if errors.Is(err, storage.ErrUserExists) {
+		invitation.UserID, err = s.reclaimPendingUser(
+			ctx, tx, invitation.Email, []byte{}, models.UserStatusPending)
+	}
+	if err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query, args, err := sq.Insert("invitations").
+		Columns("user_id", "org_id", "role", "app_ids", "invited_by", "expires_at").
+		Values(
+			invitation.UserID,
+			invitation.OrgID,
+			invitation.Role,
+			string(appIDs),
+			invitation.InvitedBy,
+			invitation.ExpiresAt.UTC(),
+		).ToSql()
+	if err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	invitation.ID, err = res.LastInsertId()
+	if err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.SaveEvent(ctx, tx, events.TypeUserInvited, events.UserInvited{
+		UserID:  invitation.UserID,
+		Email:   invitation.Email,
+		Name:    name,
+		Surname: surname,
+	}); err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return invitation, nil
+}
+
+// AcceptInvitation sets the password of the invited user, activates it
+// and applies the membership and app access of the invitation. An
+// invitation can be accepted once and only before it expires.
+func (s *Storage) AcceptInvitation(
+	ctx context.Context,
+	invitationID int64,
+	passHash []byte,
+) (invitation models.Invitation, err error) {
+	const op = "storage.sqlite.AcceptInvitation"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	query, args, err := sq.Update("invitations").
+		Set("accepted_at", time.Now().UTC()).
+		Where(sq.Eq{"id": invitationID, "accepted_at": nil}).
+		Where(sq.Gt{"expires_at": time.Now().UTC()}).
+		Suffix("RETURNING id, user_id, org_id, role, app_ids, invited_by, expires_at").
+		ToSql()
+	if err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	var appIDs string
+	err = tx.QueryRowContext(ctx, query, args...).Scan(
+		&invitation.ID,
+		&invitation.UserID,
+		&invitation.OrgID,
+		&invitation.Role,
+		&appIDs,
+		&invitation.InvitedBy,
+		&invitation.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Invitation{}, fmt.Errorf("%s: %w", op, storage.ErrInvitationNotFound)
+		}
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := json.Unmarshal([]byte(appIDs), &invitation.AppIDs); err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: unmarshal app ids: %w", op, err)
+	}
+
+	query, args, err = sq.Update("users").
+		SetMap(sq.Eq{"pass_hash": passHash, "status": models.UserStatusActive}).
+		Where(sq.Eq{"id": invitation.UserID, "status": models.UserStatusPending}).
+		Suffix("RETURNING email").
+		ToSql()
+	if err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&invitation.Email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Invitation{}, fmt.Errorf("%s: %w", op, storage.ErrInvitationNotFound)
+		}
+		return models.Invitation{}, fmt.Errorf("%s: activate user: %w", op, err)
+	}
+
+	if err := s.addMember(ctx, tx, invitation.OrgID, invitation.UserID, invitation.Role); err != nil {
+		return models.Invitation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, appID := range invitation.AppIDs {
+		if _, err := s.grantAppAccess(ctx, tx, appID, invitation.UserID); err != nil {
+			return models.Invitation{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return invitation, nil
+}
+
+// reclaimPendingUser takes over the pending user with the given email
+// whose invitation expired unaccepted: the invitation is dropped and the
+// user gets passHash and status. Keeping the user id keeps the profile
+// userservice created for it. It returns storage.ErrUserExists if the
+// email belongs to any other user.
+func (s *Storage) reclaimPendingUser(
+	ctx context.Context,
+	tx *sql.Tx,
+	email string,
+	passHash []byte,
+	status string,
+) (int64, error) {
+	const op = "storage.sqlite.reclaimPendingUser"
+
+	query, args, err := sq.Select("users.id").
+		From("users").
+		Join("invitations ON invitations.user_id = users.id").
+		Where(sq.Eq{
+			"users.email":             email,
+			"users.status":            models.UserStatusPending,
+			"invitations.accepted_at": nil,
+		}).
+		Where(sq.LtOrEq{"invitations.expires_at": time.Now().UTC()}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	var userID int64
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrUserExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query, args, err = sq.Delete("invitations").Where(sq.Eq{"user_id": userID}).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return 0, fmt.Errorf("%s: delete invitation: %w", op, err)
+	}
+
+	query, args, err = sq.Update("users").
+		SetMap(sq.Eq{"pass_hash": passHash, "status": status}).
+		Where(sq.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return 0, fmt.Errorf("%s: update user: %w", op, err)
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"testing"
+	"time"
+)
+
+func createTestInvitation(t *testing.T, s *Storage, email string, ttl time.Duration) models.Invitation {
+	t.Helper()
+
+	invitation, err := s.CreateInvitation(context.Background(), models.Invitation{
+		OrgID:     models.DefaultOrgID,
+		Email:     email,
+		Role:      models.RoleMember,
+		ExpiresAt: time.Now().Add(ttl),
+	}, "Ivan", "Ivanov")
+	if err != nil {
+		t.Fatalf("CreateInvitation() error = %v", err)
+	}
+
+	return invitation
+}
+
+func TestAcceptInvitation_Expired(t *testing.T) {
+	s := newTestStorage(t)
+
+	invitation := createTestInvitation(t, s, "user@example.com", -time.Minute)
+
+	_, err := s.AcceptInvitation(context.Background(), invitation.ID, []byte("hash"))
+	if !errors.Is(err, storage.ErrInvitationNotFound) {
+		t.Fatalf("AcceptInvitation() of expired invitation error = %v, want %v", err, storage.ErrInvitationNotFound)
+	}
+}
+
+func TestCreateInvitation_ReinvitesExpiredPendingUser(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	expired := createTestInvitation(t, s, "user@example.com", -time.Minute)
+	invitation := createTestInvitation(t, s, "user@example.com", time.Hour)
+
+	if invitation.UserID != expired.UserID {
+		t.Errorf("re-invited user id = %d, want %d", invitation.UserID, expired.UserID)
+	}
+
+	_, err := s.CreateInvitation(ctx, models.Invitation{
+		OrgID:     models.DefaultOrgID,
+		Email:     "user@example.com",
+		Role:      models.RoleMember,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, "", "")
+	if !errors.Is(err, storage.ErrUserExists) {
+		t.Fatalf("CreateInvitation() with a pending invitation error = %v, want %v", err, storage.ErrUserExists)
+	}
+
+	if _, err := s.AcceptInvitation(ctx, invitation.ID, []byte("hash")); err != nil {
+		t.Fatalf("AcceptInvitation() error = %v", err)
+	}
+}
+
+func TestSaveUser_ReclaimsExpiredPendingUser(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	expired := createTestInvitation(t, s, "user@example.com", -time.Minute)
+
+	userID, err := s.SaveUser(ctx, "user@example.com", []byte("hash"))
+	if err != nil {
+		t.Fatalf("SaveUser() error = %v", err)
+	}
+	if userID != expired.UserID {
+		t.Errorf("SaveUser() = %d, want %d", userID, expired.UserID)
+	}
+
+	user, err := s.User(ctx, "user@example.com")
+	if err != nil {
+		t.Fatalf("User() error = %v", err)
+	}
+	if user.Status != models.UserStatusActive || string(user.PassHash) != "hash" {
+		t.Errorf("User() = %+v, want active user with the new password", user)
+	}
+
+	if _, err := s.AcceptInvitation(ctx, expired.ID, []byte("other")); !errors.Is(err, storage.ErrInvitationNotFound) {
+		t.Errorf("AcceptInvitation() after registration error = %v, want %v", err, storage.ErrInvitationNotFound)
+	}
+}
+
+func TestSaveUser_PendingInvitationKeepsEmail(t *testing.T) {
+	s := newTestStorage(t)
+
+	createTestInvitation(t, s, "user@example.com", time.Hour)
+
+	_, err := s.SaveUser(context.Background(), "user@example.com", []byte("hash"))
+	if !errors.Is(err, storage.ErrUserExists) {
+		t.Fatalf("SaveUser() with a pending invitation error = %v, want %v", err, storage.ErrUserExists)
+	}
+}
@@ -0,0 +1,380 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"events"
+	"fmt"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattn/go-sqlite3"
+)
+
+// CreateOrganization creates an organization with ownerID as its admin.
+func (s *Storage) CreateOrganization(ctx context.Context, name string, ownerID int64) (orgID int64, err error) {
+	const op = "storage.sqlite.CreateOrganization"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	query, args, err := sq.Insert("organizations").Columns("name").Values(name).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+
+		if errors.As(err, &sqliteErr) &&
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrOrgExists)
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	orgID, err = res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.addMember(ctx, tx, orgID, ownerID, models.RoleAdmin); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orgID, nil
+}
+
+func (s *Storage) Organization(ctx context.Context, orgID int64) (models.Organization, error) {
+	const op = "storage.sqlite.Organization"
+	var org models.Organization
+
+	query, args, err := sq.Select("id", "name").From("organizations").Where(sq.Eq{"id": orgID}).ToSql()
+	if err != nil {
+		return org, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return org, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, args...)
+	if err := row.Scan(&org.ID, &org.Name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Organization{}, fmt.Errorf("%s: %w", op, storage.ErrOrgNotFound)
+		}
+		return models.Organization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return org, nil
+}
+
+// CreateMembershipInvitation invites a registered user into the
+// organization. Inviting the user again replaces the pending invitation.
+func (s *Storage) CreateMembershipInvitation(
+	ctx context.Context,
+	invitation models.MembershipInvitation,
+) (err error) {
+	const op = "storage.sqlite.CreateMembershipInvitation"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	query, args, err := sq.Select("1").
+		From("memberships").
+		Where(sq.Eq{"org_id": invitation.OrgID, "user_id": invitation.UserID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	var found int
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&found)
+	if err == nil {
+		return fmt.Errorf("%s: %w", op, storage.ErrMemberExists)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: check membership: %w", op, err)
+	}
+
+	query, args, err = sq.Insert("membership_invitations").
+		Columns("org_id", "user_id", "role", "invited_by", "expires_at").
+		Values(
+			invitation.OrgID,
+			invitation.UserID,
+			invitation.Role,
+			invitation.InvitedBy,
+			invitation.ExpiresAt.UTC(),
+		).
+		Suffix(`ON CONFLICT (org_id, user_id) DO UPDATE SET
+			role = excluded.role,
+			invited_by = excluded.invited_by,
+			expires_at = excluded.expires_at,
+			created_at = CURRENT_TIMESTAMP`).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// AcceptMembershipInvitation makes userID a member of the organization
+// it was invited to, with the invited role. Expired invitations can't be
+// accepted.
+func (s *Storage) AcceptMembershipInvitation(
+	ctx context.Context,
+	orgID int64,
+	userID int64,
+) (membership models.Membership, err error) {
+	const op = "storage.sqlite.AcceptMembershipInvitation"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Membership{}, fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	query, args, err := sq.Delete("membership_invitations").
+		Where(sq.Eq{"org_id": orgID, "user_id": userID}).
+		Where(sq.Gt{"expires_at": time.Now().UTC()}).
+		Suffix("RETURNING role").
+		ToSql()
+	if err != nil {
+		return models.Membership{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	membership = models.Membership{OrgID: orgID, UserID: userID}
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&membership.Role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Membership{}, fmt.Errorf("%s: %w", op, storage.ErrInvitationNotFound)
+		}
+		return models.Membership{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.addMember(ctx, tx, orgID, userID, membership.Role); err != nil {
+		return models.Membership{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return membership, nil
+}
+
+// addMember inserts a membership together with its OrgMemberAdded event.
+func (s *Storage) addMember(ctx context.Context, tx *sql.Tx, orgID int64, userID int64, role string) error {
+	const op = "storage.sqlite.addMember"
+
+	query, args, err := sq.Insert("memberships").
+		Columns("org_id", "user_id", "role").
+		Values(orgID, userID, role).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, args...); err != nil {
+		var sqliteErr sqlite3.Error
+
+		if errors.As(err, &sqliteErr) &&
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey {
+			return fmt.Errorf("%s: %w", op, storage.ErrMemberExists)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.SaveEvent(ctx, tx, events.TypeOrgMemberAdded, events.OrgMemberAdded{
+		OrgID:  orgID,
+		UserID: userID,
+		Role:   role,
+	}); err != nil {
+		return fmt.Errorf("%s: save event: %w", op, err)
+	}
+
+	return nil
+}
+
+// updateMemberRole changes the role of userID in the organization and
+// reports whether the user is a member of it.
+func (s *Storage) updateMemberRole(ctx context.Context, tx *sql.Tx, orgID int64, userID int64, role string) (bool, error) {
+	const op = "storage.sqlite.updateMemberRole"
+
+	query, args, err := sq.Update("memberships").
+		Set("role", role).
+		Where(sq.Eq{"org_id": orgID, "user_id": userID}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated > 0, nil
+}
+
+func (s *Storage) Membership(ctx context.Context, orgID int64, userID int64) (models.Membership, error) {
+	const op = "storage.sqlite.Membership"
+	var membership models.Membership
+
+	query, args, err := sq.Select("org_id", "user_id", "role").
+		From("memberships").
+		Where(sq.Eq{"org_id": orgID, "user_id": userID}).
+		ToSql()
+	if err != nil {
+		return membership, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return membership, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, args...)
+	if err := row.Scan(&membership.OrgID, &membership.UserID, &membership.Role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Membership{}, fmt.Errorf("%s: %w", op, storage.ErrMemberNotFound)
+		}
+		return models.Membership{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return membership, nil
+}
+
+// DefaultOrganization returns the organization the user joined first.
+func (s *Storage) DefaultOrganization(ctx context.Context, userID int64) (int64, error) {
+	const op = "storage.sqlite.DefaultOrganization"
+
+	query, args, err := sq.Select("org_id").
+		From("memberships").
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("created_at", "org_id").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var orgID int64
+	if err := stmt.QueryRowContext(ctx, args...).Scan(&orgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrOrgNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orgID, nil
+}
+
+// OrgUser returns the user only if it is a member of the organization,
+// so lookups can't cross tenants.
+func (s *Storage) OrgUser(ctx context.Context, orgID int64, userID int64) (models.User, error) {
+	const op = "storage.sqlite.OrgUser"
+	var user models.User
+
+	query, args, err := sq.Select("users.id", "users.email", "users.pass_hash", "users.status", "users.directory").
+		From("users").
+		Join("memberships ON memberships.user_id = users.id").
+		Where(sq.Eq{"memberships.org_id": orgID, "users.id": userID}).
+		ToSql()
+	if err != nil {
+		return user, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return user, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, args...)
+	if err := row.Scan(&user.ID, &user.Email, &user.PassHash, &user.Status, &user.Directory); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// IsAdmin reports whether the user is an admin of the organization.
+func (s *Storage) IsAdmin(ctx context.Context, userID int64, orgID int64) (bool, error) {
+	const op = "storage.sqlite.IsAdmin"
+
+	membership, err := s.Membership(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrMemberNotFound) {
+			return false, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return membership.Role == models.RoleAdmin, nil
+}
@@ -0,0 +1,372 @@
+package sqlite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ClaimEvents leases up to limit due events to relayID, oldest first.
+// Scheduled events are due once their available_at has passed.
+// Events leased to another relay are skipped until the lease expires, so
+// each event is handled by one relay at a time. An event also waits while
+// an earlier event with its partition key is leased or waiting to be
+// retried, so the events of a user are published in order. The claim is
+// a single statement, which SQLite runs atomically.
+func (s *Storage) ClaimEvents(
+	ctx context.Context,
+	relayID string,
+	limit int,
+	lease time.Duration,
+) ([]models.Event, error) {
+	const op = "storage.sqlite.ClaimEvents"
+
+	due, dueArgs, err := sq.Select("id").
+		From("messages").
+		Where(sq.Eq{"status": "new"}).
+		Where("next_attempt_at <= CURRENT_TIMESTAMP").
+		Where("(available_at IS NULL OR available_at <= CURRENT_TIMESTAMP)").
+		Where("(lease_until IS NULL OR lease_until <= CURRENT_TIMESTAMP)").
+		Where(notBehindPartitionKey).
+		OrderBy("created_at", "id").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	query, args, err := sq.Update("messages").
+		Set("claimed_by", relayID).
+		Set("lease_until", sq.Expr("datetime('now', ?)", sqliteDelay(lease))).
+		Where("id IN ("+due+")", dueArgs...).
+		Suffix("RETURNING id, event_type, payload, partition_key, trace_parent, attempts, COALESCE(last_error, '')").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	events := make([]models.Event, 0, limit)
+	for rows.Next() {
+		var event models.Event
+		if err := rows.Scan(
+			&event.ID,
+			&event.Type,
+			&event.Payload,
+			&event.PartitionKey,
+			&event.TraceParent,
+			&event.Attempts,
+			&event.LastError,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrNoNewEvents)
+	}
+
+	// RETURNING doesn't keep the order of the subquery.
+	slices.SortFunc(events, func(a, b models.Event) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return events, nil
+}
+
+// notBehindPartitionKey matches the events of the claim subquery that no
+// earlier unsent event with the same partition key holds back: one that
+// is leased or waits for its next attempt. Earlier due
+// events are claimed in the same batch, ahead of the later ones; events
+// held back by their schedule don't hold back others.
+const notBehindPartitionKey = `(partition_key = '' OR NOT EXISTS (
+	SELECT 1 FROM messages AS earlier
+	WHERE earlier.partition_key = messages.partition_key
+		AND earlier.id < messages.id
+		AND earlier.status = 'new'
+		AND (earlier.available_at IS NULL OR earlier.available_at <= CURRENT_TIMESTAMP)
+		AND (earlier.next_attempt_at > CURRENT_TIMESTAMP OR earlier.lease_until > CURRENT_TIMESTAMP)
+))`
+
+// MarkEventsAsDone marks a published batch claimed by relayID as sent in
+// one transaction, so a batch is never left partially marked.
+func (s *Storage) MarkEventsAsDone(ctx context.Context, relayID string, eventIDs []int64) (err error) {
+	const op = "storage.sqlite.MarkEventsAsDone"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	query, args, err := releaseLease(sq.Update("messages")).
+		Set("status", "sent").
+		Set("sent_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"id": eventIDs, "claimed_by": relayID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ScheduleEventRetries records failed publish attempts and postpones each
+// event by its RetryIn.
+func (s *Storage) ScheduleEventRetries(
+	ctx context.Context,
+	relayID string,
+	failures []models.EventFailure,
+) (err error) {
+	const op = "storage.sqlite.ScheduleEventRetries"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	for _, failure := range failures {
+		query, args, err := releaseLease(sq.Update("messages")).
+			Set("attempts", sq.Expr("attempts + 1")).
+			Set("last_error", failure.Error).
+			Set("next_attempt_at", sq.Expr("datetime('now', ?)", sqliteDelay(failure.RetryIn))).
+			Where(sq.Eq{"id": failure.EventID, "claimed_by": relayID}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: build query: %w", op, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// MarkEventsAsFailed records the last failed attempt of each event and
+// stops retrying it.
+func (s *Storage) MarkEventsAsFailed(
+	ctx context.Context,
+	relayID string,
+	failures []models.EventFailure,
+) (err error) {
+	const op = "storage.sqlite.MarkEventsAsFailed"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	for _, failure := range failures {
+		query, args, err := releaseLease(sq.Update("messages")).
+			Set("status", "failed").
+			Set("attempts", sq.Expr("attempts + 1")).
+			Set("last_error", failure.Error).
+			Where(sq.Eq{"id": failure.EventID, "claimed_by": relayID}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: build query: %w", op, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// RequeueFailedEvents returns failed events to the outbox with a fresh
+// attempt counter. With no ids, every failed event is requeued. The last
+// error is kept for reference.
+func (s *Storage) RequeueFailedEvents(ctx context.Context, eventIDs []int64) (int64, error) {
+	const op = "storage.sqlite.RequeueFailedEvents"
+
+	update := releaseLease(sq.Update("messages")).
+		Set("status", "new").
+		Set("attempts", 0).
+		Set("next_attempt_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"status": "failed"})
+	if len(eventIDs) > 0 {
+		update = update.Where(sq.Eq{"id": eventIDs})
+	}
+
+	query, args, err := update.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	requeued, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return requeued, nil
+}
+
+// OutboxBacklog returns the number of events not sent yet and the age of
+// the oldest of them, 0 when there is none. Failed events and scheduled
+// ones not yet available are not counted: they wait for a requeue or
+// their time, not for the relay. A scheduled event ages from the time it
+// became available.
+func (s *Storage) OutboxBacklog(ctx context.Context) (unsent int64, oldestAge time.Duration, err error) {
+	const op = "storage.sqlite.OutboxBacklog"
+
+	query, args, err := sq.Select(
+		"COUNT(*)",
+		"COALESCE(MAX(strftime('%s', 'now') - strftime('%s', COALESCE(available_at, created_at))), 0)",
+	).
+		From("messages").
+		Where(sq.Eq{"status": "new"}).
+		Where("(available_at IS NULL OR available_at <= CURRENT_TIMESTAMP)").
+		ToSql()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	var ageSeconds int64
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&unsent, &ageSeconds); err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return unsent, time.Duration(ageSeconds) * time.Second, nil
+}
+
+// PurgeSentEvents removes up to limit events sent more than olderThan
+// ago and returns how many were removed. With archive set, the events are
+// copied to messages_archive first. Each call is a short transaction, so
+// callers purge in batches without holding the write lock for long.
+func (s *Storage) PurgeSentEvents(
+	ctx context.Context,
+	olderThan time.Duration,
+	limit int,
+	archive bool,
+) (purged int64, err error) {
+	const op = "storage.sqlite.PurgeSentEvents"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	expired, expiredArgs, err := sq.Select("id").
+		From("messages").
+		Where(sq.Eq{"status": "sent"}).
+		Where("sent_at <= datetime('now', ?)", sqliteAge(olderThan)).
+		OrderBy("id").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	if archive {
+		query, args, err := sq.Insert("messages_archive").
+			Columns("id", "event_type", "payload", "created_at", "sent_at").
+			Select(sq.Select("id", "event_type", "payload", "created_at", "sent_at").
+				From("messages").
+				Where("id IN ("+expired+")", expiredArgs...)).
+			ToSql()
+		if err != nil {
+			return 0, fmt.Errorf("%s: build query: %w", op, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("%s: archive: %w", op, err)
+		}
+	}
+
+	query, args, err := sq.Delete("messages").
+		Where("id IN ("+expired+")", expiredArgs...).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: delete: %w", op, err)
+	}
+
+	purged, err = res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return purged, nil
+}
+
+func releaseLease(update sq.UpdateBuilder) sq.UpdateBuilder {
+	return update.Set("claimed_by", nil).Set("lease_until", nil)
+}
+
+// sqliteAge formats d as a datetime() modifier pointing d into the past.
+func sqliteAge(d time.Duration) string {
+	return fmt.Sprintf("-%d seconds", int64(d.Seconds()))
+}
+
+// sqliteDelay formats d as a datetime() modifier, rounded up to seconds.
+func sqliteDelay(d time.Duration) string {
+	return fmt.Sprintf("+%d seconds", int64(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,441 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"events"
+	"path/filepath"
+	"slices"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// newTestStorage opens a migrated storage in a temporary directory.
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	storagePath := filepath.Join(t.TempDir(), "sso.db")
+	m, err := migrate.New("file://../../../migrations", "sqlite3://"+storagePath)
+	if err != nil {
+		t.Fatalf("create migrator: %v", err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+	if srcErr, dbErr := m.Close(); srcErr != nil || dbErr != nil {
+		t.Fatalf("close migrator: %v, %v", srcErr, dbErr)
+	}
+
+	s, err := New(storagePath)
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+
+	return s
+}
+
+func TestOutboxBacklog(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	unsent, oldestAge, err := s.OutboxBacklog(ctx)
+	if err != nil {
+		t.Fatalf("OutboxBacklog() error = %v", err)
+	}
+	if unsent != 0 || oldestAge != 0 {
+		t.Errorf("OutboxBacklog() of empty outbox = %d, %v, want 0, 0", unsent, oldestAge)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO messages (event_type, payload, status, created_at) VALUES
+			('UserCreated', '{}', 'new', datetime('now', '-90 seconds')),
+			('UserCreated', '{}', 'new', datetime('now', '-10 seconds')),
+			('UserCreated', '{}', 'sent', datetime('now', '-1 hour')),
+			('UserCreated', '{}', 'failed', datetime('now', '-1 hour'))`)
+	if err != nil {
+		t.Fatalf("insert events: %v", err)
+	}
+
+	unsent, oldestAge, err = s.OutboxBacklog(ctx)
+	if err != nil {
+		t.Fatalf("OutboxBacklog() error = %v", err)
+	}
+	if unsent != 2 {
+		t.Errorf("unsent = %d, want 2", unsent)
+	}
+	if oldestAge < 89*time.Second || oldestAge > 95*time.Second {
+		t.Errorf("oldest age = %v, want about 90s", oldestAge)
+	}
+}
+
+func TestClaimEvents_KeepsTraceParent(t *testing.T) {
+	s := newTestStorage(t)
+
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := events.WithTraceParent(context.Background(), traceParent)
+
+	if _, err := s.SaveUser(ctx, "user@example.com", []byte("hash")); err != nil {
+		t.Fatalf("SaveUser() error = %v", err)
+	}
+
+	claimed, err := s.ClaimEvents(context.Background(), "test", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimEvents() error = %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].TraceParent != traceParent {
+		t.Errorf("ClaimEvents() = %+v, want one event with traceparent %q", claimed, traceParent)
+	}
+}
+
+// insertEvents adds due events with the given partition keys to the
+// outbox, in order, and returns their ids.
+func insertEvents(t *testing.T, s *Storage, partitionKeys ...string) []int64 {
+	t.Helper()
+
+	ids := make([]int64, 0, len(partitionKeys))
+	for _, key := range partitionKeys {
+		res, err := s.db.Exec(
+			`INSERT INTO messages (event_type, payload, partition_key) VALUES ('UserCreated', '{}', ?)`, key)
+		if err != nil {
+			t.Fatalf("insert event: %v", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			t.Fatalf("insert event: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+func claimedIDs(t *testing.T, s *Storage, relayID string, limit int) []int64 {
+	t.Helper()
+
+	claimed, err := s.ClaimEvents(context.Background(), relayID, limit, time.Minute)
+	if errors.Is(err, storage.ErrNoNewEvents) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("ClaimEvents() error = %v", err)
+	}
+
+	ids := make([]int64, 0, len(claimed))
+	for _, event := range claimed {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestClaimEvents_KeepsPartitionKeyOrder(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	ids := insertEvents(t, s, "1", "1", "2", "")
+	first, second, other, unkeyed := ids[0], ids[1], ids[2], ids[3]
+
+	// A batch takes the events of a user in order.
+	if got := claimedIDs(t, s, "relay-a", 1); !slices.Equal(got, []int64{first}) {
+		t.Fatalf("relay-a claimed %v, want [%d]", got, first)
+	}
+
+	// While the first event is leased, the second one waits.
+	if got := claimedIDs(t, s, "relay-b", 10); !slices.Equal(got, []int64{other, unkeyed}) {
+		t.Fatalf("relay-b claimed %v, want [%d %d]", got, other, unkeyed)
+	}
+
+	// So it does while the first one waits for its retry.
+	failures := []models.EventFailure{{EventID: first, Error: "broker down", RetryIn: time.Minute}}
+	if err := s.ScheduleEventRetries(ctx, "relay-a", failures); err != nil {
+		t.Fatalf("ScheduleEventRetries() error = %v", err)
+	}
+	if got := claimedIDs(t, s, "relay-c", 10); len(got) != 0 {
+		t.Fatalf("relay-c claimed %v while the first event waits for its retry", got)
+	}
+
+	// Once the first one is due, both are claimed together, in order.
+	if _, err := s.db.Exec(`UPDATE messages SET next_attempt_at = CURRENT_TIMESTAMP WHERE id = ?`, first); err != nil {
+		t.Fatalf("make event due: %v", err)
+	}
+	if got := claimedIDs(t, s, "relay-c", 10); !slices.Equal(got, []int64{first, second}) {
+		t.Fatalf("relay-c claimed %v, want [%d %d]", got, first, second)
+	}
+}
+
+func TestClaimEvents_SentEventDoesntHoldBack(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	ids := insertEvents(t, s, "1", "1")
+
+	if got := claimedIDs(t, s, "relay-a", 1); !slices.Equal(got, ids[:1]) {
+		t.Fatalf("relay-a claimed %v, want %v", got, ids[:1])
+	}
+	if err := s.MarkEventsAsDone(ctx, "relay-a", ids[:1]); err != nil {
+		t.Fatalf("MarkEventsAsDone() error = %v", err)
+	}
+	if got := claimedIDs(t, s, "relay-b", 10); !slices.Equal(got, ids[1:]) {
+		t.Fatalf("relay-b claimed %v, want %v", got, ids[1:])
+	}
+}
+
+// outboxRow is the delivery state of an outbox event.
+type outboxRow struct {
+	status    string
+	attempts  int
+	lastError string
+	// retryIn is how long until the next attempt, in whole seconds.
+	retryIn   time.Duration
+	claimedBy string
+}
+
+func readOutboxRow(t *testing.T, s *Storage, id int64) outboxRow {
+	t.Helper()
+
+	var row outboxRow
+	var retryIn int64
+	err := s.db.QueryRow(`
+		SELECT status, attempts, COALESCE(last_error, ''),
+			CAST(strftime('%s', next_attempt_at) AS INTEGER) - CAST(strftime('%s', 'now') AS INTEGER),
+			COALESCE(claimed_by, '')
+		FROM messages WHERE id = ?`, id).
+		Scan(&row.status, &row.attempts, &row.lastError, &retryIn, &row.claimedBy)
+	if err != nil {
+		t.Fatalf("read event %d: %v", id, err)
+	}
+	row.retryIn = time.Duration(retryIn) * time.Second
+
+	return row
+}
+
+func TestOutboxFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		// fail records a failure of the event claimed by "relay".
+		fail      func(s *Storage, id int64) error
+		want      outboxRow
+		claimable bool
+	}{
+		{
+			name: "retry",
+			fail: func(s *Storage, id int64) error {
+				return s.ScheduleEventRetries(context.Background(), "relay",
+					[]models.EventFailure{{EventID: id, Error: "broker down", RetryIn: time.Minute}})
+			},
+			want: outboxRow{status: "new", attempts: 1, lastError: "broker down", retryIn: time.Minute},
+		},
+		{
+			name: "retry of another relay's event",
+			fail: func(s *Storage, id int64) error {
+				return s.ScheduleEventRetries(context.Background(), "other",
+					[]models.EventFailure{{EventID: id, Error: "broker down", RetryIn: time.Minute}})
+			},
+			want: outboxRow{status: "new", claimedBy: "relay"},
+		},
+		{
+			name: "failed",
+			fail: func(s *Storage, id int64) error {
+				return s.MarkEventsAsFailed(context.Background(), "relay",
+					[]models.EventFailure{{EventID: id, Error: "broker down"}})
+			},
+			want: outboxRow{status: "failed", attempts: 1, lastError: "broker down"},
+		},
+		{
+			name: "failed by another relay",
+			fail: func(s *Storage, id int64) error {
+				return s.MarkEventsAsFailed(context.Background(), "other",
+					[]models.EventFailure{{EventID: id, Error: "broker down"}})
+			},
+			want: outboxRow{status: "new", claimedBy: "relay"},
+		},
+		{
+			name: "requeued by id",
+			fail: func(s *Storage, id int64) error {
+				if err := s.MarkEventsAsFailed(context.Background(), "relay",
+					[]models.EventFailure{{EventID: id, Error: "broker down"}}); err != nil {
+					return err
+				}
+				_, err := s.RequeueFailedEvents(context.Background(), []int64{id})
+				return err
+			},
+			want:      outboxRow{status: "new", lastError: "broker down"},
+			claimable: true,
+		},
+		{
+			name: "requeued with every failed event",
+			fail: func(s *Storage, id int64) error {
+				if err := s.MarkEventsAsFailed(context.Background(), "relay",
+					[]models.EventFailure{{EventID: id, Error: "broker down"}}); err != nil {
+					return err
+				}
+				_, err := s.RequeueFailedEvents(context.Background(), nil)
+				return err
+			},
+			want:      outboxRow{status: "new", lastError: "broker down"},
+			claimable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t)
+			id := insertEvents(t, s, "1")[0]
+			if got := claimedIDs(t, s, "relay", 10); !slices.Equal(got, []int64{id}) {
+				t.Fatalf("claimed %v, want [%d]", got, id)
+			}
+
+			if err := tt.fail(s, id); err != nil {
+				t.Fatalf("record failure: %v", err)
+			}
+
+			got := readOutboxRow(t, s, id)
+			if got.status != tt.want.status || got.attempts != tt.want.attempts ||
+				got.lastError != tt.want.lastError || got.claimedBy != tt.want.claimedBy {
+				t.Errorf("event = %+v, want %+v", got, tt.want)
+			}
+			if tt.want.retryIn > 0 && (got.retryIn < tt.want.retryIn-time.Second || got.retryIn > tt.want.retryIn) {
+				t.Errorf("next attempt in %v, want %v", got.retryIn, tt.want.retryIn)
+			}
+			if claimed := claimedIDs(t, s, "next", 10); (len(claimed) > 0) != tt.claimable {
+				t.Errorf("claimable = %v, want %v", len(claimed) > 0, tt.claimable)
+			}
+		})
+	}
+}
+
+func TestRequeueFailedEvents_LeavesOtherEvents(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	ids := insertEvents(t, s, "1", "2", "3")
+	claimedIDs(t, s, "relay", 10)
+	failures := []models.EventFailure{{EventID: ids[0], Error: "broker down"}, {EventID: ids[1], Error: "broker down"}}
+	if err := s.MarkEventsAsFailed(ctx, "relay", failures); err != nil {
+		t.Fatalf("MarkEventsAsFailed() error = %v", err)
+	}
+
+	requeued, err := s.RequeueFailedEvents(ctx, []int64{ids[0], ids[2]})
+	if err != nil {
+		t.Fatalf("RequeueFailedEvents() error = %v", err)
+	}
+	if requeued != 1 {
+		t.Errorf("RequeueFailedEvents() = %d, want 1", requeued)
+	}
+	if row := readOutboxRow(t, s, ids[1]); row.status != "failed" {
+		t.Errorf("event not asked for has status %q, want failed", row.status)
+	}
+	if row := readOutboxRow(t, s, ids[2]); row.status != "new" || row.claimedBy != "relay" {
+		t.Errorf("leased event = %+v, want it untouched", row)
+	}
+}
+
+// markSentAgo marks the event as sent age ago.
+func markSentAgo(t *testing.T, s *Storage, id int64, age time.Duration) {
+	t.Helper()
+
+	_, err := s.db.Exec(`UPDATE messages SET status = 'sent', sent_at = datetime('now', ?) WHERE id = ?`,
+		sqliteAge(age), id)
+	if err != nil {
+		t.Fatalf("mark event %d sent: %v", id, err)
+	}
+}
+
+func tableIDs(t *testing.T, s *Storage, table string) []int64 {
+	t.Helper()
+
+	rows, err := s.db.Query(`SELECT id FROM ` + table + ` ORDER BY id`)
+	if err != nil {
+		t.Fatalf("read %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("read %s: %v", table, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("read %s: %v", table, err)
+	}
+
+	return ids
+}
+
+func TestPurgeSentEvents(t *testing.T) {
+	// Events 1-3 were sent two days ago, 4 an hour ago, 5 is unsent and
+	// 6 failed.
+	tests := []struct {
+		name         string
+		limit        int
+		archive      bool
+		wantPurged   int64
+		wantKept     []int64
+		wantArchived []int64
+	}{
+		{
+			name:       "purges events past the cutoff",
+			limit:      10,
+			wantPurged: 3,
+			wantKept:   []int64{4, 5, 6},
+		},
+		{
+			name:       "stops at the batch limit",
+			limit:      2,
+			wantPurged: 2,
+			wantKept:   []int64{3, 4, 5, 6},
+		},
+		{
+			name:         "archives purged events",
+			limit:        10,
+			archive:      true,
+			wantPurged:   3,
+			wantKept:     []int64{4, 5, 6},
+			wantArchived: []int64{1, 2, 3},
+		},
+		{
+			name:         "archives only the batch",
+			limit:        1,
+			archive:      true,
+			wantPurged:   1,
+			wantKept:     []int64{2, 3, 4, 5, 6},
+			wantArchived: []int64{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t)
+			ids := insertEvents(t, s, "1", "2", "3", "4", "5", "6")
+			for _, id := range ids[:3] {
+				markSentAgo(t, s, id, 48*time.Hour)
+			}
+			markSentAgo(t, s, ids[3], time.Hour)
+			if _, err := s.db.Exec(`UPDATE messages SET status = 'failed' WHERE id = ?`, ids[5]); err != nil {
+				t.Fatalf("mark event failed: %v", err)
+			}
+
+			purged, err := s.PurgeSentEvents(context.Background(), 24*time.Hour, tt.limit, tt.archive)
+			if err != nil {
+				t.Fatalf("PurgeSentEvents() error = %v", err)
+			}
+			if purged != tt.wantPurged {
+				t.Errorf("PurgeSentEvents() = %d, want %d", purged, tt.wantPurged)
+			}
+			if got := tableIDs(t, s, "messages"); !slices.Equal(got, tt.wantKept) {
+				t.Errorf("kept events %v, want %v", got, tt.wantKept)
+			}
+			if got := tableIDs(t, s, "messages_archive"); !slices.Equal(got, tt.wantArchived) {
+				t.Errorf("archived events %v, want %v", got, tt.wantArchived)
+			}
+		})
+	}
+}
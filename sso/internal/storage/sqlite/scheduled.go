@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// SaveDelayedEvent writes an event to the outbox within tx that the relay
+// publishes no earlier than delay from now. With a scheduleKey, it can be
+// cancelled by CancelScheduledEvents until it is published.
+func (s *Storage) SaveDelayedEvent(
+	ctx context.Context,
+	tx *sql.Tx,
+	eventType string,
+	payload any,
+	delay time.Duration,
+	scheduleKey string,
+) error {
+	const op = "storage.sqlite.SaveDelayedEvent"
+
+	if err := s.saveEvent(ctx, tx, eventType, payload, delay, scheduleKey); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ScheduleEvent is SaveDelayedEvent in a transaction of its own, for
+// events not tied to another change.
+func (s *Storage) ScheduleEvent(
+	ctx context.Context,
+	eventType string,
+	payload any,
+	delay time.Duration,
+	scheduleKey string,
+) (err error) {
+	const op = "storage.sqlite.ScheduleEvent"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	if err := s.saveEvent(ctx, tx, eventType, payload, delay, scheduleKey); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CancelScheduledEvents removes the unsent events scheduled with
+// scheduleKey and returns how many were removed. Events a relay holds a
+// lease on may be being published and are left alone.
+func (s *Storage) CancelScheduledEvents(ctx context.Context, scheduleKey string) (int64, error) {
+	const op = "storage.sqlite.CancelScheduledEvents"
+
+	query, args, err := sq.Delete("messages").
+		Where(sq.Eq{"schedule_key": scheduleKey, "status": "new"}).
+		Where("(lease_until IS NULL OR lease_until <= CURRENT_TIMESTAMP)").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cancelled, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cancelled, nil
+}
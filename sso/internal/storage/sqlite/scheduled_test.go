@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"events"
+	"sso/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestScheduledEvents(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	reminder := events.UserCreated{UserID: 1, Email: "user@example.com"}
+	if err := s.ScheduleEvent(ctx, events.TypeUserCreated, reminder, 24*time.Hour, "remind:1"); err != nil {
+		t.Fatalf("ScheduleEvent() error = %v", err)
+	}
+	if err := s.ScheduleEvent(ctx, events.TypeUserCreated, reminder, 24*time.Hour, "remind:2"); err != nil {
+		t.Fatalf("ScheduleEvent() error = %v", err)
+	}
+
+	if _, err := s.ClaimEvents(ctx, "test", 10, time.Minute); !errors.Is(err, storage.ErrNoNewEvents) {
+		t.Fatalf("ClaimEvents() error = %v, want events scheduled for tomorrow held back", err)
+	}
+	if unsent, _, err := s.OutboxBacklog(ctx); err != nil || unsent != 0 {
+		t.Errorf("OutboxBacklog() = %d, %v, want scheduled events left out", unsent, err)
+	}
+
+	cancelled, err := s.CancelScheduledEvents(ctx, "remind:1")
+	if err != nil {
+		t.Fatalf("CancelScheduledEvents() error = %v", err)
+	}
+	if cancelled != 1 {
+		t.Errorf("cancelled %d events, want 1", cancelled)
+	}
+
+	// The time comes for the other one.
+	if _, err := s.db.Exec(`UPDATE messages SET available_at = datetime('now', '-1 seconds')`); err != nil {
+		t.Fatalf("move schedule: %v", err)
+	}
+
+	claimed, err := s.ClaimEvents(ctx, "test", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimEvents() error = %v", err)
+	}
+	if len(claimed) != 1 {
+		t.Fatalf("claimed %d events, want the one not cancelled", len(claimed))
+	}
+
+	// Leased events may be being published and aren't cancelled.
+	if cancelled, err := s.CancelScheduledEvents(ctx, "remind:2"); err != nil || cancelled != 0 {
+		t.Errorf("CancelScheduledEvents() of a leased event = %d, %v, want 0", cancelled, err)
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"events"
 	"fmt"
 	"sso/internal/domain/models"
 	"sso/internal/storage"
+	"strings"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/mattn/go-sqlite3"
@@ -17,15 +20,22 @@ type Storage struct {
 	db *sql.DB
 }
 
-type eventPayload struct {
-	Id    int64  `json:"id"`
-	Email string `json:"email"`
-}
+const (
+	// producer names sso in the envelopes of the events it writes.
+	producer = "sso"
+)
 
 func New(storagePath string) (*Storage, error) {
 	const op = "storage.sqlite.New"
 
-	db, err := sql.Open("sqlite3", storagePath)
+	// Several sso replicas may share the database file, so writers wait
+	// for the lock instead of failing right away.
+	dsn := storagePath
+	if !strings.Contains(dsn, "?") {
+		dsn += "?_busy_timeout=5000&_txlock=immediate"
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -51,7 +61,35 @@ func (s *Storage) SaveUser(ctx context.Context, email string, passHash []byte) (
 		}
 	}()
 
-	query, args, err := sq.Insert("users").Columns("email", "pass_hash").Values(email, passHash).ToSql()
+	// An expired invitation doesn't keep the email from registering.
+	resID, err = s.insertUser(ctx, tx, email, passHash, models.UserStatusActive)
+	if errors.Is(err, storage.ErrUserExists) {
+		resID, err = s.reclaimPendingUser(ctx, tx, email, passHash, models.UserStatusActive)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.SaveEvent(ctx, tx, events.TypeUserCreated, events.UserCreated{UserID: resID, Email: email}); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resID, nil
+}
+
+func (s *Storage) insertUser(
+	ctx context.Context,
+	tx *sql.Tx,
+	email string,
+	passHash []byte,
+	status string,
+) (int64, error) {
+	const op = "storage.sqlite.insertUser"
+
+	query, args, err := sq.Insert("users").
+		Columns("email", "pass_hash", "status").
+		Values(email, passHash, status).
+		ToSql()
 	if err != nil {
 		return 0, fmt.Errorf("%s: build query: %w", op, err)
 	}
@@ -74,44 +112,241 @@ func (s *Storage) SaveUser(ctx context.Context, email string, passHash []byte) (
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	resID, err = res.LastInsertId()
+	resID, err := res.LastInsertId()
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	eventPayloadBytes, err := json.Marshal(eventPayload{
-		Id:    resID,
-		Email: email,
-	})
+	return resID, nil
+}
+
+// ProvisionUser returns the directory user with the given email, creating
+// one without a local password if it doesn't exist yet. It returns
+// ErrNotDirectoryUser for a local account with that email.
+func (s *Storage) ProvisionUser(ctx context.Context, email string) (models.User, error) {
+	const op = "storage.sqlite.ProvisionUser"
+
+	user, err := s.User(ctx, email)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		user, err = s.createDirectoryUser(ctx, email)
+		if errors.Is(err, storage.ErrUserExists) {
+			// A concurrent login of the same user provisioned it first.
+			user, err = s.User(ctx, email)
+		}
+	}
 	if err != nil {
-		return resID, fmt.Errorf("failed to marshal event: %w", err)
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	eventPayload := string(eventPayloadBytes)
 
-	if err := s.SaveEvent(ctx, tx, "UserCreated", eventPayload); err != nil {
-		return 0, fmt.Errorf("%s: save event: %w", op, err)
+	// A directory bind must not sign in as a local account that happens
+	// to have the same email.
+	if !user.Directory {
+		return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrNotDirectoryUser)
 	}
 
-	return resID, nil
+	return user, nil
 }
 
-func (s *Storage) SaveEvent(ctx context.Context, tx *sql.Tx, eventType, payload string) error {
-	const op = "storage.sqlite.SaveEvent"
+// createDirectoryUser creates an active user without a local password.
+func (s *Storage) createDirectoryUser(ctx context.Context, email string) (user models.User, err error) {
+	const op = "storage.sqlite.createDirectoryUser"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	uid, err := s.insertUser(ctx, tx, email, []byte{}, models.UserStatusActive)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := markDirectoryUser(ctx, tx, uid); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.SaveEvent(ctx, tx, events.TypeUserCreated, events.UserCreated{UserID: uid, Email: email}); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
 
-	query, args, err := sq.Insert("messages").Columns("event_type", "payload").Values(eventType, payload).ToSql()
+	return models.User{
+		ID:        uid,
+		Email:     email,
+		PassHash:  []byte{},
+		Status:    models.UserStatusActive,
+		Directory: true,
+	}, nil
+}
+
+// markDirectoryUser flags the user as provisioned from the directory.
+func markDirectoryUser(ctx context.Context, tx *sql.Tx, userID int64) error {
+	const op = "storage.sqlite.markDirectoryUser"
+
+	query, args, err := sq.Update("users").Set("directory", true).Where(sq.Eq{"id": userID}).ToSql()
 	if err != nil {
 		return fmt.Errorf("%s: build query: %w", op, err)
 	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-	stmt, err := tx.PrepareContext(ctx, query)
+	return nil
+}
+
+// SetUserRoles replaces the roles of a directory user and syncs its
+// membership in the default organization: the "admin" role makes the user
+// an admin there and any other role a member. Without roles the user
+// isn't added to it, and loses admin rights if it is already a member.
+// Local accounts are left alone with ErrNotDirectoryUser.
+func (s *Storage) SetUserRoles(ctx context.Context, userID int64, roles []string) (err error) {
+	const op = "storage.sqlite.SetUserRoles"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	query, args, err := sq.Select("directory").From("users").Where(sq.Eq{"id": userID}).ToSql()
 	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+	var directory bool
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&directory); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return fmt.Errorf("%s: get user: %w", op, err)
+	}
+	if !directory {
+		return fmt.Errorf("%s: %w", op, storage.ErrNotDirectoryUser)
+	}
+
+	query, args, err = sq.Delete("user_roles").Where(sq.Eq{"user_id": userID}).ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("%s: delete roles: %w", op, err)
+	}
+
+	memberRole := models.RoleMember
+	if len(roles) > 0 {
+		insert := sq.Insert("user_roles").Columns("user_id", "role")
+		for _, role := range roles {
+			insert = insert.Values(userID, role)
+			if role == models.RoleAdmin {
+				memberRole = models.RoleAdmin
+			}
+		}
+
+		query, args, err = insert.ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: build query: %w", op, err)
+		}
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("%s: insert roles: %w", op, err)
+		}
+	}
+
+	member, err := s.updateMemberRole(ctx, tx, models.DefaultOrgID, userID, memberRole)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !member && len(roles) > 0 {
+		if err = s.addMember(ctx, tx, models.DefaultOrgID, userID, memberRole); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// SaveEvent writes an event to the outbox within tx. The payload is
+// wrapped in a versioned envelope carrying the correlation id of ctx, and
+// the trace context of ctx is kept for publishing.
+// Payloads that belong to a user are published with the user's partition
+// key.
+func (s *Storage) SaveEvent(ctx context.Context, tx *sql.Tx, eventType string, payload any) error {
+	const op = "storage.sqlite.SaveEvent"
+
+	if err := s.saveEvent(ctx, tx, eventType, payload, 0, ""); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	return nil
+}
+
+// saveEvent writes an event to the outbox within tx, held back for delay
+// and cancellable by scheduleKey unless it is empty.
+func (s *Storage) saveEvent(
+	ctx context.Context,
+	tx *sql.Tx,
+	eventType string,
+	payload any,
+	delay time.Duration,
+	scheduleKey string,
+) error {
+	envelope, err := events.New(ctx, eventType, producer, payload)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("marshal envelope: %w", err)
+	}
+
+	var partitionKey string
+	if keyed, ok := payload.(events.Keyed); ok {
+		partitionKey = keyed.PartitionKey()
+	}
+
+	columns := []string{"event_type", "payload", "partition_key", "trace_parent"}
+	values := []any{eventType, string(data), partitionKey, events.TraceParent(ctx)}
+	if delay > 0 {
+		columns = append(columns, "available_at")
+		values = append(values, sq.Expr("datetime('now', ?)", sqliteDelay(delay)))
+	}
+	if scheduleKey != "" {
+		columns = append(columns, "schedule_key")
+		values = append(values, scheduleKey)
+	}
+
+	query, args, err := sq.Insert("messages").
+		Columns(columns...).
+		Values(values...).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
 	defer stmt.Close()
 	_, err = stmt.ExecContext(ctx, args...)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return err
 	}
 
 	return nil
@@ -121,7 +356,7 @@ func (s *Storage) User(ctx context.Context, email string) (models.User, error) {
 	const op = "storage.sqlite.User"
 	var user models.User
 
-	query, args, err := sq.Select("id", "email", "pass_hash").From("users").Where(sq.Eq{"email": email}).ToSql()
+	query, args, err := sq.Select("id", "email", "pass_hash", "status", "directory").From("users").Where(sq.Eq{"email": email}).ToSql()
 	if err != nil {
 		return user, fmt.Errorf("%s: build query: %w", op, err)
 	}
@@ -132,11 +367,11 @@ func (s *Storage) User(ctx context.Context, email string) (models.User, error) {
 	}
 	defer stmt.Close()
 	row := stmt.QueryRowContext(ctx, args...)
-	err = row.Scan(&user.ID, &user.Email, &user.PassHash)
+	err = row.Scan(&user.ID, &user.Email, &user.PassHash, &user.Status, &user.Directory)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotExists)
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
 		}
 
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
@@ -145,39 +380,39 @@ func (s *Storage) User(ctx context.Context, email string) (models.User, error) {
 	return user, nil
 }
 
-func (s *Storage) IsAdmin(ctx context.Context, userID int64) (bool, error) {
-	const op = "storage.sqlite.IsAdmin"
+func (s *Storage) UserByID(ctx context.Context, userID int64) (models.User, error) {
+	const op = "storage.sqlite.UserByID"
+	var user models.User
 
-	query, args, err := sq.Select("is_admin").From("admins").Where(sq.Eq{"user_id": userID}).ToSql()
+	query, args, err := sq.Select("id", "email", "pass_hash", "status", "directory").From("users").Where(sq.Eq{"id": userID}).ToSql()
 	if err != nil {
-		return false, fmt.Errorf("%s: build query: %w", op, err)
+		return user, fmt.Errorf("%s: build query: %w", op, err)
 	}
 
 	stmt, err := s.db.PrepareContext(ctx, query)
 	if err != nil {
-		return false, fmt.Errorf("%s: %w", op, err)
+		return user, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
-
 	row := stmt.QueryRowContext(ctx, args...)
-	var isAdmin bool
-	err = row.Scan(&isAdmin)
+	err = row.Scan(&user.ID, &user.Email, &user.PassHash, &user.Status, &user.Directory)
+
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return false, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
 		}
 
-		return false, fmt.Errorf("%s: %w", op, err)
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return isAdmin, nil
+	return user, nil
 }
 
 func (s *Storage) App(ctx context.Context, appID int64) (models.App, error) {
 	const op = "storage.sqlite.App"
 	var app models.App
 
-	query, args, err := sq.Select("id", "name", "secret").From("apps").Where(sq.Eq{"id": appID}).ToSql()
+	query, args, err := sq.Select("id", "name", "secret", "org_id", "restricted").From("apps").Where(sq.Eq{"id": appID}).ToSql()
 	if err != nil {
 		return models.App{}, fmt.Errorf("%s: build query: %w", op, err)
 	}
@@ -189,7 +424,7 @@ func (s *Storage) App(ctx context.Context, appID int64) (models.App, error) {
 	defer stmt.Close()
 
 	row := stmt.QueryRowContext(ctx, args...)
-	err = row.Scan(&app.ID, &app.Name, &app.Secret)
+	err = row.Scan(&app.ID, &app.Name, &app.Secret, &app.OrgID, &app.Restricted)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.App{}, fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
@@ -200,41 +435,19 @@ func (s *Storage) App(ctx context.Context, appID int64) (models.App, error) {
 	return app, nil
 }
 
-func (s *Storage) GetNewEvent(ctx context.Context) (models.Event, error) {
-	const op = "storage.sqlite.GetNewEvent"
-
-	query, args, err := sq.Select("id", "event_type", "payload").
-		From("messages").
-		Where(sq.Eq{"status": "new"}).
-		OrderBy("created_at").
-		Limit(1).ToSql()
-	if err != nil {
-		return models.Event{}, fmt.Errorf("%s: build query: %w", op, err)
-	}
-
-	stmt, err := s.db.PrepareContext(ctx, query)
-	if err != nil {
-		return models.Event{}, fmt.Errorf("%s: %w", op, err)
-	}
-
-	row := stmt.QueryRowContext(ctx, args...)
-
-	var event models.Event
-	err = row.Scan(&event.ID, &event.Type, &event.Payload)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return models.Event{}, fmt.Errorf("%s: %w", op, storage.ErrNoNewEvents)
-		}
-		return models.Event{}, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return event, nil
-}
-
-func (s *Storage) MarkEventAsDone(ctx context.Context, eventID int64) error {
-	const op = "storage.sqlite.MarkEventAsDone"
-
-	query, args, err := sq.Update("messages").Set("status", "sent").Where(sq.Eq{"id": eventID}).ToSql()
+func (s *Storage) SaveImpersonation(ctx context.Context, impersonation models.Impersonation) error {
+	const op = "storage.sqlite.SaveImpersonation"
+
+	query, args, err := sq.Insert("impersonations").
+		Columns("actor_id", "org_id", "user_id", "app_id", "reason", "expires_at").
+		Values(
+			impersonation.ActorID,
+			impersonation.OrgID,
+			impersonation.UserID,
+			impersonation.AppID,
+			impersonation.Reason,
+			impersonation.ExpiresAt,
+		).ToSql()
 	if err != nil {
 		return fmt.Errorf("%s: build query: %w", op, err)
 	}
@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestProvisionUser_ConcurrentInsert(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	// Another login inserts the same user but hasn't committed yet, so
+	// ProvisionUser misses the user and then blocks on the write lock.
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin tx: %v", err)
+	}
+	uid, err := s.insertUser(ctx, tx, "ldap@example.com", []byte{}, models.UserStatusActive)
+	if err != nil {
+		t.Fatalf("insertUser() error = %v", err)
+	}
+	if err := markDirectoryUser(ctx, tx, uid); err != nil {
+		t.Fatalf("markDirectoryUser() error = %v", err)
+	}
+
+	type result struct {
+		user models.User
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		user, err := s.ProvisionUser(ctx, "ldap@example.com")
+		done <- result{user, err}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit tx: %v", err)
+	}
+
+	got := <-done
+	if got.err != nil {
+		t.Fatalf("ProvisionUser() error = %v", got.err)
+	}
+	if got.user.ID != uid {
+		t.Errorf("ProvisionUser() id = %d, want %d", got.user.ID, uid)
+	}
+}
+
+func TestProvisionUser_RefusesLocalAccount(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	if _, err := s.SaveUser(ctx, "local@example.com", []byte("hash")); err != nil {
+		t.Fatalf("SaveUser() error = %v", err)
+	}
+
+	_, err := s.ProvisionUser(ctx, "local@example.com")
+	if !errors.Is(err, storage.ErrNotDirectoryUser) {
+		t.Fatalf("ProvisionUser() of local account error = %v, want %v", err, storage.ErrNotDirectoryUser)
+	}
+}
+
+func TestSetUserRoles(t *testing.T) {
+	tests := []struct {
+		name       string
+		roles      []string
+		wantMember bool
+		wantRole   string
+	}{
+		{name: "admin role", roles: []string{models.RoleAdmin}, wantMember: true, wantRole: models.RoleAdmin},
+		{name: "other role", roles: []string{"support"}, wantMember: true, wantRole: models.RoleMember},
+		{name: "no roles", wantMember: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t)
+			ctx := context.Background()
+
+			user, err := s.ProvisionUser(ctx, "ldap@example.com")
+			if err != nil {
+				t.Fatalf("ProvisionUser() error = %v", err)
+			}
+			if err := s.SetUserRoles(ctx, user.ID, tt.roles); err != nil {
+				t.Fatalf("SetUserRoles() error = %v", err)
+			}
+
+			membership, err := s.Membership(ctx, models.DefaultOrgID, user.ID)
+			if !tt.wantMember {
+				if !errors.Is(err, storage.ErrMemberNotFound) {
+					t.Fatalf("Membership() error = %v, want %v", err, storage.ErrMemberNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Membership() error = %v", err)
+			}
+			if membership.Role != tt.wantRole {
+				t.Errorf("Membership().Role = %q, want %q", membership.Role, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestSetUserRoles_DemotesDirectoryAdmin(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	user, err := s.ProvisionUser(ctx, "ldap@example.com")
+	if err != nil {
+		t.Fatalf("ProvisionUser() error = %v", err)
+	}
+	if err := s.SetUserRoles(ctx, user.ID, []string{models.RoleAdmin}); err != nil {
+		t.Fatalf("SetUserRoles() error = %v", err)
+	}
+	if err := s.SetUserRoles(ctx, user.ID, nil); err != nil {
+		t.Fatalf("SetUserRoles() error = %v", err)
+	}
+
+	isAdmin, err := s.IsAdmin(ctx, user.ID, models.DefaultOrgID)
+	if err != nil {
+		t.Fatalf("IsAdmin() error = %v", err)
+	}
+	if isAdmin {
+		t.Error("directory user kept admin rights after losing the admin group")
+	}
+}
+
+func TestSetUserRoles_RefusesLocalAccount(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID, err := s.SaveUser(ctx, "local@example.com", []byte("hash"))
+	if err != nil {
+		t.Fatalf("SaveUser() error = %v", err)
+	}
+
+	err = s.SetUserRoles(ctx, userID, []string{models.RoleAdmin})
+	if !errors.Is(err, storage.ErrNotDirectoryUser) {
+		t.Fatalf("SetUserRoles() of local account error = %v, want %v", err, storage.ErrNotDirectoryUser)
+	}
+	if _, err := s.Membership(ctx, models.DefaultOrgID, userID); !errors.Is(err, storage.ErrMemberNotFound) {
+		t.Errorf("Membership() of local account error = %v, want %v", err, storage.ErrMemberNotFound)
+	}
+}
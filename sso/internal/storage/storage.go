@@ -3,9 +3,17 @@ package storage
 import "errors"
 
 var (
-	ErrUserNotFound  = errors.New("user not found")
-	ErrUserNotExists = errors.New("user already exists")
-	ErrAppNotFound   = errors.New("app not found")
-	ErrUserExists    = errors.New("user already exists")
-	ErrNoNewEvents   = errors.New("no new events")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUserNotExists      = errors.New("user already exists")
+	ErrAppNotFound        = errors.New("app not found")
+	ErrUserExists         = errors.New("user already exists")
+	ErrNoNewEvents        = errors.New("no new events")
+	ErrOrgNotFound        = errors.New("organization not found")
+	ErrOrgExists          = errors.New("organization already exists")
+	ErrMemberNotFound     = errors.New("membership not found")
+	ErrMemberExists       = errors.New("membership already exists")
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrAccessExists       = errors.New("app access already granted")
+	ErrAccessNotFound     = errors.New("app access not found")
+	ErrNotDirectoryUser   = errors.New("user is not provisioned from the directory")
 )
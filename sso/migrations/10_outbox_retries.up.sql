@@ -0,0 +1,21 @@
+-- SQLite can't change a CHECK constraint in place, so the outbox table is
+-- rebuilt with the retry columns and the 'failed' status.
+CREATE TABLE messages_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'new' CHECK (status IN ('new', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO messages_new (id, event_type, payload, status, created_at)
+SELECT id, event_type, payload, status, created_at FROM messages;
+
+DROP TABLE messages;
+
+ALTER TABLE messages_new RENAME TO messages;
+
+CREATE INDEX IF NOT EXISTS idx_messages_status_next_attempt ON messages(status, next_attempt_at);
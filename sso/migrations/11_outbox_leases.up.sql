@@ -0,0 +1,4 @@
+-- A relay leases the events it publishes; an expired lease can be claimed
+-- by another relay.
+ALTER TABLE messages ADD COLUMN claimed_by TEXT;
+ALTER TABLE messages ADD COLUMN lease_until TIMESTAMP;
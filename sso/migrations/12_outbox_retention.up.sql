@@ -0,0 +1,15 @@
+ALTER TABLE messages ADD COLUMN sent_at TIMESTAMP;
+
+UPDATE messages SET sent_at = created_at WHERE status = 'sent';
+
+CREATE INDEX IF NOT EXISTS idx_messages_status_sent_at ON messages(status, sent_at);
+
+-- Sent events moved out of the outbox by the retention job in archive mode.
+CREATE TABLE IF NOT EXISTS messages_archive (
+    id INTEGER PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMP,
+    sent_at TIMESTAMP,
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- Events of one user share a partition key, so Kafka keeps their order.
+ALTER TABLE messages ADD COLUMN partition_key TEXT NOT NULL DEFAULT '';
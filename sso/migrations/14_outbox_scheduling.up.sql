@@ -0,0 +1,7 @@
+-- Scheduled events are held back until available_at; NULL makes an event
+-- available at once. Pending scheduled events can be cancelled by their
+-- schedule key.
+ALTER TABLE messages ADD COLUMN available_at TIMESTAMP;
+ALTER TABLE messages ADD COLUMN schedule_key TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_messages_schedule_key ON messages(schedule_key) WHERE schedule_key IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Every app belongs to an organization whose admins manage its access
+-- grants. Existing apps belong to the default organization.
+ALTER TABLE apps ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1;
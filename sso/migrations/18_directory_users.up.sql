@@ -0,0 +1,6 @@
+-- Users provisioned on their first LDAP bind. Only they are signed in and
+-- have their roles synced through the directory; local accounts with the
+-- same email are never linked to it.
+ALTER TABLE users ADD COLUMN directory INTEGER NOT NULL DEFAULT 0;
+
+UPDATE users SET directory = 1 WHERE length(pass_hash) = 0 AND status = 'active';
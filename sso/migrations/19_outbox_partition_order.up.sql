@@ -0,0 +1,2 @@
+-- Claims look up earlier unsent events of the same partition key.
+CREATE INDEX IF NOT EXISTS idx_messages_partition_key ON messages(partition_key, id) WHERE status = 'new';
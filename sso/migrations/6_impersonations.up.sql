@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS impersonations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    app_id INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonations_actor_id ON impersonations(actor_id);
+CREATE INDEX IF NOT EXISTS idx_impersonations_user_id ON impersonations(user_id);
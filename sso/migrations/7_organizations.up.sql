@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS memberships (
+    org_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('admin', 'member')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (org_id, user_id),
+    FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_memberships_user_id ON memberships(user_id);
+
+-- Existing users and admins move into the default organization.
+INSERT INTO organizations (id, name)
+VALUES (1, 'default')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO memberships (org_id, user_id, role)
+SELECT 1, users.id, CASE WHEN admins.is_admin THEN 'admin' ELSE 'member' END
+FROM users
+LEFT JOIN admins ON admins.user_id = users.id
+ON CONFLICT DO NOTHING;
+
+INSERT INTO messages (event_type, payload)
+SELECT 'OrgMemberAdded', json_object('org_id', org_id, 'user_id', user_id, 'role', role)
+FROM memberships;
+
+DROP TABLE IF EXISTS admins;
+
+ALTER TABLE impersonations ADD COLUMN org_id INTEGER NOT NULL DEFAULT 0;
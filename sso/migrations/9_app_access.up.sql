@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS app_access (
+    app_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (app_id, user_id),
+    FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Restricted apps only issue tokens to users listed in app_access.
+ALTER TABLE apps ADD COLUMN restricted BOOLEAN NOT NULL DEFAULT 0;
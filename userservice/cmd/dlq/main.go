@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	busapp "userservice/internal/app/bus"
+	"userservice/internal/config"
+	"userservice/internal/lib/bus"
+)
+
+// replayGroupID is the consumer group, or NATS durable consumer, replay
+// reads the dead-letter topic as, separate from the one of the service.
+const replayGroupID = "user-service-dlq-replay"
+
+// dlq is an admin tool for the userservice dead-letter topic.
+//
+//	dlq --config=./config/local.yaml replay [--limit=N] [--idle=5s]
+func main() {
+	cfg := config.MustLoad()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: dlq --config=PATH replay [--limit=N] [--idle=DURATION]")
+		os.Exit(2)
+	}
+
+	switch flag.Arg(0) {
+	case "replay":
+		replay(cfg, flag.Args()[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		os.Exit(2)
+	}
+}
+
+// replay moves dead letters back to the main topic, all of them unless
+// --limit is given.
+func replay(cfg *config.Config, args []string) {
+	var (
+		limit int
+		idle  time.Duration
+	)
+
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	flags.IntVar(&limit, "limit", 0, "maximum number of messages to replay")
+	flags.DurationVar(&idle, "idle", 5*time.Second, "stop after no dead letter arrived for this long")
+	_ = flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	factory, err := busapp.New(log, cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	deadLetters, err := factory.DeadLetterSubscriber(replayGroupID)
+	if err != nil {
+		panic(err)
+	}
+	defer deadLetters.Close()
+
+	publisher, err := factory.EventPublisher()
+	if err != nil {
+		panic(err)
+	}
+	defer publisher.Close()
+
+	replayed, err := bus.ReplayDeadLetters(ctx, log, deadLetters, publisher, limit, idle)
+	fmt.Printf("Replayed %d messages\n", replayed)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"events"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	busapp "userservice/internal/app/bus"
+	"userservice/internal/config"
+	"userservice/internal/lib/bus"
+	"userservice/internal/services/processors"
+	profilerebuilder "userservice/internal/services/profile-rebuilder"
+	"userservice/internal/storage/sqlstorage"
+)
+
+// rebuild replays the events topic into the profiles storage, from the
+// oldest event kept unless --offset or --since is given, up to the events
+// written by the time it starts. Events are applied again even if already
+// processed; the consumer group offsets of the service are left alone.
+//
+//	rebuild --config=./config/local.yaml [--offset=PARTITION:OFFSET,... | --since=RFC3339]
+func main() {
+	var (
+		offsets string
+		since   string
+	)
+
+	flag.StringVar(&offsets, "offset", "",
+		"comma-separated PARTITION:OFFSET pairs to start partitions at; NATS has partition 0 only")
+	flag.StringVar(&since, "since", "", "start at the first event written at or after this RFC 3339 time")
+	cfg := config.MustLoad()
+
+	from, err := parseOffsets(offsets)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --offset: %v\n", err)
+		os.Exit(2)
+	}
+	if since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --since: %v\n", err)
+			os.Exit(2)
+		}
+		from.Time = t
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	storage, err := sqlstorage.New("sqlite3", cfg.StoragePath)
+	if err != nil {
+		panic(err)
+	}
+
+	factory, err := busapp.New(log, cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	subscriber, err := factory.ReplaySubscriber(from)
+	if err != nil {
+		panic(err)
+	}
+	defer subscriber.Close()
+
+	// The same routing as the service, without the processed events
+	// ledger in front of it.
+	registry := processors.NewRegistry(log, processors.NewSkipProcessor(log))
+	registry.Register(processors.NewUserProcessor(log, storage),
+		events.TypeUserCreated, events.TypeUserInvited, events.TypeUserSnapshot)
+	registry.Register(processors.NewMembershipProcessor(log, storage),
+		events.TypeOrgMemberAdded)
+
+	applied, skipped, err := profilerebuilder.New(log, subscriber, registry).Rebuild(ctx)
+	fmt.Printf("Applied %d events, skipped %d\n", applied, skipped)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// parseOffsets parses the --offset flag, such as "0:1000,1:950".
+func parseOffsets(value string) (bus.Position, error) {
+	from := bus.Position{Offsets: make(map[int]int64)}
+	if value == "" {
+		return from, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		partition, offset, ok := strings.Cut(pair, ":")
+		if !ok {
+			return bus.Position{}, fmt.Errorf("%q is not PARTITION:OFFSET", pair)
+		}
+		p, err := strconv.Atoi(partition)
+		if err != nil {
+			return bus.Position{}, fmt.Errorf("partition %q: %w", partition, err)
+		}
+		o, err := strconv.ParseInt(offset, 10, 64)
+		if err != nil {
+			return bus.Position{}, fmt.Errorf("offset %q: %w", offset, err)
+		}
+		from.Offsets[p] = o
+	}
+
+	return from, nil
+}
@@ -3,16 +3,22 @@ package main
 import (
 	"context"
 	"errors"
+	"events"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
+	busapp "userservice/internal/app/bus"
 	grpcapp "userservice/internal/app/grpc"
 	"userservice/internal/config"
-	kafkaconsumer "userservice/internal/lib/kafka"
+	"userservice/internal/lib/bus"
 	"userservice/internal/lib/metrics"
 	eventgetter "userservice/internal/services/event-getter"
+	eventrelay "userservice/internal/services/event-relay"
+	ledgercleaner "userservice/internal/services/ledger-cleaner"
 	"userservice/internal/services/processors"
 	"userservice/internal/storage/sqlstorage"
 )
@@ -45,25 +51,98 @@ func main() {
 		exitCode = 1
 		return
 	}
-	defer application.Stop()
 
-	userProcessor := setupUserProcessor(log, cfg.StoragePath)
-	kafkaConsumer := setupKafkaConsumer(log, cfg)
+	storage := setupStorage(log, cfg.StoragePath)
+	eventProcessor := setupEventProcessor(log, storage)
+	busFactory, err := busapp.New(log, cfg)
+	if err != nil {
+		log.Error("failed to set up bus", slog.String("error", err.Error()))
+		exitCode = 1
+		return
+	}
+
+	if err := ensureTopics(busFactory); err != nil {
+		log.Error("failed to provision topics", slog.String("error", err.Error()))
+		exitCode = 1
+		return
+	}
+
+	eventSubscriber := setupEventSubscriber(log, busFactory, cfg)
 	defer func() {
-		if err := kafkaConsumer.Close(); err != nil {
-			log.Error("Kafka close error", slog.String("err", err.Error()))
+		if err := eventSubscriber.Close(); err != nil {
+			log.Error("bus close error", slog.String("err", err.Error()))
 			exitCode = 1
 		}
 	}()
 
-	userEventGetter := eventgetter.New(log, kafkaConsumer, userProcessor)
+	deadLetterPublisher, err := busFactory.DeadLetterPublisher()
+	if err != nil {
+		log.Error("failed to create dead letter producer", slog.String("error", err.Error()))
+		exitCode = 1
+		return
+	}
+	deadLetterProducer := bus.NewDeadLetterProducer(log, deadLetterPublisher)
+	defer func() {
+		if err := deadLetterProducer.Close(); err != nil {
+			log.Error("bus close error", slog.String("err", err.Error()))
+			exitCode = 1
+		}
+	}()
+
+	userEventGetter := eventgetter.New(
+		log,
+		eventSubscriber,
+		eventProcessor,
+		deadLetterProducer,
+		cfg.EventGetter.Workers,
+		cfg.EventGetter.DrainTimeout,
+		events.RetryPolicy{
+			MaxAttempts: cfg.EventGetter.MaxAttempts,
+			Backoff:     cfg.EventGetter.RetryBackoff,
+			MaxBackoff:  cfg.EventGetter.MaxRetryBackoff,
+		},
+	)
+
+	ledgerCleaner := ledgercleaner.New(log, storage, cfg.EventLedger.Retention)
+
+	profilePublisher, err := busFactory.ProfileEventPublisher()
+	if err != nil {
+		log.Error("failed to create profile event producer", slog.String("error", err.Error()))
+		exitCode = 1
+		return
+	}
+	profileProducer := bus.NewEventProducer(log, profilePublisher)
+	defer func() {
+		if err := profileProducer.Close(); err != nil {
+			log.Error("bus close error", slog.String("err", err.Error()))
+			exitCode = 1
+		}
+	}()
+
+	eventRelay := eventrelay.New(
+		log,
+		storage,
+		profileProducer,
+		relayID(cfg.EventRelay.RelayID),
+		cfg.EventRelay.BatchSize,
+		cfg.EventRelay.Lease,
+		events.RetryPolicy{
+			Backoff:    cfg.EventRelay.RetryBackoff,
+			MaxBackoff: cfg.EventRelay.MaxRetryBackoff,
+		},
+	)
 
 	var wg sync.WaitGroup
-	wg.Add(1)
+	wg.Add(3)
 
+	// Shutdown stops the gRPC server and the background jobs at once: the
+	// getter stops fetching and drains its in-flight events while
+	// requests finish. The subscriber and producers are closed by the
+	// deferred calls above, after everything using them returned.
 	ctx, cancel := context.WithCancel(context.Background())
 	defer func() {
 		cancel()
+		application.Stop()
 		wg.Wait()
 	}()
 
@@ -80,6 +159,22 @@ func main() {
 		}
 	}()
 
+	go func() {
+		defer wg.Done()
+		if err := ledgerCleaner.StartCleaning(ctx, cfg.EventLedger.PruneInterval); err != nil &&
+			!errors.Is(err, context.Canceled) {
+			log.Error("ledger cleaner exit with error", slog.String("error", err.Error()))
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		if err := eventRelay.StartRelaying(ctx, cfg.EventRelay.PollInterval); err != nil &&
+			!errors.Is(err, context.Canceled) {
+			log.Error("event relay exit with error", slog.String("error", err.Error()))
+		}
+	}()
+
 	go func() {
 		if err := metrics.Listen(cfg.Metrics.Host, cfg.Metrics.Port); err != nil {
 			log.Error("failed to start metrics server", slog.String("error", err.Error()))
@@ -140,22 +235,61 @@ func setupLogger(env string) *slog.Logger {
 	return log
 }
 
-func setupKafkaConsumer(log *slog.Logger, cfg *config.Config) *kafkaconsumer.Consumer {
-	kafkaconsumer, error := kafkaconsumer.New(
-		log, cfg.Kafka.Brokers, cfg.Kafka.Topic, cfg.Kafka.GroupID, cfg.Kafka.DialAddr)
-	if error != nil {
-		log.Error("failed to initialize kafka consumer")
+func setupEventSubscriber(log *slog.Logger, factory *busapp.Factory, cfg *config.Config) bus.Subscriber {
+	groupID := cfg.Kafka.GroupID
+	if cfg.Bus.Driver == bus.DriverNATS {
+		groupID = cfg.NATS.Durable
+	}
+
+	subscriber, err := factory.EventSubscriber(groupID)
+	if err != nil {
+		log.Error("failed to initialize event subscriber", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 
-	return kafkaconsumer
+	return subscriber
+}
+
+func ensureTopics(busFactory *busapp.Factory) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return busFactory.EnsureTopics(ctx)
 }
 
-func setupUserProcessor(log *slog.Logger, storagePath string) *processors.UserProcessor {
+func setupStorage(log *slog.Logger, storagePath string) *sqlstorage.SQLStorage {
 	storage, err := sqlstorage.New("sqlite3", storagePath)
 	if err != nil {
 		log.Error("failed to initialize storage")
 		os.Exit(1)
 	}
-	return processors.NewUserProcessor(log, storage)
+	return storage
+}
+
+// setupEventProcessor routes every event type userservice handles to its
+// processor. Other events are logged and skipped. Events already in the
+// processed events ledger are skipped before routing.
+func setupEventProcessor(log *slog.Logger, storage *sqlstorage.SQLStorage) *processors.IdempotentProcessor {
+	registry := processors.NewRegistry(log, processors.NewSkipProcessor(log))
+	registry.Register(processors.NewUserProcessor(log, storage),
+		events.TypeUserCreated, events.TypeUserInvited, events.TypeUserSnapshot)
+	registry.Register(processors.NewMembershipProcessor(log, storage),
+		events.TypeOrgMemberAdded)
+
+	return processors.NewIdempotentProcessor(log, storage, registry)
+}
+
+// relayID returns the configured outbox relay id, or the hostname and pid
+// of this replica.
+func relayID(configured string) string {
+	if configured != "" {
+		return configured
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "userservice"
+	}
+
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
 }
@@ -0,0 +1,165 @@
+// Package busapp opens the subscribers and publishers userservice needs
+// on the broker picked by configuration.
+package busapp
+
+import (
+	"context"
+	"events/kafkasetup"
+	"fmt"
+	"log/slog"
+	"userservice/internal/config"
+	"userservice/internal/lib/bus"
+	kafkaconsumer "userservice/internal/lib/kafka"
+	"userservice/internal/lib/membus"
+	natsbus "userservice/internal/lib/nats"
+)
+
+type Factory struct {
+	log    *slog.Logger
+	cfg    *config.Config
+	memory *membus.Bus
+}
+
+func New(log *slog.Logger, cfg *config.Config) (*Factory, error) {
+	factory := &Factory{log: log, cfg: cfg}
+
+	switch cfg.Bus.Driver {
+	case bus.DriverKafka, bus.DriverNATS:
+	case bus.DriverMemory:
+		log.Warn("using the in-memory bus, only events published by this process are consumed")
+		factory.memory = membus.New()
+	default:
+		return nil, fmt.Errorf("unknown bus driver %q", cfg.Bus.Driver)
+	}
+
+	return factory, nil
+}
+
+// EventSubscriber reads the events topic as groupID.
+func (f *Factory) EventSubscriber(groupID string) (bus.Subscriber, error) {
+	switch f.cfg.Bus.Driver {
+	case bus.DriverKafka:
+		return kafkaconsumer.New(
+			f.log, f.cfg.Kafka.Brokers, f.cfg.Kafka.Topic, groupID, f.cfg.Kafka.DialAddr, kafkaSecurity(f.cfg.Kafka))
+	case bus.DriverNATS:
+		return natsbus.NewSubscriber(f.log, f.cfg.NATS.URL, f.cfg.NATS.Subject, groupID)
+	default:
+		return f.memory.Subscriber(f.cfg.Kafka.Topic), nil
+	}
+}
+
+// ReplaySubscriber reads the events topic from the position from,
+// without a consumer group and without committing anything, up to the
+// events written by now. The memory bus keeps no history to replay.
+func (f *Factory) ReplaySubscriber(from bus.Position) (bus.ReplaySubscriber, error) {
+	switch f.cfg.Bus.Driver {
+	case bus.DriverKafka:
+		return kafkaconsumer.NewReplayReader(
+			f.log, f.cfg.Kafka.Brokers, f.cfg.Kafka.Topic, f.cfg.Kafka.DialAddr, kafkaSecurity(f.cfg.Kafka), from)
+	case bus.DriverNATS:
+		return natsbus.NewReplaySubscriber(f.log, f.cfg.NATS.URL, f.cfg.NATS.Subject, from)
+	default:
+		return nil, fmt.Errorf("the %s bus can't be replayed", bus.DriverMemory)
+	}
+}
+
+// EventPublisher writes to the events topic.
+func (f *Factory) EventPublisher() (bus.Publisher, error) {
+	switch f.cfg.Bus.Driver {
+	case bus.DriverKafka:
+		return kafkaconsumer.NewProducer(
+			f.log, f.cfg.Kafka.Brokers, f.cfg.Kafka.Topic, f.cfg.Kafka.DialAddr, kafkaSecurity(f.cfg.Kafka))
+	case bus.DriverNATS:
+		return natsbus.NewPublisher(f.log, f.cfg.NATS.URL, f.cfg.NATS.Subject)
+	default:
+		return f.memory.Publisher(f.cfg.Kafka.Topic), nil
+	}
+}
+
+// DeadLetterSubscriber reads the dead-letter topic as groupID.
+func (f *Factory) DeadLetterSubscriber(groupID string) (bus.Subscriber, error) {
+	switch f.cfg.Bus.Driver {
+	case bus.DriverKafka:
+		return kafkaconsumer.New(
+			f.log, f.cfg.Kafka.Brokers, f.cfg.Kafka.DeadLetterTopic, groupID, f.cfg.Kafka.DialAddr, kafkaSecurity(f.cfg.Kafka))
+	case bus.DriverNATS:
+		return natsbus.NewSubscriber(f.log, f.cfg.NATS.URL, f.cfg.NATS.DeadLetterSubject, groupID)
+	default:
+		return f.memory.Subscriber(f.cfg.Kafka.DeadLetterTopic), nil
+	}
+}
+
+// DeadLetterPublisher writes to the dead-letter topic.
+func (f *Factory) DeadLetterPublisher() (bus.Publisher, error) {
+	switch f.cfg.Bus.Driver {
+	case bus.DriverKafka:
+		return kafkaconsumer.NewProducer(
+			f.log, f.cfg.Kafka.Brokers, f.cfg.Kafka.DeadLetterTopic, f.cfg.Kafka.DialAddr, kafkaSecurity(f.cfg.Kafka))
+	case bus.DriverNATS:
+		return natsbus.NewPublisher(f.log, f.cfg.NATS.URL, f.cfg.NATS.DeadLetterSubject)
+	default:
+		return f.memory.Publisher(f.cfg.Kafka.DeadLetterTopic), nil
+	}
+}
+
+// ProfileEventPublisher writes to the topic of the events userservice
+// publishes.
+func (f *Factory) ProfileEventPublisher() (bus.Publisher, error) {
+	switch f.cfg.Bus.Driver {
+	case bus.DriverKafka:
+		return kafkaconsumer.NewProducer(
+			f.log, f.cfg.Kafka.Brokers, f.cfg.Kafka.ProfileTopic, f.cfg.Kafka.DialAddr, kafkaSecurity(f.cfg.Kafka))
+	case bus.DriverNATS:
+		return natsbus.NewPublisher(f.log, f.cfg.NATS.URL, f.cfg.NATS.ProfileSubject)
+	default:
+		return f.memory.Publisher(f.cfg.Kafka.ProfileTopic), nil
+	}
+}
+
+// EnsureTopics provisions the Kafka topics userservice publishes to and
+// checks that the events topic, which belongs to sso, exists. Other
+// drivers create their streams on connection.
+func (f *Factory) EnsureTopics(ctx context.Context) error {
+	if f.cfg.Bus.Driver != bus.DriverKafka {
+		return nil
+	}
+
+	cfg := f.cfg.Kafka
+	return kafkasetup.EnsureTopics(ctx, f.log, cfg.Brokers, kafkaSecurity(cfg), []kafkasetup.TopicSpec{
+		{
+			Name:     cfg.Topic,
+			External: true,
+		},
+		{
+			Name:              cfg.DeadLetterTopic,
+			Partitions:        cfg.Partitions,
+			ReplicationFactor: cfg.ReplicationFactor,
+			Retention:         cfg.DeadLetterRetention,
+		},
+		{
+			Name:              cfg.ProfileTopic,
+			Partitions:        cfg.Partitions,
+			ReplicationFactor: cfg.ReplicationFactor,
+			Retention:         cfg.Retention,
+		},
+	}, cfg.TopicStrictness)
+}
+
+// kafkaSecurity maps the TLS and SASL settings of the config.
+func kafkaSecurity(cfg config.KafkaConfig) kafkasetup.Security {
+	return kafkasetup.Security{
+		TLS: kafkasetup.TLS{
+			Enabled:            cfg.TLS.Enabled,
+			CAFile:             cfg.TLS.CAFile,
+			CertFile:           cfg.TLS.CertFile,
+			KeyFile:            cfg.TLS.KeyFile,
+			ServerName:         cfg.TLS.ServerName,
+			InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+		},
+		SASL: kafkasetup.SASL{
+			Mechanism: cfg.SASL.Mechanism,
+			Username:  cfg.SASL.Username,
+			Password:  cfg.SASL.Password,
+		},
+	}
+}
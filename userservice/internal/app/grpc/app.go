@@ -33,8 +33,10 @@ func New(log *slog.Logger, appConfig AppConfig) (*App, error) {
 	}
 
 	userService := userservice.New(log, storage, storage)
-	jwtInterceptor := grpc.UnaryInterceptor(middleware.JWTAuthInterceptor(appConfig.Secret))
-	gRPCServer := grpc.NewServer(jwtInterceptor)
+	gRPCServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		middleware.TraceInterceptor(),
+		middleware.JWTAuthInterceptor(appConfig.Secret),
+	))
 
 	userservicegrpc.Register(gRPCServer, userService)
 
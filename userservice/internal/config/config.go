@@ -9,12 +9,17 @@ import (
 )
 
 type Config struct {
-	Env         string        `yaml:"env" envDefault:"development"`
-	Secret      string        `yaml:"secret" envDefault:"secret"`
-	StoragePath string        `yaml:"storage_path"`
-	GRPC        GRPCConfig    `yaml:"grpc"`
-	Kafka       KafkaConfig   `yaml:"kafka"`
-	Metrics     MetricsConfig `yaml:"metrics"`
+	Env         string            `yaml:"env" envDefault:"development"`
+	Secret      string            `yaml:"secret" envDefault:"secret"`
+	StoragePath string            `yaml:"storage_path"`
+	GRPC        GRPCConfig        `yaml:"grpc"`
+	Bus         BusConfig         `yaml:"bus"`
+	Kafka       KafkaConfig       `yaml:"kafka"`
+	NATS        NATSConfig        `yaml:"nats"`
+	EventGetter EventGetterConfig `yaml:"event_getter"`
+	EventLedger EventLedgerConfig `yaml:"event_ledger"`
+	EventRelay  EventRelayConfig  `yaml:"event_relay"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
 }
 
 type GRPCConfig struct {
@@ -27,6 +32,96 @@ type KafkaConfig struct {
 	Topic    string   `yaml:"topic"`
 	GroupID  string   `yaml:"group_id" envDefault:"user-service-group"`
 	DialAddr string   `yaml:"dial_addr" envDefault:"kafka:9092"`
+	// DeadLetterTopic receives events that can't be processed.
+	DeadLetterTopic string `yaml:"dead_letter_topic" env-default:"user_service_dlq"`
+	// ProfileTopic receives the events userservice publishes.
+	ProfileTopic string `yaml:"profile_topic" env-default:"user_profile_events"`
+	// Partitions of the dead-letter and profile topics userservice
+	// creates. The events of one user share a partition and keep their
+	// order.
+	Partitions        int `yaml:"partitions" env-default:"1"`
+	ReplicationFactor int `yaml:"replication_factor" env-default:"1"`
+	// Retention of the topics userservice creates; 0 leaves the broker
+	// default.
+	Retention           time.Duration `yaml:"retention" env-default:"0"`
+	DeadLetterRetention time.Duration `yaml:"dead_letter_retention" env-default:"0"`
+	// TopicStrictness is "warn" or "fail": whether topics that don't
+	// match these settings, or a missing events topic, are logged or
+	// stop the startup.
+	TopicStrictness string          `yaml:"topic_strictness" env:"KAFKA_TOPIC_STRICTNESS" env-default:"warn"`
+	TLS             KafkaTLSConfig  `yaml:"tls"`
+	SASL            KafkaSASLConfig `yaml:"sasl"`
+}
+
+// KafkaTLSConfig enables TLS to the brokers. CAFile replaces the system
+// roots; CertFile and KeyFile are the client certificate.
+type KafkaTLSConfig struct {
+	Enabled            bool   `yaml:"enabled" env:"KAFKA_TLS_ENABLED"`
+	CAFile             string `yaml:"ca_file" env:"KAFKA_TLS_CA_FILE"`
+	CertFile           string `yaml:"cert_file" env:"KAFKA_TLS_CERT_FILE"`
+	KeyFile            string `yaml:"key_file" env:"KAFKA_TLS_KEY_FILE"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// KafkaSASLConfig authenticates to the brokers. Mechanism is PLAIN,
+// SCRAM-SHA-256 or SCRAM-SHA-512; empty disables SASL.
+type KafkaSASLConfig struct {
+	Mechanism string `yaml:"mechanism" env:"KAFKA_SASL_MECHANISM"`
+	Username  string `yaml:"username" env:"KAFKA_SASL_USERNAME"`
+	Password  string `yaml:"password" env:"KAFKA_SASL_PASSWORD"`
+}
+
+// BusConfig picks the message broker: kafka, nats, or memory, an
+// in-process bus that needs no broker but only lives as long as the
+// process.
+type BusConfig struct {
+	Driver string `yaml:"driver" env:"BUS_DRIVER" env-default:"kafka"`
+}
+
+// NATSConfig is used when the bus driver is nats. Durable is the name of
+// the JetStream consumer, which plays the role of the Kafka group.
+type NATSConfig struct {
+	URL               string `yaml:"url" env-default:"nats://localhost:4222"`
+	Subject           string `yaml:"subject" env-default:"sso_events"`
+	DeadLetterSubject string `yaml:"dead_letter_subject" env-default:"user_service_dlq"`
+	Durable           string `yaml:"durable" env-default:"user-service-group"`
+	ProfileSubject    string `yaml:"profile_subject" env-default:"user_profile_events"`
+}
+
+// EventGetterConfig tunes event consumption. Partitions are spread over
+// Workers workers. Failed events are retried with a delay that doubles
+// with every attempt, starting at RetryBackoff and capped at
+// MaxRetryBackoff. After MaxAttempts the event is dead-lettered.
+type EventGetterConfig struct {
+	Workers int `yaml:"workers" env-default:"4"`
+	// DrainTimeout bounds how long the events being processed at shutdown
+	// may take to finish before they are abandoned uncommitted.
+	DrainTimeout    time.Duration `yaml:"drain_timeout" env-default:"10s"`
+	MaxAttempts     int           `yaml:"max_attempts" env-default:"5"`
+	RetryBackoff    time.Duration `yaml:"retry_backoff" env-default:"500ms"`
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff" env-default:"30s"`
+}
+
+// EventLedgerConfig sets how long processed event ids are remembered to
+// skip redeliveries.
+type EventLedgerConfig struct {
+	Retention     time.Duration `yaml:"retention" env-default:"168h"`
+	PruneInterval time.Duration `yaml:"prune_interval" env-default:"1h"`
+}
+
+// EventRelayConfig tunes publishing of the userservice outbox. Failed
+// events are retried with a delay that doubles with every attempt,
+// starting at RetryBackoff and capped at MaxRetryBackoff.
+type EventRelayConfig struct {
+	// RelayID identifies this replica in outbox leases. Defaults to
+	// the hostname and pid.
+	RelayID         string        `yaml:"relay_id" env:"EVENT_RELAY_RELAY_ID"`
+	BatchSize       int           `yaml:"batch_size" env-default:"100"`
+	PollInterval    time.Duration `yaml:"poll_interval" env-default:"1s"`
+	Lease           time.Duration `yaml:"lease" env-default:"30s"`
+	RetryBackoff    time.Duration `yaml:"retry_backoff" env-default:"1s"`
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff" env-default:"1m"`
 }
 
 type MetricsConfig struct {
@@ -20,6 +20,10 @@ type UserService interface {
 	GetUser(
 		ctx context.Context,
 		userID int64) (*models.User, error)
+	GetOrgUser(
+		ctx context.Context,
+		orgID int64,
+		userID int64) (*models.User, error)
 	UpdateUser(
 		ctx context.Context,
 		user *models.User) (*models.User, error)
@@ -42,7 +46,7 @@ func (UserService *serverAPI) GetUser(
 	defer func() {
 		metrics.ObserveRequest("GetUser", exitCode, time.Since(start))
 	}()
-	user, err := UserService.userService.GetUser(ctx, req.GetUserId())
+	user, err := UserService.getUser(ctx, req.GetUserId())
 
 	if err != nil {
 		if errors.Is(err, userservice.ErrUserNotFound) {
@@ -63,6 +67,22 @@ func (UserService *serverAPI) GetUser(
 	}, nil
 }
 
+// getUser returns the caller's own profile, or a profile from the
+// organization of the caller's token. Callers without an organization
+// can only see themselves.
+func (UserService *serverAPI) getUser(ctx context.Context, userID int64) (*models.User, error) {
+	if callerID, ok := ctx.Value(middleware.UserIDKey).(int64); ok && callerID == userID {
+		return UserService.userService.GetUser(ctx, userID)
+	}
+
+	orgID, ok := ctx.Value(middleware.OrgIDKey).(int64)
+	if !ok {
+		return nil, userservice.ErrUserNotFound
+	}
+
+	return UserService.userService.GetOrgUser(ctx, orgID, userID)
+}
+
 func (UserService *serverAPI) UpdateUser(
 	ctx context.Context,
 	req *userservicev1.UpdateUserRequest) (*userservicev1.UpdateUserResponse, error) {
@@ -79,6 +99,11 @@ func (UserService *serverAPI) UpdateUser(
 		return nil, status.Error(codes.Unauthenticated, "unauthenticated")
 	}
 
+	if middleware.IsImpersonated(ctx) {
+		exitCode = int(codes.PermissionDenied)
+		return nil, status.Error(codes.PermissionDenied, "not allowed during impersonation")
+	}
+
 	user := &models.User{
 		Name:    req.GetUser().GetName(),
 		Surname: req.GetUser().GetSurname(),
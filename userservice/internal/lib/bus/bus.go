@@ -0,0 +1,87 @@
+// Package bus decouples event consumption from the message broker. The
+// broker is picked by configuration: Kafka, NATS JetStream, or an
+// in-process bus for tests and running userservice without a broker.
+package bus
+
+import (
+	"context"
+	"events"
+	"fmt"
+	"time"
+)
+
+const (
+	DriverKafka  = "kafka"
+	DriverNATS   = "nats"
+	DriverMemory = "memory"
+)
+
+// Message is a message as every broker carries it. Messages of a
+// partition are delivered in Offset order; brokers without partitions use
+// partition 0.
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+}
+
+// Subscriber reads the messages of a topic. Messages must be committed
+// once handled; uncommitted ones are delivered again after a restart.
+type Subscriber interface {
+	FetchMessage(ctx context.Context) (Message, error)
+	CommitMessages(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// ReplaySubscriber reads a topic from a Position up to the messages
+// that were in it when the subscriber was created.
+type ReplaySubscriber interface {
+	Subscriber
+	// HighWaterMarks returns, for every partition with messages to
+	// replay, the offset following the last of them.
+	HighWaterMarks() map[int]int64
+}
+
+// Position is where a replay starts: at the first message written at or
+// after Time if it is set, otherwise at the offset Offsets holds for each
+// partition. Partitions without one start at the oldest message the
+// broker keeps.
+type Position struct {
+	Offsets map[int]int64
+	Time    time.Time
+}
+
+// Publisher writes messages to a single topic.
+type Publisher interface {
+	Publish(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// DecodeEvent decodes the event in msg according to its content-type
+// header, so producers may switch between JSON and protobuf at any time.
+// Messages without the header are JSON.
+func DecodeEvent(msg Message) (events.Envelope, error) {
+	// sso puts the event type into the message key, which is all that
+	// identifies messages written before the envelope. The event-type
+	// header, when present, takes precedence.
+	eventType := string(msg.Key)
+	if header, ok := msg.Headers[events.HeaderEventType]; ok {
+		eventType = header
+	}
+
+	event, err := events.Unmarshal(msg.Headers[events.HeaderContentType], eventType, msg.Value)
+	if err != nil {
+		return events.Envelope{}, fmt.Errorf("decode event: %w", err)
+	}
+
+	// Messages without an envelope have no id of their own; the one sso
+	// puts in the event-id header lets them be deduplicated too.
+	if event.ID == "" {
+		event.ID = msg.Headers[events.HeaderEventID]
+	}
+
+	return event, nil
+}
@@ -0,0 +1,26 @@
+package bus_test
+
+import (
+	"events"
+	"testing"
+	"userservice/internal/lib/bus"
+)
+
+func TestDecodeEvent_IDFromHeader(t *testing.T) {
+	// A bare payload, as sso wrote before the envelope.
+	msg := bus.Message{
+		Key:   []byte(events.TypeUserCreated),
+		Value: []byte(`{"id": 42, "email": "user@example.com"}`),
+		Headers: map[string]string{
+			events.HeaderEventID: "sso-7",
+		},
+	}
+
+	event, err := bus.DecodeEvent(msg)
+	if err != nil {
+		t.Fatalf("DecodeEvent() error = %v", err)
+	}
+	if event.Type != events.TypeUserCreated || event.ID != "sso-7" {
+		t.Errorf("DecodeEvent() = %s event %q, want UserCreated event sso-7", event.Type, event.ID)
+	}
+}
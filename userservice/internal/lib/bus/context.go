@@ -0,0 +1,18 @@
+package bus
+
+import "context"
+
+type headersKey struct{}
+
+// WithHeaders returns a context carrying the headers of the message being
+// processed, so processors can read the metadata of the event (see
+// events.Headers) without it being passed along.
+func WithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return context.WithValue(ctx, headersKey{}, headers)
+}
+
+// Headers returns the message headers of ctx, nil if none.
+func Headers(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersKey{}).(map[string]string)
+	return headers
+}
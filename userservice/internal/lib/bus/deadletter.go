@@ -0,0 +1,142 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers added to dead letters. The original headers are kept.
+const (
+	HeaderDeadLetterReason    = "dlq-reason"
+	HeaderDeadLetterError     = "dlq-error"
+	HeaderDeadLetterAttempts  = "dlq-attempts"
+	HeaderDeadLetterTopic     = "dlq-topic"
+	HeaderDeadLetterPartition = "dlq-partition"
+	HeaderDeadLetterOffset    = "dlq-offset"
+	HeaderDeadLetterFailedAt  = "dlq-failed-at"
+
+	deadLetterHeaderPrefix = "dlq-"
+)
+
+// DeadLetterProducer writes messages that failed processing to the
+// dead-letter topic.
+type DeadLetterProducer struct {
+	log       *slog.Logger
+	publisher Publisher
+}
+
+func NewDeadLetterProducer(log *slog.Logger, publisher Publisher) *DeadLetterProducer {
+	return &DeadLetterProducer{
+		log:       log,
+		publisher: publisher,
+	}
+}
+
+// SendDeadLetter writes msg to the dead-letter topic. The reason, the
+// error, the number of attempts and where msg was read from travel in the
+// headers.
+func (p *DeadLetterProducer) SendDeadLetter(
+	ctx context.Context,
+	msg Message,
+	reason string,
+	attempts int,
+	cause error,
+) error {
+	const op = "bus.SendDeadLetter"
+
+	log := p.log.With(slog.String("op", op))
+
+	headers := withoutDeadLetterHeaders(msg.Headers)
+	headers[HeaderDeadLetterReason] = reason
+	headers[HeaderDeadLetterError] = cause.Error()
+	headers[HeaderDeadLetterAttempts] = strconv.Itoa(attempts)
+	headers[HeaderDeadLetterTopic] = msg.Topic
+	headers[HeaderDeadLetterPartition] = strconv.Itoa(msg.Partition)
+	headers[HeaderDeadLetterOffset] = strconv.FormatInt(msg.Offset, 10)
+	headers[HeaderDeadLetterFailedAt] = time.Now().UTC().Format(time.RFC3339)
+
+	err := p.publisher.Publish(ctx, Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+	if err != nil {
+		log.Error("failed to send dead letter", slog.String("error", err.Error()))
+		return fmt.Errorf("send dead letter: %w", err)
+	}
+
+	log.Warn("message dead-lettered",
+		slog.String("reason", reason),
+		slog.Int64("offset", msg.Offset),
+	)
+	return nil
+}
+
+func (p *DeadLetterProducer) Close() error {
+	return p.publisher.Close()
+}
+
+// ReplayDeadLetters moves messages from the dead-letter subscriber back to
+// publisher, without the dead-letter headers. Replayed messages are
+// committed, so they are never replayed twice. It stops after limit
+// messages, if limit is positive, or once no message arrived for idle.
+func ReplayDeadLetters(
+	ctx context.Context,
+	log *slog.Logger,
+	deadLetters Subscriber,
+	publisher Publisher,
+	limit int,
+	idle time.Duration,
+) (int, error) {
+	const op = "bus.ReplayDeadLetters"
+
+	log = log.With(slog.String("op", op))
+
+	replayed := 0
+	for limit <= 0 || replayed < limit {
+		fetchCtx, cancel := context.WithTimeout(ctx, idle)
+		msg, err := deadLetters.FetchMessage(fetchCtx)
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				break
+			}
+			return replayed, fmt.Errorf("%s: fetch dead letter: %w", op, err)
+		}
+
+		err = publisher.Publish(ctx, Message{
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: withoutDeadLetterHeaders(msg.Headers),
+		})
+		if err != nil {
+			return replayed, fmt.Errorf("%s: replay message: %w", op, err)
+		}
+
+		if err := deadLetters.CommitMessages(ctx, msg); err != nil {
+			return replayed, fmt.Errorf("%s: commit dead letter: %w", op, err)
+		}
+
+		log.Debug("dead letter replayed", slog.Int64("offset", msg.Offset))
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+func withoutDeadLetterHeaders(headers map[string]string) map[string]string {
+	kept := maps.Clone(headers)
+	if kept == nil {
+		kept = make(map[string]string)
+	}
+	maps.DeleteFunc(kept, func(key string, _ string) bool {
+		return strings.HasPrefix(key, deadLetterHeaderPrefix)
+	})
+	return kept
+}
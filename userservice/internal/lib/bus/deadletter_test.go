@@ -0,0 +1,66 @@
+package bus_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+	"userservice/internal/lib/bus"
+	"userservice/internal/lib/membus"
+)
+
+func TestReplayDeadLetters(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	memory := membus.New()
+
+	deadLetters := bus.NewDeadLetterProducer(log, memory.Publisher("dlq"))
+	for _, key := range []string{"a", "b", "c"} {
+		err := deadLetters.SendDeadLetter(ctx, bus.Message{
+			Topic:   "events",
+			Key:     []byte(key),
+			Value:   []byte(`{}`),
+			Headers: map[string]string{"content-type": "application/json"},
+		}, "permanent", 1, errors.New("boom"))
+		if err != nil {
+			t.Fatalf("send dead letter: %v", err)
+		}
+	}
+
+	replayed, err := bus.ReplayDeadLetters(
+		ctx, log, memory.Subscriber("dlq"), memory.Publisher("events"), 2, time.Second)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if replayed != 2 {
+		t.Fatalf("replayed %d messages, want 2", replayed)
+	}
+
+	events := memory.Subscriber("events")
+	for _, want := range []string{"a", "b"} {
+		msg, err := events.FetchMessage(ctx)
+		if err != nil {
+			t.Fatalf("fetch: %v", err)
+		}
+		if string(msg.Key) != want {
+			t.Errorf("key = %q, want %q", msg.Key, want)
+		}
+		if _, ok := msg.Headers[bus.HeaderDeadLetterReason]; ok {
+			t.Errorf("replayed message kept dead-letter headers: %v", msg.Headers)
+		}
+		if msg.Headers["content-type"] != "application/json" {
+			t.Errorf("replayed message lost its headers: %v", msg.Headers)
+		}
+	}
+
+	replayed, err = bus.ReplayDeadLetters(
+		ctx, log, memory.Subscriber("dlq"), memory.Publisher("events"), 0, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("replay rest: %v", err)
+	}
+	if replayed != 1 {
+		t.Fatalf("replayed %d messages, want the remaining 1", replayed)
+	}
+}
@@ -0,0 +1,69 @@
+package bus
+
+import (
+	"context"
+	"events"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"userservice/internal/domain/models"
+)
+
+// EventProducer publishes outbox events through a Publisher. The outbox
+// holds JSON envelopes, which are sent as is.
+type EventProducer struct {
+	log       *slog.Logger
+	publisher Publisher
+}
+
+func NewEventProducer(log *slog.Logger, publisher Publisher) *EventProducer {
+	return &EventProducer{
+		log:       log,
+		publisher: publisher,
+	}
+}
+
+// SendEvents publishes events with a single write, keyed and with the
+// headers sso publishes its events with.
+func (p *EventProducer) SendEvents(ctx context.Context, outbox []models.Event) error {
+	const op = "bus.SendEvents"
+
+	log := p.log.With(slog.String("op", op))
+
+	messages := make([]Message, 0, len(outbox))
+	for _, event := range outbox {
+		envelope, err := events.Parse(event.Type, []byte(event.Payload))
+		if err != nil {
+			return fmt.Errorf("parse event %d: %w", event.ID, err)
+		}
+		if envelope.ID == "" {
+			envelope.ID = "userservice-" + strconv.FormatInt(event.ID, 10)
+		}
+		if envelope.Producer == "" {
+			envelope.Producer = "userservice"
+		}
+
+		partitionKey := event.PartitionKey
+		if partitionKey == "" {
+			partitionKey = event.Type
+		}
+
+		messages = append(messages, Message{
+			Key:     events.MessageKey(partitionKey, envelope.ID),
+			Value:   []byte(event.Payload),
+			Headers: events.Headers(envelope, events.ContentTypeJSON, event.TraceParent),
+		})
+	}
+
+	if err := p.publisher.Publish(ctx, messages...); err != nil {
+		log.Error("failed to send messages", slog.String("error", err.Error()))
+		return fmt.Errorf("send messages: %w", err)
+	}
+
+	log.Debug("messages sent", slog.Int("count", len(messages)))
+	return nil
+}
+
+func (p *EventProducer) Close() error {
+	return p.publisher.Close()
+}
@@ -2,8 +2,12 @@ package kafkaconsumer
 
 import (
 	"context"
+	"events"
+	"events/kafkasetup"
 	"fmt"
 	"log/slog"
+	"userservice/internal/lib/bus"
+	"userservice/internal/lib/metrics"
 
 	"github.com/segmentio/kafka-go"
 )
@@ -23,7 +27,8 @@ func New(
 	brokers []string,
 	topic string,
 	groupID string,
-	dialAddr string) (*Consumer, error) {
+	dialAddr string,
+	security kafkasetup.Security) (*Consumer, error) {
 	if len(brokers) == 0 {
 		return nil, ErrNoBrokers
 	}
@@ -31,7 +36,12 @@ func New(
 		return nil, ErrNoTopic
 	}
 
-	conn, err := kafka.Dial("tcp", dialAddr)
+	dialer, err := security.Dialer()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dialer.Dial("tcp", dialAddr)
 	if err != nil {
 		log.Error("failed to dial Kafka")
 		return nil, fmt.Errorf("dial kafka: %w", err)
@@ -42,6 +52,7 @@ func New(
 		Brokers: brokers,
 		Topic:   topic,
 		GroupID: groupID,
+		Dialer:  dialer,
 	})
 
 	log.Info("Kafka consumer initialized", slog.String("topic", topic))
@@ -56,33 +67,75 @@ func (c *Consumer) Close() error {
 	const op = "kafkaconsumer.Close"
 
 	c.log.With(slog.String("op", op)).
-		Info("closing Kafka producer")
+		Info("closing Kafka consumer")
 	return c.reader.Close()
 }
 
-func (c *Consumer) ReadMessage(ctx context.Context) (kafka.Message, error) {
-	const op = "kafkaconsumer.ReadMessage"
+// FetchMessage returns the next message without committing it; the
+// caller commits it with CommitMessages once it is processed.
+func (c *Consumer) FetchMessage(ctx context.Context) (bus.Message, error) {
+	const op = "kafkaconsumer.FetchMessage"
 
 	log := c.log.With(slog.String("op", op))
 
-	msg, err := c.reader.ReadMessage(ctx)
+	msg, err := c.reader.FetchMessage(ctx)
 
 	if err != nil {
-		log.Error("failed to read message from Kafka", slog.String("error", err.Error()))
-		return kafka.Message{}, fmt.Errorf("read message: %w", err)
+		// A cancelled fetch is how the getter stops, not a failure.
+		if ctx.Err() == nil {
+			log.Error("failed to read message from Kafka", slog.String("error", err.Error()))
+		}
+		return bus.Message{}, fmt.Errorf("read message: %w", err)
 	}
 
-	log.Info("message read from Kafka", slog.Int64("offset", msg.Offset))
+	message := toBusMessage(msg)
+	log.Info("message read from Kafka",
+		slog.Int("partition", msg.Partition),
+		slog.Int64("offset", msg.Offset),
+		slog.String("event_id", message.Headers[events.HeaderEventID]),
+	)
 
-	return msg, nil
+	// The lag in reader.Stats() is that of the last message fetched by
+	// the reader, whatever its partition, so it is taken per message
+	// instead. The high-water mark is the offset the next message
+	// written to the partition will get.
+	metrics.ObserveConsumerLag(msg.Topic, msg.Partition, max(msg.HighWaterMark-msg.Offset-1, 0))
+
+	return message, nil
 }
 
-func (c *Consumer) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+func toBusMessage(msg kafka.Message) bus.Message {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+
+	return bus.Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   headers,
+	}
+}
+
+func (c *Consumer) CommitMessages(ctx context.Context, msgs ...bus.Message) error {
 	const op = "kafkaconsumer.CommitMessages"
 
 	log := c.log.With(slog.String("op", op))
 
-	err := c.reader.CommitMessages(ctx, msgs...)
+	// Only the position of a message is needed to commit it.
+	kafkaMessages := make([]kafka.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		kafkaMessages = append(kafkaMessages, kafka.Message{
+			Topic:     msg.Topic,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+		})
+	}
+
+	err := c.reader.CommitMessages(ctx, kafkaMessages...)
 	if err != nil {
 		log.Error("failed to commit message", slog.String("error", err.Error()))
 		return fmt.Errorf("commit message: %w", err)
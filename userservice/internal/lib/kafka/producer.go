@@ -0,0 +1,95 @@
+package kafkaconsumer
+
+import (
+	"context"
+	"events/kafkasetup"
+	"fmt"
+	"log/slog"
+	"time"
+	"userservice/internal/lib/bus"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type Producer struct {
+	log    *slog.Logger
+	writer *kafka.Writer
+}
+
+// NewProducer returns a producer writing to topic, which EnsureTopics is
+// expected to have provisioned.
+func NewProducer(
+	log *slog.Logger,
+	brokers []string,
+	topic string,
+	dialAddr string,
+	security kafkasetup.Security) (*Producer, error) {
+	if len(brokers) == 0 {
+		return nil, ErrNoBrokers
+	}
+	if topic == "" {
+		return nil, ErrNoTopic
+	}
+
+	dialer, err := security.Dialer()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dialer.Dial("tcp", dialAddr)
+	if err != nil {
+		log.Error("failed to dial Kafka")
+		return nil, fmt.Errorf("dial kafka: %w", err)
+	}
+	conn.Close()
+
+	log.Info("Kafka producer initialized", slog.String("topic", topic))
+
+	return &Producer{
+		log: log,
+		writer: kafka.NewWriter(kafka.WriterConfig{
+			Brokers:      brokers,
+			Topic:        topic,
+			Balancer:     &kafkasetup.PartitionKeyBalancer{},
+			BatchTimeout: 10 * time.Millisecond,
+			Dialer:       dialer,
+		}),
+	}, nil
+}
+
+func (p *Producer) Publish(ctx context.Context, msgs ...bus.Message) error {
+	const op = "kafkaconsumer.Publish"
+
+	kafkaMessages := make([]kafka.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		kafkaMessages = append(kafkaMessages, kafka.Message{
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: toKafkaHeaders(msg.Headers),
+		})
+	}
+
+	if err := p.writer.WriteMessages(ctx, kafkaMessages...); err != nil {
+		p.log.With(slog.String("op", op)).
+			Error("failed to send Kafka messages", slog.String("error", err.Error()))
+		return fmt.Errorf("send messages: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Producer) Close() error {
+	const op = "kafkaconsumer.Producer.Close"
+
+	p.log.With(slog.String("op", op)).
+		Info("closing Kafka producer")
+	return p.writer.Close()
+}
+
+func toKafkaHeaders(headers map[string]string) []kafka.Header {
+	kafkaHeaders := make([]kafka.Header, 0, len(headers))
+	for key, value := range headers {
+		kafkaHeaders = append(kafkaHeaders, kafka.Header{Key: key, Value: []byte(value)})
+	}
+	return kafkaHeaders
+}
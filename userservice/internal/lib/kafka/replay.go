@@ -0,0 +1,195 @@
+package kafkaconsumer
+
+import (
+	"context"
+	"errors"
+	"events/kafkasetup"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+	"userservice/internal/lib/bus"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ReplayReader reads every partition of a topic from a position, outside
+// any consumer group: it commits nothing and doesn't disturb the offsets
+// of the service.
+type ReplayReader struct {
+	log            *slog.Logger
+	readers        []*kafka.Reader
+	highWaterMarks map[int]int64
+	messages       chan bus.Message
+	cancel         context.CancelFunc
+	wg             sync.WaitGroup
+}
+
+func NewReplayReader(
+	log *slog.Logger,
+	brokers []string,
+	topic string,
+	dialAddr string,
+	security kafkasetup.Security,
+	from bus.Position,
+) (*ReplayReader, error) {
+	if len(brokers) == 0 {
+		return nil, ErrNoBrokers
+	}
+	if topic == "" {
+		return nil, ErrNoTopic
+	}
+
+	dialer, err := security.Dialer()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dialer.Dial("tcp", dialAddr)
+	if err != nil {
+		return nil, fmt.Errorf("dial kafka: %w", err)
+	}
+	partitions, err := conn.ReadPartitions(topic)
+	conn.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read partitions of %s: %w", topic, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &ReplayReader{
+		log:            log,
+		highWaterMarks: make(map[int]int64, len(partitions)),
+		messages:       make(chan bus.Message),
+		cancel:         cancel,
+	}
+
+	for _, partition := range partitions {
+		start, end, err := offsets(ctx, dialer, dialAddr, topic, partition.ID, from)
+		if err != nil {
+			_ = r.Close()
+			return nil, fmt.Errorf("read offsets of partition %d: %w", partition.ID, err)
+		}
+		if start >= end {
+			continue
+		}
+		r.highWaterMarks[partition.ID] = end
+
+		reader := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:   brokers,
+			Topic:     topic,
+			Partition: partition.ID,
+			Dialer:    dialer,
+		})
+		r.readers = append(r.readers, reader)
+
+		if err := reader.SetOffset(start); err != nil {
+			_ = r.Close()
+			return nil, fmt.Errorf("seek partition %d: %w", partition.ID, err)
+		}
+
+		r.wg.Add(1)
+		go r.read(ctx, reader)
+	}
+
+	log.Info("Kafka replay reader initialized",
+		slog.String("topic", topic),
+		slog.Int("partitions", len(partitions)),
+		slog.Int("replayed_partitions", len(r.highWaterMarks)),
+	)
+
+	return r, nil
+}
+
+// offsets returns the offset of partition the replay from starts at and
+// the offset the next message will be written at.
+func offsets(
+	ctx context.Context,
+	dialer *kafka.Dialer,
+	dialAddr string,
+	topic string,
+	partition int,
+	from bus.Position,
+) (start int64, end int64, err error) {
+	conn, err := dialer.DialLeader(ctx, "tcp", dialAddr, topic, partition)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+
+	first, end, err := conn.ReadOffsets()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	start = from.Offsets[partition]
+	if !from.Time.IsZero() {
+		start, err = conn.ReadOffset(from.Time)
+		if err != nil {
+			return 0, 0, err
+		}
+		// The broker answers -1 when nothing was written since.
+		if start < 0 {
+			start = end
+		}
+	}
+
+	return max(start, first), end, nil
+}
+
+func (r *ReplayReader) read(ctx context.Context, reader *kafka.Reader) {
+	defer r.wg.Done()
+
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return
+			}
+			r.log.Error("failed to read message from Kafka", slog.String("error", err.Error()))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		select {
+		case r.messages <- toBusMessage(msg):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *ReplayReader) FetchMessage(ctx context.Context) (bus.Message, error) {
+	select {
+	case msg := <-r.messages:
+		return msg, nil
+	case <-ctx.Done():
+		return bus.Message{}, fmt.Errorf("read message: %w", ctx.Err())
+	}
+}
+
+// CommitMessages does nothing: a replay keeps no position.
+func (r *ReplayReader) CommitMessages(context.Context, ...bus.Message) error {
+	return nil
+}
+
+func (r *ReplayReader) Close() error {
+	r.cancel()
+	r.wg.Wait()
+
+	var errs []error
+	for _, reader := range r.readers {
+		errs = append(errs, reader.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// HighWaterMarks returns the offsets the replayed partitions ended at
+// when the reader was created.
+func (r *ReplayReader) HighWaterMarks() map[int]int64 {
+	return r.highWaterMarks
+}
@@ -0,0 +1,104 @@
+// Package membus is an in-process message bus. It lets userservice run
+// without a broker and lets tests feed it messages.
+package membus
+
+import (
+	"context"
+	"sync"
+	"userservice/internal/lib/bus"
+)
+
+// topicBuffer is how many messages a topic holds before publishers block.
+const topicBuffer = 1024
+
+// Bus keeps a queue per topic. Every message is delivered to one
+// subscriber of its topic, in publishing order.
+type Bus struct {
+	mu     sync.Mutex
+	topics map[string]*topic
+}
+
+type topic struct {
+	// mu keeps offsets in the order messages are queued.
+	mu       sync.Mutex
+	messages chan bus.Message
+	offset   int64
+}
+
+func New() *Bus {
+	return &Bus{topics: make(map[string]*topic)}
+}
+
+func (b *Bus) topic(name string) *topic {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{messages: make(chan bus.Message, topicBuffer)}
+		b.topics[name] = t
+	}
+	return t
+}
+
+// Publisher returns a publisher writing to the topic name.
+func (b *Bus) Publisher(name string) *Publisher {
+	return &Publisher{bus: b, name: name}
+}
+
+// Subscriber returns a subscriber reading the topic name.
+func (b *Bus) Subscriber(name string) *Subscriber {
+	return &Subscriber{topic: b.topic(name)}
+}
+
+type Publisher struct {
+	bus  *Bus
+	name string
+}
+
+func (p *Publisher) Publish(ctx context.Context, msgs ...bus.Message) error {
+	t := p.bus.topic(p.name)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, msg := range msgs {
+		msg.Topic = p.name
+		msg.Offset = t.offset
+
+		select {
+		case t.messages <- msg:
+			t.offset++
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (p *Publisher) Close() error {
+	return nil
+}
+
+type Subscriber struct {
+	topic *topic
+}
+
+func (s *Subscriber) FetchMessage(ctx context.Context) (bus.Message, error) {
+	select {
+	case msg := <-s.topic.messages:
+		return msg, nil
+	case <-ctx.Done():
+		return bus.Message{}, ctx.Err()
+	}
+}
+
+// CommitMessages does nothing: fetched messages are gone from the queue.
+func (s *Subscriber) CommitMessages(context.Context, ...bus.Message) error {
+	return nil
+}
+
+func (s *Subscriber) Close() error {
+	return nil
+}
@@ -21,3 +21,37 @@ var requestMetrics = promauto.NewSummaryVec(
 func ObserveRequest(methodName string, status int, duration time.Duration) {
 	requestMetrics.WithLabelValues(strconv.Itoa(status), methodName).Observe(duration.Seconds())
 }
+
+var consumerLag = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "userservice",
+		Subsystem: "consumer",
+		Name:      "lag",
+		Help:      "Messages of a partition written after the last fetched one.",
+	},
+	[]string{"topic", "partition"},
+)
+
+var eventProcessing = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: "userservice",
+		Subsystem: "events",
+		Name:      "processing_duration_seconds",
+		Help:      "Duration of event processing attempts, by event type and result.",
+		Buckets:   prometheus.DefBuckets,
+	},
+	[]string{"type", "result"},
+)
+
+// ObserveConsumerLag records the lag of a partition of topic.
+func ObserveConsumerLag(topic string, partition int, lag int64) {
+	consumerLag.WithLabelValues(topic, strconv.Itoa(partition)).Set(float64(lag))
+}
+
+// ObserveEventProcessing records a processing attempt of an event of
+// eventType, result is "ok" or "error". The event id is kept as an
+// exemplar, linking the metric to the logs of the event.
+func ObserveEventProcessing(eventType string, result string, duration time.Duration, eventID string) {
+	eventProcessing.WithLabelValues(eventType, result).(prometheus.ExemplarObserver).
+		ObserveWithExemplar(duration.Seconds(), prometheus.Labels{"event_id": eventID})
+}
@@ -0,0 +1,308 @@
+// Package natsbus is the NATS JetStream implementation of the bus.
+package natsbus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"userservice/internal/lib/bus"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// HeaderKey carries the message key, which NATS has no place for.
+const HeaderKey = "bus-key"
+
+// ackWait is how long a fetched message may stay uncommitted before
+// JetStream redelivers it. It covers the retries of a failing event.
+const ackWait = 10 * time.Minute
+
+var invalidStreamChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// connect opens a JetStream connection and makes sure the stream holding
+// subject exists. Streams are named after their subject.
+func connect(ctx context.Context, url string, subject string) (*nats.Conn, jetstream.JetStream, string, error) {
+	if subject == "" {
+		return nil, nil, "", fmt.Errorf("no NATS subject provided")
+	}
+
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, "", fmt.Errorf("open JetStream: %w", err)
+	}
+
+	stream := strings.ToUpper(invalidStreamChars.ReplaceAllString(subject, "_"))
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     stream,
+		Subjects: []string{subject},
+	})
+	if err != nil {
+		conn.Close()
+		return nil, nil, "", fmt.Errorf("create stream: %w", err)
+	}
+
+	return conn, js, stream, nil
+}
+
+// Subscriber reads a subject through a durable pull consumer. The
+// subject is a single partition.
+type Subscriber struct {
+	log      *slog.Logger
+	conn     *nats.Conn
+	consumer jetstream.Consumer
+
+	// replay subscribers read through an ordered consumer, which
+	// acknowledges nothing, up to highWaterMarks.
+	replay         bool
+	highWaterMarks map[int]int64
+
+	mu      sync.Mutex
+	pending map[int64]jetstream.Msg
+}
+
+// NewSubscriber returns a subscriber reading subject as the durable
+// consumer durable, which remembers the acknowledged messages.
+func NewSubscriber(log *slog.Logger, url string, subject string, durable string) (*Subscriber, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, js, stream, err := connect(ctx, url, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	consumer, err := js.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:       durable,
+		FilterSubject: subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       ackWait,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create consumer: %w", err)
+	}
+
+	log.Info("NATS subscriber initialized", slog.String("subject", subject))
+
+	return &Subscriber{
+		log:      log,
+		conn:     conn,
+		consumer: consumer,
+		pending:  make(map[int64]jetstream.Msg),
+	}, nil
+}
+
+// NewReplaySubscriber returns a subscriber reading subject from the
+// position from through an ephemeral ordered consumer, which remembers
+// nothing once closed.
+func NewReplaySubscriber(log *slog.Logger, url string, subject string, from bus.Position) (*Subscriber, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, js, stream, err := connect(ctx, url, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{subject},
+		DeliverPolicy:  jetstream.DeliverAllPolicy,
+	}
+	switch {
+	case !from.Time.IsZero():
+		cfg.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		cfg.OptStartTime = &from.Time
+	case from.Offsets[0] > 0:
+		cfg.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		cfg.OptStartSeq = uint64(from.Offsets[0])
+	}
+
+	highWaterMarks, err := streamHighWaterMarks(ctx, js, stream, from)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	consumer, err := js.OrderedConsumer(ctx, stream, cfg)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create ordered consumer: %w", err)
+	}
+
+	log.Info("NATS replay subscriber initialized", slog.String("subject", subject))
+
+	return &Subscriber{
+		log:            log,
+		conn:           conn,
+		consumer:       consumer,
+		replay:         true,
+		highWaterMarks: highWaterMarks,
+		pending:        make(map[int64]jetstream.Msg),
+	}, nil
+}
+
+// streamHighWaterMarks returns the sequence following the last message
+// of stream as the high-water mark of partition 0, unless the replay from
+// has nothing to read.
+func streamHighWaterMarks(
+	ctx context.Context,
+	js jetstream.JetStream,
+	stream string,
+	from bus.Position,
+) (map[int]int64, error) {
+	s, err := js.Stream(ctx, stream)
+	if err != nil {
+		return nil, fmt.Errorf("look up stream: %w", err)
+	}
+	info, err := s.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read stream info: %w", err)
+	}
+
+	state := info.State
+	empty := state.Msgs == 0 ||
+		(!from.Time.IsZero() && state.LastTime.Before(from.Time)) ||
+		(from.Time.IsZero() && uint64(from.Offsets[0]) > state.LastSeq)
+	if empty {
+		return map[int]int64{}, nil
+	}
+
+	return map[int]int64{0: int64(state.LastSeq) + 1}, nil
+}
+
+func (s *Subscriber) FetchMessage(ctx context.Context) (bus.Message, error) {
+	msg, err := s.consumer.Next(jetstream.FetchContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return bus.Message{}, fmt.Errorf("read message: %w", ctx.Err())
+		}
+		return bus.Message{}, fmt.Errorf("read message: %w", err)
+	}
+
+	metadata, err := msg.Metadata()
+	if err != nil {
+		return bus.Message{}, fmt.Errorf("read message metadata: %w", err)
+	}
+	offset := int64(metadata.Sequence.Stream)
+
+	if !s.replay {
+		s.mu.Lock()
+		s.pending[offset] = msg
+		s.mu.Unlock()
+	}
+
+	headers := make(map[string]string, len(msg.Headers()))
+	for key := range msg.Headers() {
+		headers[key] = msg.Headers().Get(key)
+	}
+	key := headers[HeaderKey]
+	delete(headers, HeaderKey)
+
+	return bus.Message{
+		Topic:   msg.Subject(),
+		Offset:  offset,
+		Key:     []byte(key),
+		Value:   msg.Data(),
+		Headers: headers,
+	}, nil
+}
+
+// HighWaterMarks returns the high-water mark of a replay subscriber, taken
+// when it was created.
+func (s *Subscriber) HighWaterMarks() map[int]int64 {
+	return s.highWaterMarks
+}
+
+// CommitMessages acknowledges msgs.
+func (s *Subscriber) CommitMessages(_ context.Context, msgs ...bus.Message) error {
+	for _, msg := range msgs {
+		s.mu.Lock()
+		pending, ok := s.pending[msg.Offset]
+		delete(s.pending, msg.Offset)
+		s.mu.Unlock()
+		if !ok {
+			continue
+		}
+
+		if err := pending.Ack(); err != nil {
+			return fmt.Errorf("commit message: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Subscriber) Close() error {
+	const op = "natsbus.Subscriber.Close"
+
+	s.log.With(slog.String("op", op)).
+		Info("closing NATS subscriber")
+	return s.conn.Drain()
+}
+
+// Publisher writes messages to a subject.
+type Publisher struct {
+	log     *slog.Logger
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	subject string
+}
+
+func NewPublisher(log *slog.Logger, url string, subject string) (*Publisher, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, js, _, err := connect(ctx, url, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("NATS publisher initialized", slog.String("subject", subject))
+
+	return &Publisher{
+		log:     log,
+		conn:    conn,
+		js:      js,
+		subject: subject,
+	}, nil
+}
+
+// Publish writes msgs one by one, waiting for every acknowledgment so
+// their order is kept.
+func (p *Publisher) Publish(ctx context.Context, msgs ...bus.Message) error {
+	for _, msg := range msgs {
+		natsMsg := nats.NewMsg(p.subject)
+		natsMsg.Data = msg.Value
+		for key, value := range msg.Headers {
+			natsMsg.Header.Set(key, value)
+		}
+		if len(msg.Key) > 0 {
+			natsMsg.Header.Set(HeaderKey, string(msg.Key))
+		}
+
+		if _, err := p.js.PublishMsg(ctx, natsMsg); err != nil {
+			return fmt.Errorf("publish to %s: %w", p.subject, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Publisher) Close() error {
+	const op = "natsbus.Publisher.Close"
+
+	p.log.With(slog.String("op", op)).
+		Info("closing NATS publisher")
+	return p.conn.Drain()
+}
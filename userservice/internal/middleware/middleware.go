@@ -16,8 +16,19 @@ type contextKey string
 const (
 	UserIDKey contextKey = "uid"
 	EmailKey  contextKey = "email"
+	OrgIDKey  contextKey = "org_id"
+	// ActorIDKey holds the id of the user acting on behalf of UserIDKey
+	// when the request carries an impersonation token.
+	ActorIDKey contextKey = "act"
 )
 
+// IsImpersonated reports whether the request is made with an
+// impersonation token.
+func IsImpersonated(ctx context.Context) bool {
+	_, ok := ctx.Value(ActorIDKey).(int64)
+	return ok
+}
+
 func JWTAuthInterceptor(secret string) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
@@ -59,8 +70,35 @@ func JWTAuthInterceptor(secret string) grpc.UnaryServerInterceptor {
 			if email, ok := claims["email"].(string); ok {
 				ctx = context.WithValue(ctx, EmailKey, email)
 			}
+			if orgID, ok := claims["org_id"].(float64); ok {
+				ctx = context.WithValue(ctx, OrgIDKey, int64(orgID))
+			}
+			// A token that claims an actor must name it, or the request
+			// would pass as the user's own session.
+			if act, ok := claims["act"]; ok {
+				actorID, ok := parseActor(act)
+				if !ok {
+					return nil, status.Error(codes.Unauthenticated, "invalid act claim")
+				}
+				ctx = context.WithValue(ctx, ActorIDKey, actorID)
+			}
 		}
 
 		return handler(ctx, req)
 	}
 }
+
+// parseActor returns the user id of an "act" claim.
+func parseActor(act interface{}) (int64, bool) {
+	claim, ok := act.(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+
+	uid, ok := claim["uid"].(float64)
+	if !ok || uid <= 0 {
+		return 0, false
+	}
+
+	return int64(uid), true
+}
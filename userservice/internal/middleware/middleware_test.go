@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"events"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testSecret = "secret"
+
+func signToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	return token
+}
+
+func TestJWTAuthInterceptor_ActClaim(t *testing.T) {
+	tests := []struct {
+		name             string
+		act              interface{}
+		wantCode         codes.Code
+		wantImpersonated bool
+	}{
+		{name: "no act claim", wantCode: codes.OK},
+		{name: "valid act claim", act: map[string]interface{}{"uid": 2}, wantCode: codes.OK, wantImpersonated: true},
+		{name: "act is not an object", act: "2", wantCode: codes.Unauthenticated},
+		{name: "act without uid", act: map[string]interface{}{}, wantCode: codes.Unauthenticated},
+		{name: "uid is not a number", act: map[string]interface{}{"uid": "2"}, wantCode: codes.Unauthenticated},
+		{name: "zero uid", act: map[string]interface{}{"uid": 0}, wantCode: codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := jwt.MapClaims{"uid": 1, "email": "user@example.com"}
+			if tt.act != nil {
+				claims["act"] = tt.act
+			}
+
+			ctx := metadata.NewIncomingContext(context.Background(),
+				metadata.Pairs("authorization", "Bearer "+signToken(t, claims)))
+
+			var impersonated bool
+			handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				impersonated = IsImpersonated(ctx)
+				return nil, nil
+			}
+
+			_, err := JWTAuthInterceptor(testSecret)(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("interceptor code = %v, want %v", code, tt.wantCode)
+			}
+			if impersonated != tt.wantImpersonated {
+				t.Errorf("IsImpersonated() = %v, want %v", impersonated, tt.wantImpersonated)
+			}
+		})
+	}
+}
+
+func TestTraceInterceptor(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name     string
+		incoming string
+		want     string
+	}{
+		{name: "valid traceparent", incoming: traceParent, want: traceParent},
+		{name: "malformed traceparent", incoming: "00-xyz-01"},
+		{name: "no traceparent"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.incoming != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("traceparent", tt.incoming))
+			}
+
+			var got string
+			handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				got = events.TraceParent(ctx)
+				return nil, nil
+			}
+
+			if _, err := TraceInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, handler); err != nil {
+				t.Fatalf("interceptor error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("TraceParent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+	"events"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const traceParentHeader = "traceparent"
+
+// TraceInterceptor puts the W3C trace context of the request into the
+// context, so events written while serving it join the caller's trace. A
+// missing or malformed traceparent is left out.
+func TraceInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(traceParentHeader); len(values) > 0 && events.ValidTraceParent(values[0]) {
+				ctx = events.WithTraceParent(ctx, values[0])
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,122 @@
+package eventgetter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"events"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+	"userservice/internal/lib/bus"
+	"userservice/internal/services/processors"
+	"userservice/internal/storage/sqlstorage"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// crashingConsumer fails the first commit and cancels the getter, as a
+// consumer crashing between processing a message and committing it.
+type crashingConsumer struct {
+	fakeConsumer
+	crash   context.CancelFunc
+	crashed bool
+}
+
+func (c *crashingConsumer) CommitMessages(ctx context.Context, msgs ...bus.Message) error {
+	if !c.crashed {
+		c.crashed = true
+		c.crash()
+		return errors.New("consumer crashed")
+	}
+	return c.fakeConsumer.CommitMessages(ctx, msgs...)
+}
+
+// countingProcessor counts the events that reach the wrapped processor.
+type countingProcessor struct {
+	next  processors.Processor
+	calls int
+}
+
+func (p *countingProcessor) ProcessEvent(ctx context.Context, event events.Envelope) error {
+	p.calls++
+	return p.next.ProcessEvent(ctx, event)
+}
+
+func TestGetter_CrashesApplyEventsAtMostOnce(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	storagePath := filepath.Join(t.TempDir(), "userservice.db")
+	m, err := migrate.New("file://../../../migrations", "sqlite3://"+storagePath)
+	if err != nil {
+		t.Fatalf("create migrator: %v", err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+	if srcErr, dbErr := m.Close(); srcErr != nil || dbErr != nil {
+		t.Fatalf("close migrator: %v, %v", srcErr, dbErr)
+	}
+	storage, err := sqlstorage.New("sqlite3", storagePath)
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+
+	envelope, err := events.New(context.Background(), events.TypeUserCreated, "sso",
+		events.UserCreated{UserID: 42, Email: "user@example.com"})
+	if err != nil {
+		t.Fatalf("create envelope: %v", err)
+	}
+	value, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+	message := func(offset int64) bus.Message {
+		return bus.Message{
+			Offset: offset,
+			Key:    events.MessageKey("42", envelope.ID),
+			Value:  value,
+			Headers: map[string]string{
+				events.HeaderContentType: events.ContentTypeJSON,
+				events.HeaderEventType:   events.TypeUserCreated,
+				events.HeaderEventID:     envelope.ID,
+			},
+		}
+	}
+
+	registry := processors.NewRegistry(log, processors.NewSkipProcessor(log))
+	counter := &countingProcessor{next: processors.NewUserProcessor(log, storage)}
+	registry.Register(counter, events.TypeUserCreated)
+	processor := processors.NewIdempotentProcessor(log, storage, registry)
+	policy := events.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	// The consumer processes the event and crashes before committing it.
+	ctx, crash := context.WithCancel(context.Background())
+	defer crash()
+	crashing := &crashingConsumer{crash: crash}
+	New(log, crashing, processor, &fakeDeadLetters{}, 1, time.Second, policy).processMessage(ctx, message(0))
+	if len(crashing.committed) != 0 {
+		t.Fatalf("crashed consumer committed %v", crashing.committed)
+	}
+
+	// After the restart the uncommitted event is delivered again, followed
+	// by the copy a relay crash published.
+	restarted := &fakeConsumer{}
+	getter := New(log, restarted, processor, &fakeDeadLetters{}, 1, time.Second, policy)
+	getter.processMessage(context.Background(), message(0))
+	getter.processMessage(context.Background(), message(1))
+
+	if len(restarted.committed) != 2 {
+		t.Errorf("committed %d messages after the restart, want 2", len(restarted.committed))
+	}
+	if counter.calls != 1 {
+		t.Errorf("event applied %d times, want once", counter.calls)
+	}
+	if _, err := storage.GetUserByID(context.Background(), 42); err != nil {
+		t.Errorf("user of the event not created: %v", err)
+	}
+}
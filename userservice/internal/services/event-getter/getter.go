@@ -2,74 +2,271 @@ package eventgetter
 
 import (
 	"context"
+	"events"
 	"log/slog"
+	"sync"
+	"time"
+	"userservice/internal/lib/bus"
+	"userservice/internal/lib/metrics"
+)
+
+// workerQueueSize is how many fetched messages may wait for a worker.
+const workerQueueSize = 16
 
-	"github.com/segmentio/kafka-go"
+// Reasons a message is dead-lettered for.
+const (
+	ReasonPermanent = "permanent"
+	ReasonExhausted = "retries_exhausted"
 )
 
 type EventConsumer interface {
-	ReadMessage(ctx context.Context) (kafka.Message, error)
-	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	FetchMessage(ctx context.Context) (bus.Message, error)
+	CommitMessages(ctx context.Context, msgs ...bus.Message) error
 }
 
 type EventProcessor interface {
-	ProcessEvent(ctx context.Context, event []byte) error
+	ProcessEvent(ctx context.Context, event events.Envelope) error
+}
+
+type DeadLetterProducer interface {
+	SendDeadLetter(ctx context.Context, msg bus.Message, reason string, attempts int, cause error) error
 }
 
 type Getter struct {
-	log            *slog.Logger
-	EventConsumer  EventConsumer
-	EventProcessor EventProcessor
+	log                *slog.Logger
+	EventConsumer      EventConsumer
+	EventProcessor     EventProcessor
+	DeadLetterProducer DeadLetterProducer
+	workers            int
+	drainTimeout       time.Duration
+	retryPolicy        events.RetryPolicy
 }
 
-func New(log *slog.Logger, consumer EventConsumer, processor EventProcessor) *Getter {
+// New returns a new Getter. After retryPolicy.MaxAttempts a message is
+// dead-lettered.
+func New(
+	log *slog.Logger,
+	consumer EventConsumer,
+	processor EventProcessor,
+	deadLetterProducer DeadLetterProducer,
+	workers int,
+	drainTimeout time.Duration,
+	retryPolicy events.RetryPolicy,
+) *Getter {
 	return &Getter{
-		log:            log,
-		EventConsumer:  consumer,
-		EventProcessor: processor,
+		log:                log,
+		EventConsumer:      consumer,
+		EventProcessor:     processor,
+		DeadLetterProducer: deadLetterProducer,
+		workers:            max(workers, 1),
+		drainTimeout:       drainTimeout,
+		retryPolicy:        retryPolicy,
 	}
 }
 
+// GetEventStart fetches messages and hands them to the workers. All
+// messages of a partition go to the same worker, which processes and
+// commits them in offset order, while partitions of different workers are
+// processed concurrently.
+//
+// When ctx is done the getter stops fetching and the workers finish the
+// messages they are processing, committing them, for up to the drain
+// timeout. Queued messages are left uncommitted and delivered again
+// after the restart. GetEventStart returns once the workers stopped, so
+// the consumer can be closed then.
 func (g *Getter) GetEventStart(ctx context.Context) error {
 	const op = "eventgetter.Getter.GetEvent"
 
 	log := g.log.With(slog.String("op", op))
 
+	// Messages are processed under a context that outlives ctx by the
+	// drain timeout.
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+
+	queues := make([]chan bus.Message, g.workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan bus.Message, workerQueueSize)
+
+		wg.Add(1)
+		go func(queue <-chan bus.Message) {
+			defer wg.Done()
+			for message := range queue {
+				if ctx.Err() != nil {
+					continue
+				}
+				g.processMessage(workCtx, message)
+			}
+		}(queues[i])
+	}
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		g.drain(log, &wg, cancelWork)
+	}()
+
+	readFailures := 0
 	for {
 		select {
 		case <-ctx.Done():
 			log.Info("stopping event getter")
 			return ctx.Err()
 		default:
-			g.processEvent(ctx)
+		}
+
+		message, err := g.EventConsumer.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			log.Error("failed to read message from consumer", slog.String("error", err.Error()))
+			g.sleep(ctx, g.retryPolicy.Delay(readFailures))
+			readFailures++
+			continue
+		}
+		readFailures = 0
+
+		select {
+		case queues[message.Partition%len(queues)] <- message:
+		case <-ctx.Done():
 		}
 	}
 }
 
-func (g *Getter) processEvent(ctx context.Context) {
-	const op = "eventgetter.processEvent"
+// drain waits for the workers to finish their messages, cancelling them
+// if it takes longer than the drain timeout.
+func (g *Getter) drain(log *slog.Logger, wg *sync.WaitGroup, cancelWork context.CancelFunc) {
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
 
-	log := g.log.With(slog.String("op", op))
+	timer := time.NewTimer(g.drainTimeout)
+	defer timer.Stop()
 
-	message, err := g.EventConsumer.ReadMessage(ctx)
-	if err != nil {
-		log.Error("failed to read message from consumer", slog.String("error", err.Error()))
-		return
+	select {
+	case <-drained:
+		log.Info("in-flight events drained")
+	case <-timer.C:
+		log.Warn("drain timed out, abandoning in-flight events",
+			slog.Duration("timeout", g.drainTimeout))
+		cancelWork()
+		<-drained
 	}
+}
 
-	log.Info("event received", slog.Int("message_size", len(message.Value)))
+// processMessage handles message until it is processed or dead-lettered
+// and committed. Transient failures are retried with backoff; permanent
+// ones and those that ran out of attempts go to the dead-letter topic.
+func (g *Getter) processMessage(ctx context.Context, message bus.Message) {
+	const op = "eventgetter.processMessage"
 
-	err = g.EventProcessor.ProcessEvent(ctx, message.Value)
-	if err != nil {
-		log.Error("failed to process event", slog.String("error", err.Error()))
+	log := g.log.With(
+		slog.String("op", op),
+		slog.Int("partition", message.Partition),
+		slog.Int64("offset", message.Offset),
+		slog.String("event_id", message.Headers[events.HeaderEventID]),
+		slog.String("correlation_id", message.Headers[events.HeaderCorrelationID]),
+	)
+
+	log.Info("event received",
+		slog.String("event_type", message.Headers[events.HeaderEventType]),
+		slog.String("source", message.Headers[events.HeaderSource]),
+		slog.Int("message_size", len(message.Value)),
+	)
+
+	for attempts := 1; ; attempts++ {
+		err := g.processEvent(ctx, message)
+		if err == nil {
+			log.Info("event processed successfully")
+			break
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if events.IsPermanent(err) {
+			log.Error("event can't be processed", slog.String("error", err.Error()))
+			g.deadLetter(ctx, message, ReasonPermanent, attempts, err)
+			break
+		}
+		if attempts >= g.retryPolicy.MaxAttempts {
+			log.Error("event ran out of attempts",
+				slog.Int("attempts", attempts),
+				slog.String("error", err.Error()))
+			g.deadLetter(ctx, message, ReasonExhausted, attempts, err)
+			break
+		}
+
+		log.Warn("failed to process event, retrying",
+			slog.Int("attempt", attempts),
+			slog.String("error", err.Error()))
+		g.sleep(ctx, g.retryPolicy.Delay(attempts-1))
+	}
+	if ctx.Err() != nil {
 		return
 	}
-	log.Info("event processed successfully")
 
-	err = g.EventConsumer.CommitMessages(ctx, message)
-	if err != nil {
+	if err := g.EventConsumer.CommitMessages(ctx, message); err != nil {
 		log.Error("failed to commit message", slog.String("error", err.Error()))
 		return
 	}
 	log.Info("message committed successfully")
 }
+
+func (g *Getter) processEvent(ctx context.Context, message bus.Message) error {
+	event, err := bus.DecodeEvent(message)
+	if err != nil {
+		return err
+	}
+
+	// Processors see the message headers, the correlation id and the
+	// trace context of the event in ctx.
+	ctx = bus.WithHeaders(ctx, message.Headers)
+	if event.CorrelationID != "" {
+		ctx = events.WithCorrelationID(ctx, event.CorrelationID)
+	}
+	if traceParent := message.Headers[events.HeaderTraceParent]; events.ValidTraceParent(traceParent) {
+		ctx = events.WithTraceParent(ctx, traceParent)
+	}
+
+	start := time.Now()
+	err = g.EventProcessor.ProcessEvent(ctx, event)
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	metrics.ObserveEventProcessing(event.Type, result, time.Since(start), event.ID)
+
+	return err
+}
+
+// deadLetter sends message to the dead-letter topic, retrying until it
+// succeeds or ctx is done, since the message is committed afterwards.
+func (g *Getter) deadLetter(ctx context.Context, message bus.Message, reason string, attempts int, cause error) {
+	const op = "eventgetter.deadLetter"
+
+	log := g.log.With(slog.String("op", op))
+
+	for failures := 0; ctx.Err() == nil; failures++ {
+		err := g.DeadLetterProducer.SendDeadLetter(ctx, message, reason, attempts, cause)
+		if err == nil {
+			return
+		}
+		log.Error("failed to send dead letter", slog.String("error", err.Error()))
+		g.sleep(ctx, g.retryPolicy.Delay(failures))
+	}
+}
+
+func (g *Getter) sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,406 @@
+package eventgetter
+
+import (
+	"context"
+	"errors"
+	"events"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"userservice/internal/lib/bus"
+)
+
+type fakeConsumer struct {
+	mu        sync.Mutex
+	messages  chan bus.Message
+	committed []bus.Message
+}
+
+func (c *fakeConsumer) FetchMessage(ctx context.Context) (bus.Message, error) {
+	select {
+	case message := <-c.messages:
+		return message, nil
+	case <-ctx.Done():
+		return bus.Message{}, ctx.Err()
+	}
+}
+
+func (c *fakeConsumer) CommitMessages(_ context.Context, msgs ...bus.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.committed = append(c.committed, msgs...)
+	return nil
+}
+
+// flakyProcessor fails the first failures calls with err.
+type flakyProcessor struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (p *flakyProcessor) ProcessEvent(context.Context, events.Envelope) error {
+	p.calls++
+	if p.calls <= p.failures {
+		return p.err
+	}
+	return nil
+}
+
+type deadLetter struct {
+	reason   string
+	attempts int
+}
+
+type fakeDeadLetters struct {
+	sent     []deadLetter
+	failures int
+}
+
+func (d *fakeDeadLetters) SendDeadLetter(_ context.Context, _ bus.Message, reason string, attempts int, _ error) error {
+	if d.failures > 0 {
+		d.failures--
+		return errors.New("kafka is down")
+	}
+	d.sent = append(d.sent, deadLetter{reason: reason, attempts: attempts})
+	return nil
+}
+
+func TestGetter_ProcessMessage(t *testing.T) {
+	userCreated := bus.Message{Key: []byte(events.TypeUserCreated), Value: []byte(`{"id": 1, "email": "a@b.c"}`)}
+	transient := errors.New("database is locked")
+
+	tests := []struct {
+		name            string
+		message         bus.Message
+		processor       *flakyProcessor
+		deadLetterFails int
+		wantCalls       int
+		wantDeadLetter  *deadLetter
+	}{
+		{
+			name:      "processed",
+			message:   userCreated,
+			processor: &flakyProcessor{},
+			wantCalls: 1,
+		},
+		{
+			name:      "transient error retried",
+			message:   userCreated,
+			processor: &flakyProcessor{failures: 2, err: transient},
+			wantCalls: 3,
+		},
+		{
+			name:           "retries exhausted",
+			message:        userCreated,
+			processor:      &flakyProcessor{failures: 10, err: transient},
+			wantCalls:      3,
+			wantDeadLetter: &deadLetter{reason: ReasonExhausted, attempts: 3},
+		},
+		{
+			name:           "malformed message",
+			message:        bus.Message{Key: []byte(events.TypeUserCreated), Value: []byte(`{not json`)},
+			processor:      &flakyProcessor{},
+			wantCalls:      0,
+			wantDeadLetter: &deadLetter{reason: ReasonPermanent, attempts: 1},
+		},
+		{
+			name:           "permanent processor error",
+			message:        userCreated,
+			processor:      &flakyProcessor{failures: 10, err: events.ErrUnsupportedVersion},
+			wantCalls:      1,
+			wantDeadLetter: &deadLetter{reason: ReasonPermanent, attempts: 1},
+		},
+		{
+			name:            "dead letter retried",
+			message:         userCreated,
+			processor:       &flakyProcessor{failures: 10, err: events.ErrMalformed},
+			deadLetterFails: 2,
+			wantCalls:       1,
+			wantDeadLetter:  &deadLetter{reason: ReasonPermanent, attempts: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := &fakeConsumer{}
+			deadLetters := &fakeDeadLetters{failures: tt.deadLetterFails}
+			getter := New(
+				slog.New(slog.NewTextHandler(io.Discard, nil)),
+				consumer,
+				tt.processor,
+				deadLetters,
+				1,
+				time.Second,
+				events.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond},
+			)
+
+			getter.processMessage(context.Background(), tt.message)
+
+			if tt.processor.calls != tt.wantCalls {
+				t.Errorf("processor called %d times, want %d", tt.processor.calls, tt.wantCalls)
+			}
+			if len(consumer.committed) != 1 {
+				t.Errorf("committed %d messages, want 1", len(consumer.committed))
+			}
+
+			switch {
+			case tt.wantDeadLetter == nil && len(deadLetters.sent) != 0:
+				t.Errorf("dead letters = %v, want none", deadLetters.sent)
+			case tt.wantDeadLetter != nil && (len(deadLetters.sent) != 1 || deadLetters.sent[0] != *tt.wantDeadLetter):
+				t.Errorf("dead letters = %v, want %v", deadLetters.sent, *tt.wantDeadLetter)
+			}
+		})
+	}
+}
+
+func TestGetter_StopsWithoutCommitOnCancel(t *testing.T) {
+	consumer := &fakeConsumer{}
+	getter := New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		consumer,
+		&flakyProcessor{failures: 1000, err: errors.New("database is locked")},
+		&fakeDeadLetters{},
+		1,
+		time.Second,
+		events.RetryPolicy{MaxAttempts: 1000, Backoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	getter.processMessage(ctx, bus.Message{Key: []byte(events.TypeUserCreated), Value: []byte(`{"id": 1}`)})
+
+	if len(consumer.committed) != 0 {
+		t.Errorf("committed %d messages, want none", len(consumer.committed))
+	}
+}
+
+// blockingProcessor holds events of partition 0 until an event of
+// partition 1 was processed, so it only finishes if partitions are
+// processed concurrently.
+type blockingProcessor struct {
+	mu        sync.Mutex
+	processed map[int64][]string
+	release   chan struct{}
+	once      sync.Once
+}
+
+func (p *blockingProcessor) ProcessEvent(_ context.Context, event events.Envelope) error {
+	var payload struct {
+		UserID int64 `json:"id"`
+	}
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	if payload.UserID == 0 {
+		<-p.release
+	} else {
+		p.once.Do(func() { close(p.release) })
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.processed[payload.UserID] = append(p.processed[payload.UserID], event.ID)
+	return nil
+}
+
+func TestGetter_ProcessesPartitionsConcurrentlyInOrder(t *testing.T) {
+	const perPartition = 20
+
+	consumer := &fakeConsumer{messages: make(chan bus.Message, 2*perPartition)}
+	for offset := 0; offset < perPartition; offset++ {
+		for partition := 0; partition < 2; partition++ {
+			consumer.messages <- bus.Message{
+				Partition: partition,
+				Offset:    int64(offset),
+				Key:       []byte(events.TypeUserCreated),
+				Value: []byte(fmt.Sprintf(
+					`{"id": "%d-%d", "type": "UserCreated", "version": 1, "payload": {"id": %d}}`,
+					partition, offset, partition)),
+			}
+		}
+	}
+
+	processor := &blockingProcessor{processed: map[int64][]string{}, release: make(chan struct{})}
+	getter := New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		consumer,
+		processor,
+		&fakeDeadLetters{},
+		2,
+		time.Second,
+		events.RetryPolicy{MaxAttempts: 1, Backoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- getter.GetEventStart(ctx) }()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		consumer.mu.Lock()
+		committed := len(consumer.committed)
+		consumer.mu.Unlock()
+		if committed == 2*perPartition {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("committed %d of %d messages", committed, 2*perPartition)
+		case <-time.After(time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+
+	next := map[int]int64{}
+	for _, message := range consumer.committed {
+		if message.Offset != next[message.Partition] {
+			t.Fatalf("partition %d committed offset %d, want %d", message.Partition, message.Offset, next[message.Partition])
+		}
+		next[message.Partition]++
+	}
+	for partition, ids := range processor.processed {
+		for offset, id := range ids {
+			if want := fmt.Sprintf("%d-%d", partition, offset); id != want {
+				t.Fatalf("partition %d processed %s at position %d, want %s", partition, id, offset, want)
+			}
+		}
+	}
+}
+
+// gatedProcessor signals started when an event arrives and finishes it
+// once release is closed or ctx is done.
+type gatedProcessor struct {
+	started chan struct{}
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (p *gatedProcessor) ProcessEvent(ctx context.Context, _ events.Envelope) error {
+	p.calls.Add(1)
+	p.started <- struct{}{}
+	select {
+	case <-p.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestGetter_DrainsInFlightMessageOnStop(t *testing.T) {
+	tests := []struct {
+		name          string
+		drainTimeout  time.Duration
+		release       bool
+		wantCommitted int
+	}{
+		{name: "finished within the timeout", drainTimeout: 5 * time.Second, release: true, wantCommitted: 1},
+		{name: "abandoned after the timeout", drainTimeout: 20 * time.Millisecond, wantCommitted: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := &fakeConsumer{messages: make(chan bus.Message, 2)}
+			for offset := range 2 {
+				consumer.messages <- bus.Message{
+					Offset: int64(offset),
+					Key:    []byte(events.TypeUserCreated),
+					Value:  []byte(`{"id": 1}`),
+				}
+			}
+
+			processor := &gatedProcessor{started: make(chan struct{}, 2), release: make(chan struct{})}
+			getter := New(
+				slog.New(slog.NewTextHandler(io.Discard, nil)),
+				consumer,
+				processor,
+				&fakeDeadLetters{},
+				1,
+				tt.drainTimeout,
+				events.RetryPolicy{MaxAttempts: 1, Backoff: time.Millisecond, MaxBackoff: time.Millisecond},
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() { done <- getter.GetEventStart(ctx) }()
+
+			<-processor.started
+			cancel()
+			if tt.release {
+				close(processor.release)
+			}
+			if err := <-done; !errors.Is(err, context.Canceled) {
+				t.Fatalf("GetEventStart() error = %v, want context.Canceled", err)
+			}
+
+			if len(consumer.committed) != tt.wantCommitted {
+				t.Errorf("committed %d messages, want %d", len(consumer.committed), tt.wantCommitted)
+			}
+			if calls := processor.calls.Load(); calls != 1 {
+				t.Errorf("processor called %d times, want only the in-flight message", calls)
+			}
+		})
+	}
+}
+
+// contextProcessor records the headers, correlation id and trace context
+// it is given.
+type contextProcessor struct {
+	headers       map[string]string
+	correlationID string
+	traceParent   string
+}
+
+func (p *contextProcessor) ProcessEvent(ctx context.Context, _ events.Envelope) error {
+	p.headers = bus.Headers(ctx)
+	p.correlationID = events.CorrelationID(ctx)
+	p.traceParent = events.TraceParent(ctx)
+	return nil
+}
+
+func TestGetter_PassesHeadersToProcessor(t *testing.T) {
+	envelope, err := events.New(events.WithCorrelationID(context.Background(), "registration-1"),
+		events.TypeUserCreated, "sso", events.UserCreated{UserID: 1, Email: "a@b.c"})
+	if err != nil {
+		t.Fatalf("create envelope: %v", err)
+	}
+	value, err := events.Marshal(envelope, events.ContentTypeJSON)
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	processor := &contextProcessor{}
+	getter := New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		&fakeConsumer{},
+		processor,
+		&fakeDeadLetters{},
+		1,
+		time.Second,
+		events.RetryPolicy{MaxAttempts: 1, Backoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	)
+
+	getter.processMessage(context.Background(), bus.Message{
+		Key:     events.MessageKey("1", envelope.ID),
+		Value:   value,
+		Headers: events.Headers(envelope, events.ContentTypeJSON, traceParent),
+	})
+
+	if processor.headers[events.HeaderEventID] != envelope.ID || processor.headers[events.HeaderSource] != "sso" {
+		t.Errorf("processor got headers %v, want those of event %s from sso", processor.headers, envelope.ID)
+	}
+	if processor.correlationID != "registration-1" {
+		t.Errorf("processor got correlation id %q, want registration-1", processor.correlationID)
+	}
+	if processor.traceParent != traceParent {
+		t.Errorf("processor got trace context %q, want %q", processor.traceParent, traceParent)
+	}
+}
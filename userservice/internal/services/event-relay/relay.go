@@ -0,0 +1,134 @@
+package eventrelay
+
+import (
+	"context"
+	"errors"
+	"events"
+	"log/slog"
+	"time"
+	"userservice/internal/domain/models"
+	"userservice/internal/storage"
+)
+
+type Outbox interface {
+	ClaimEvents(ctx context.Context, relayID string, limit int, lease time.Duration) ([]models.Event, error)
+	MarkEventsAsSent(ctx context.Context, relayID string, eventIDs []int64) error
+	ScheduleEventRetries(ctx context.Context, relayID string, failures []models.EventFailure) error
+}
+
+type EventProducer interface {
+	SendEvents(ctx context.Context, events []models.Event) error
+}
+
+// Relay publishes the userservice outbox. Replicas sharing the database
+// each run one; events are leased, so each is published by one relay at
+// a time.
+type Relay struct {
+	log         *slog.Logger
+	outbox      Outbox
+	producer    EventProducer
+	relayID     string
+	batchSize   int
+	lease       time.Duration
+	retryPolicy events.RetryPolicy
+}
+
+// New returns a new Relay. relayID identifies the relay among the
+// replicas; claimed events are leased to it for lease, which must be
+// longer than publishing a batch takes. Events are retried until they
+// are published, so retryPolicy.MaxAttempts is ignored.
+func New(
+	log *slog.Logger,
+	outbox Outbox,
+	producer EventProducer,
+	relayID string,
+	batchSize int,
+	lease time.Duration,
+	retryPolicy events.RetryPolicy,
+) *Relay {
+	return &Relay{
+		log:         log.With(slog.String("relay_id", relayID)),
+		outbox:      outbox,
+		producer:    producer,
+		relayID:     relayID,
+		batchSize:   max(batchSize, 1),
+		lease:       lease,
+		retryPolicy: retryPolicy,
+	}
+}
+
+// StartRelaying publishes the outbox every pollInterval, sending batches
+// until the backlog is drained.
+func (r *Relay) StartRelaying(ctx context.Context, pollInterval time.Duration) error {
+	const op = "eventrelay.StartRelaying"
+
+	log := r.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("stopping event relay")
+			return ctx.Err()
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				if sent := r.relayBatch(ctx); sent < r.batchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// relayBatch publishes one batch and returns the number of events sent.
+func (r *Relay) relayBatch(ctx context.Context) int {
+	const op = "eventrelay.relayBatch"
+
+	log := r.log.With(slog.String("op", op))
+
+	pending, err := r.outbox.ClaimEvents(ctx, r.relayID, r.batchSize, r.lease)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNoNewEvents) {
+			log.Error("failed to read outbox", slog.String("error", err.Error()))
+		}
+		return 0
+	}
+
+	if err := r.producer.SendEvents(ctx, pending); err != nil {
+		log.Error("failed to send events",
+			slog.Int("count", len(pending)),
+			slog.String("error", err.Error()))
+
+		failures := make([]models.EventFailure, 0, len(pending))
+		for _, event := range pending {
+			failures = append(failures, models.EventFailure{
+				EventID: event.ID,
+				Error:   err.Error(),
+				RetryIn: r.retryPolicy.Delay(event.Attempts),
+			})
+		}
+		if err := r.outbox.ScheduleEventRetries(ctx, r.relayID, failures); err != nil {
+			log.Error("failed to schedule event retries", slog.String("error", err.Error()))
+		}
+		return 0
+	}
+
+	eventIDs := make([]int64, 0, len(pending))
+	for _, event := range pending {
+		eventIDs = append(eventIDs, event.ID)
+	}
+
+	// Events that stay unmarked are published again; they keep their
+	// event id, so consumers drop the copy.
+	if err := r.outbox.MarkEventsAsSent(ctx, r.relayID, eventIDs); err != nil {
+		log.Error("failed to mark events as sent",
+			slog.Int("count", len(pending)),
+			slog.String("error", err.Error()))
+		return 0
+	}
+
+	log.Debug("events sent", slog.Int("count", len(pending)))
+	return len(pending)
+}
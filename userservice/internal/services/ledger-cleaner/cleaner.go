@@ -0,0 +1,54 @@
+package ledgercleaner
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type LedgerPruner interface {
+	PruneProcessedEvents(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+// Cleaner removes entries of the processed events ledger older than
+// retention. Redeliveries of events older than that are processed again,
+// so retention must exceed how long a message can stay uncommitted.
+type Cleaner struct {
+	log       *slog.Logger
+	pruner    LedgerPruner
+	retention time.Duration
+}
+
+func New(log *slog.Logger, pruner LedgerPruner, retention time.Duration) *Cleaner {
+	return &Cleaner{
+		log:       log,
+		pruner:    pruner,
+		retention: retention,
+	}
+}
+
+func (c *Cleaner) StartCleaning(ctx context.Context, interval time.Duration) error {
+	const op = "ledgercleaner.StartCleaning"
+
+	log := c.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("stopping ledger cleaning")
+			return ctx.Err()
+		case <-ticker.C:
+			pruned, err := c.pruner.PruneProcessedEvents(ctx, c.retention)
+			if err != nil {
+				log.Error("failed to prune processed events", slog.String("error", err.Error()))
+				continue
+			}
+			if pruned > 0 {
+				log.Info("processed events pruned", slog.Int64("count", pruned))
+			}
+		}
+	}
+}
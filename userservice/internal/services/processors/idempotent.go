@@ -0,0 +1,55 @@
+package processors
+
+import (
+	"context"
+	"errors"
+	"events"
+	"log/slog"
+	"userservice/internal/storage"
+)
+
+type EventLedger interface {
+	ProcessEventOnce(ctx context.Context, eventID string, process func(ctx context.Context) error) error
+}
+
+// IdempotentProcessor makes redelivered events no-ops. The wrapped
+// processor runs in the transaction that records the event id, so its
+// storage writes and the ledger entry commit or roll back together.
+type IdempotentProcessor struct {
+	log    *slog.Logger
+	ledger EventLedger
+	next   Processor
+}
+
+func NewIdempotentProcessor(log *slog.Logger, ledger EventLedger, next Processor) *IdempotentProcessor {
+	return &IdempotentProcessor{
+		log:    log,
+		ledger: ledger,
+		next:   next,
+	}
+}
+
+func (p *IdempotentProcessor) ProcessEvent(ctx context.Context, event events.Envelope) error {
+	const op = "processors.IdempotentProcessor.ProcessEvent"
+
+	log := p.log.With(
+		slog.String("op", op),
+		slog.String("event_type", event.Type),
+		slog.String("event_id", event.ID),
+	)
+
+	// Messages written before the envelope have no id to deduplicate by.
+	if event.ID == "" {
+		return p.next.ProcessEvent(ctx, event)
+	}
+
+	err := p.ledger.ProcessEventOnce(ctx, event.ID, func(ctx context.Context) error {
+		return p.next.ProcessEvent(ctx, event)
+	})
+	if errors.Is(err, storage.ErrEventProcessed) {
+		log.Info("skipping already processed event")
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,54 @@
+package processors
+
+import (
+	"context"
+	"events"
+	"fmt"
+	"log/slog"
+	"userservice/internal/storage"
+)
+
+// MembershipProcessor keeps organization memberships in sync with sso.
+type MembershipProcessor struct {
+	log     *slog.Logger
+	storage storage.Storage
+}
+
+func NewMembershipProcessor(log *slog.Logger, storage storage.Storage) *MembershipProcessor {
+	return &MembershipProcessor{
+		log:     log,
+		storage: storage,
+	}
+}
+
+func (p *MembershipProcessor) ProcessEvent(ctx context.Context, event events.Envelope) error {
+	const op = "processors.MembershipProcessor.ProcessEvent"
+
+	log := p.log.With(
+		slog.String("op", op),
+		slog.String("event_type", event.Type),
+		slog.String("event_id", event.ID),
+		slog.String("correlation_id", event.CorrelationID),
+	)
+
+	if event.Type != events.TypeOrgMemberAdded {
+		return fmt.Errorf("%w: %s", ErrUnexpectedType, event.Type)
+	}
+
+	var payload events.OrgMemberAdded
+	if err := event.Decode(&payload); err != nil {
+		log.Error("failed to parse member payload", slog.String("error", err.Error()))
+		return fmt.Errorf("parse error %w", err)
+	}
+
+	err := p.storage.AddMembership(ctx, payload.OrgID, payload.UserID)
+	if err != nil {
+		log.Error("failed to add membership",
+			slog.Int64("org_id", payload.OrgID),
+			slog.Int64("user_id", payload.UserID),
+			slog.String("error", err.Error()),
+		)
+		return fmt.Errorf("add membership: %w", err)
+	}
+	return nil
+}
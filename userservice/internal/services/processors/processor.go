@@ -2,8 +2,14 @@ package processors
 
 import (
 	"context"
+	"errors"
+	"events"
 )
 
+// ErrUnexpectedType is returned by a processor given an event of a type
+// it wasn't registered for.
+var ErrUnexpectedType = errors.New("unexpected event type")
+
 type Processor interface {
-	ProcessEvent(ctx context.Context, payload []byte) error
+	ProcessEvent(ctx context.Context, event events.Envelope) error
 }
@@ -0,0 +1,65 @@
+package processors
+
+import (
+	"context"
+	"events"
+	"log/slog"
+	"userservice/internal/lib/bus"
+)
+
+// Registry routes every event to the processor registered for its type.
+// Events of types nobody registered go to the fallback.
+type Registry struct {
+	log        *slog.Logger
+	processors map[string]Processor
+	fallback   Processor
+}
+
+func NewRegistry(log *slog.Logger, fallback Processor) *Registry {
+	return &Registry{
+		log:        log,
+		processors: make(map[string]Processor),
+		fallback:   fallback,
+	}
+}
+
+// Register routes events of the given types to processor, replacing the
+// processor registered for them before.
+func (r *Registry) Register(processor Processor, eventTypes ...string) {
+	for _, eventType := range eventTypes {
+		r.processors[eventType] = processor
+	}
+}
+
+func (r *Registry) ProcessEvent(ctx context.Context, event events.Envelope) error {
+	const op = "processors.Registry.ProcessEvent"
+
+	processor, ok := r.processors[event.Type]
+	if !ok {
+		r.log.With(slog.String("op", op)).
+			Debug("no processor for event type, using fallback", slog.String("event_type", event.Type))
+		processor = r.fallback
+	}
+
+	return processor.ProcessEvent(ctx, event)
+}
+
+// SkipProcessor is a fallback that logs and drops events.
+type SkipProcessor struct {
+	log *slog.Logger
+}
+
+func NewSkipProcessor(log *slog.Logger) *SkipProcessor {
+	return &SkipProcessor{log: log}
+}
+
+func (p *SkipProcessor) ProcessEvent(ctx context.Context, event events.Envelope) error {
+	const op = "processors.SkipProcessor.ProcessEvent"
+
+	p.log.With(slog.String("op", op)).Warn("skipping event of unknown type",
+		slog.String("event_type", event.Type),
+		slog.String("event_id", event.ID),
+		slog.String("source", bus.Headers(ctx)[events.HeaderSource]),
+	)
+	return nil
+}
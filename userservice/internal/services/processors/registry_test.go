@@ -0,0 +1,54 @@
+package processors
+
+import (
+	"context"
+	"errors"
+	"events"
+	"testing"
+)
+
+type recordingProcessor struct {
+	types []string
+	err   error
+}
+
+func (p *recordingProcessor) ProcessEvent(_ context.Context, event events.Envelope) error {
+	p.types = append(p.types, event.Type)
+	return p.err
+}
+
+func TestRegistry_RoutesByType(t *testing.T) {
+	users := &recordingProcessor{}
+	deletions := &recordingProcessor{err: errors.New("boom")}
+	fallback := &recordingProcessor{}
+
+	registry := NewRegistry(discard, fallback)
+	registry.Register(users, events.TypeUserCreated, events.TypeUserInvited)
+	registry.Register(deletions, "UserDeleted")
+
+	for _, eventType := range []string{events.TypeUserCreated, "UserDeleted", "Unknown", events.TypeUserInvited} {
+		err := registry.ProcessEvent(context.Background(), events.Envelope{Type: eventType})
+		if eventType == "UserDeleted" && err == nil {
+			t.Errorf("ProcessEvent(%s) error = nil, want processor error", eventType)
+		}
+	}
+
+	if len(users.types) != 2 || users.types[0] != events.TypeUserCreated || users.types[1] != events.TypeUserInvited {
+		t.Errorf("user processor got %v", users.types)
+	}
+	if len(deletions.types) != 1 {
+		t.Errorf("deletion processor got %v", deletions.types)
+	}
+	if len(fallback.types) != 1 || fallback.types[0] != "Unknown" {
+		t.Errorf("fallback got %v", fallback.types)
+	}
+}
+
+func TestUserProcessor_RejectsUnexpectedType(t *testing.T) {
+	processor := NewUserProcessor(discard, &memoryStorage{})
+
+	err := processor.ProcessEvent(context.Background(), events.Envelope{Type: events.TypeOrgMemberAdded})
+	if !errors.Is(err, ErrUnexpectedType) {
+		t.Errorf("ProcessEvent() error = %v, want %v", err, ErrUnexpectedType)
+	}
+}
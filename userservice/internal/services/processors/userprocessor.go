@@ -2,8 +2,8 @@ package processors
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"events"
 	"fmt"
 	"log/slog"
 	"userservice/internal/domain/models"
@@ -15,11 +15,8 @@ const (
 	noSurname = "no surname"
 )
 
-type UserPayload struct {
-	UserID int64  `json:"id"`
-	Email  string `json:"email"`
-}
-
+// UserProcessor creates user profiles for UserCreated, UserInvited and
+// UserSnapshot.
 type UserProcessor struct {
 	log     *slog.Logger
 	storage storage.Storage
@@ -33,43 +30,72 @@ func NewUserProcessor(log *slog.Logger, storage storage.Storage) *UserProcessor
 	}
 }
 
-func (p *UserProcessor) ProcessEvent(ctx context.Context, payload []byte) error {
+func (p *UserProcessor) ProcessEvent(ctx context.Context, event events.Envelope) error {
 	const op = "userprocessor.UserProcessor.ProcessEvent"
 
-	log := p.log.With(slog.String("op", op))
+	log := p.log.With(
+		slog.String("op", op),
+		slog.String("event_type", event.Type),
+		slog.String("event_id", event.ID),
+		slog.String("correlation_id", event.CorrelationID),
+	)
 
-	userPayload, err := parseUserPayload(payload)
-	if err != nil {
-		log.Error("failed to parse user payload", slog.String("error", err.Error()))
-		return fmt.Errorf("parse error %w", err)
+	switch event.Type {
+	case events.TypeUserCreated:
+		var payload events.UserCreated
+		if err := event.Decode(&payload); err != nil {
+			log.Error("failed to parse user payload", slog.String("error", err.Error()))
+			return fmt.Errorf("parse error %w", err)
+		}
+		return p.createUser(ctx, payload.UserID, "", "")
+	case events.TypeUserInvited:
+		var payload events.UserInvited
+		if err := event.Decode(&payload); err != nil {
+			log.Error("failed to parse user payload", slog.String("error", err.Error()))
+			return fmt.Errorf("parse error %w", err)
+		}
+		return p.createUser(ctx, payload.UserID, payload.Name, payload.Surname)
+	case events.TypeUserSnapshot:
+		var payload events.UserSnapshot
+		if err := event.Decode(&payload); err != nil {
+			log.Error("failed to parse user payload", slog.String("error", err.Error()))
+			return fmt.Errorf("parse error %w", err)
+		}
+		// Snapshots carry no names, so a profile rebuilt from one gets
+		// the placeholders; an existing profile keeps its own.
+		return p.createUser(ctx, payload.UserID, "", "")
+	default:
+		return fmt.Errorf("%w: %s", ErrUnexpectedType, event.Type)
 	}
+}
+
+// createUser creates the profile of a new user. Empty name and surname
+// are replaced with placeholders.
+func (p *UserProcessor) createUser(ctx context.Context, userID int64, name string, surname string) error {
+	const op = "userprocessor.UserProcessor.createUser"
+
+	log := p.log.With(slog.String("op", op))
 
 	user := &models.User{
-		ID:      userPayload.UserID,
+		ID:      userID,
 		Name:    noName,
 		Surname: noSurname,
 		Avatar:  []byte{},
 	}
-	_, err = p.storage.CreateUser(ctx, user)
+	if name != "" {
+		user.Name = name
+	}
+	if surname != "" {
+		user.Surname = surname
+	}
+	_, err := p.storage.CreateUser(ctx, user)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserAlreadyExists) {
-			log.Info("user already exists, skipping creation", slog.Int64("user_id", userPayload.UserID))
+			log.Info("user already exists, skipping creation", slog.Int64("user_id", userID))
 			return nil
 		}
-		log.Error("failed to create user", slog.Int64("user_id", userPayload.UserID), slog.String("error", err.Error()))
+		log.Error("failed to create user", slog.Int64("user_id", userID), slog.String("error", err.Error()))
 		return fmt.Errorf("create user: %w", err)
 	}
 	return nil
 }
-
-func parseUserPayload(payload []byte) (*UserPayload, error) {
-	var event *UserPayload = &UserPayload{}
-
-	err := json.Unmarshal([]byte(payload), event)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return event, nil
-}
@@ -0,0 +1,138 @@
+package processors
+
+import (
+	"context"
+	"encoding/json"
+	"events"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"userservice/internal/domain/models"
+	"userservice/internal/storage"
+)
+
+type memoryStorage struct {
+	storage.Storage
+	users       map[int64]*models.User
+	memberships map[int64][]int64
+}
+
+func (s *memoryStorage) CreateUser(_ context.Context, user *models.User) (*models.User, error) {
+	if _, ok := s.users[user.ID]; ok {
+		return nil, storage.ErrUserAlreadyExists
+	}
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *memoryStorage) AddMembership(_ context.Context, orgID int64, userID int64) error {
+	s.memberships[userID] = append(s.memberships[userID], orgID)
+	return nil
+}
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// newTestRegistry wires the processors the way userservice does.
+func newTestRegistry(s storage.Storage, fallback Processor) *Registry {
+	registry := NewRegistry(discard, fallback)
+	registry.Register(NewUserProcessor(discard, s),
+		events.TypeUserCreated, events.TypeUserInvited, events.TypeUserSnapshot)
+	registry.Register(NewMembershipProcessor(discard, s), events.TypeOrgMemberAdded)
+	return registry
+}
+
+// contract reads the payload sso promises for the current version of
+// eventType.
+func contract(t *testing.T, eventType string) []byte {
+	t.Helper()
+
+	version, ok := events.Version(eventType)
+	if !ok {
+		t.Fatalf("unknown event type %s", eventType)
+	}
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "events", "testdata",
+		fmt.Sprintf("%s.v%d.json", eventType, version)))
+	if err != nil {
+		t.Fatalf("read contract: %v", err)
+	}
+	return data
+}
+
+// TestProcessors_Contracts feeds the consumer the payloads of the event
+// contracts, both bare as sso wrote them before the envelope and wrapped.
+func TestProcessors_Contracts(t *testing.T) {
+	tests := []struct {
+		eventType string
+		check     func(t *testing.T, s *memoryStorage)
+	}{
+		{
+			eventType: events.TypeUserCreated,
+			check: func(t *testing.T, s *memoryStorage) {
+				if user, ok := s.users[1]; !ok || user.Name != noName {
+					t.Errorf("user not created from contract: %+v", user)
+				}
+			},
+		},
+		{
+			eventType: events.TypeUserInvited,
+			check: func(t *testing.T, s *memoryStorage) {
+				if user, ok := s.users[1]; !ok || user.Name != "Ivan" || user.Surname != "Ivanov" {
+					t.Errorf("invited user not created from contract: %+v", user)
+				}
+			},
+		},
+		{
+			eventType: events.TypeUserSnapshot,
+			check: func(t *testing.T, s *memoryStorage) {
+				if user, ok := s.users[42]; !ok || user.Name != noName {
+					t.Errorf("user not created from snapshot contract: %+v", user)
+				}
+			},
+		},
+		{
+			eventType: events.TypeOrgMemberAdded,
+			check: func(t *testing.T, s *memoryStorage) {
+				if len(s.memberships[1]) != 1 || s.memberships[1][0] != 1 {
+					t.Errorf("membership not added from contract: %v", s.memberships)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		payload := contract(t, tt.eventType)
+
+		version, _ := events.Version(tt.eventType)
+		wrapped, err := json.Marshal(events.Envelope{
+			ID:       "event-1",
+			Type:     tt.eventType,
+			Version:  version,
+			Producer: "sso",
+			Payload:  payload,
+		})
+		if err != nil {
+			t.Fatalf("marshal envelope: %v", err)
+		}
+
+		for name, value := range map[string][]byte{"legacy": payload, "envelope": wrapped} {
+			t.Run(tt.eventType+"/"+name, func(t *testing.T) {
+				s := &memoryStorage{users: map[int64]*models.User{}, memberships: map[int64][]int64{}}
+				processor := newTestRegistry(s, NewSkipProcessor(discard))
+
+				event, err := events.Parse(tt.eventType, value)
+				if err != nil {
+					t.Fatalf("parse event: %v", err)
+				}
+				if err := processor.ProcessEvent(context.Background(), event); err != nil {
+					t.Fatalf("ProcessEvent() error = %v", err)
+				}
+
+				tt.check(t, s)
+			})
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package profilerebuilder
+
+import (
+	"context"
+	"events"
+	"fmt"
+	"log/slog"
+	"maps"
+	"userservice/internal/lib/bus"
+)
+
+type EventConsumer interface {
+	FetchMessage(ctx context.Context) (bus.Message, error)
+	HighWaterMarks() map[int]int64
+}
+
+type EventProcessor interface {
+	ProcessEvent(ctx context.Context, event events.Envelope) error
+}
+
+// Rebuilder applies replayed events to the profiles. It bypasses the
+// processed events ledger, so events already applied are applied again;
+// processors must tolerate that, as creating an existing user does.
+type Rebuilder struct {
+	log       *slog.Logger
+	consumer  EventConsumer
+	processor EventProcessor
+}
+
+func New(log *slog.Logger, consumer EventConsumer, processor EventProcessor) *Rebuilder {
+	return &Rebuilder{
+		log:       log,
+		consumer:  consumer,
+		processor: processor,
+	}
+}
+
+// Rebuild applies the events that were in the topic when the consumer
+// was created, up to its high-water mark in every partition, and returns
+// how many were applied and how many skipped as malformed or not
+// understood. Later events are left alone. It stops at the first other
+// failure; being idempotent, it can be rerun.
+func (r *Rebuilder) Rebuild(ctx context.Context) (applied int, skipped int, err error) {
+	const op = "profilerebuilder.Rebuilder.Rebuild"
+
+	log := r.log.With(slog.String("op", op))
+
+	marks := r.consumer.HighWaterMarks()
+	remaining := maps.Clone(marks)
+
+	for len(remaining) > 0 {
+		msg, err := r.consumer.FetchMessage(ctx)
+		if err != nil {
+			return applied, skipped, fmt.Errorf("%s: fetch event: %w", op, err)
+		}
+
+		mark, ok := marks[msg.Partition]
+		if !ok || msg.Offset >= mark {
+			continue
+		}
+		if msg.Offset+1 >= mark {
+			delete(remaining, msg.Partition)
+		}
+
+		err = r.apply(ctx, msg)
+		if err != nil {
+			if !events.IsPermanent(err) {
+				return applied, skipped, fmt.Errorf("%s: apply event at %d/%d: %w", op, msg.Partition, msg.Offset, err)
+			}
+			log.Warn("skipping event",
+				slog.Int("partition", msg.Partition),
+				slog.Int64("offset", msg.Offset),
+				slog.String("error", err.Error()))
+			skipped++
+			continue
+		}
+		applied++
+	}
+
+	return applied, skipped, nil
+}
+
+func (r *Rebuilder) apply(ctx context.Context, msg bus.Message) error {
+	event, err := bus.DecodeEvent(msg)
+	if err != nil {
+		return err
+	}
+
+	return r.processor.ProcessEvent(bus.WithHeaders(ctx, msg.Headers), event)
+}
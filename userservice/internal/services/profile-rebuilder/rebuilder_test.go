@@ -0,0 +1,81 @@
+package profilerebuilder_test
+
+import (
+	"context"
+	"events"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+	"userservice/internal/lib/bus"
+	"userservice/internal/lib/membus"
+	profilerebuilder "userservice/internal/services/profile-rebuilder"
+)
+
+type recordingProcessor struct {
+	types []string
+}
+
+func (p *recordingProcessor) ProcessEvent(_ context.Context, event events.Envelope) error {
+	p.types = append(p.types, event.Type)
+	return nil
+}
+
+// replay is the memory bus replayed up to fixed high-water marks.
+type replay struct {
+	*membus.Subscriber
+	marks map[int]int64
+}
+
+func (r replay) HighWaterMarks() map[int]int64 {
+	return r.marks
+}
+
+func TestRebuilder_AppliesReplayedEvents(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	memory := membus.New()
+
+	err := memory.Publisher("events").Publish(ctx,
+		bus.Message{Key: []byte(events.TypeUserCreated), Value: []byte(`{"id": 1, "email": "a@example.com"}`)},
+		bus.Message{Key: []byte(events.TypeUserCreated), Value: []byte(`{"id": `)},
+		bus.Message{Key: []byte(events.TypeUserSnapshot), Value: []byte(`{"id": 2, "email": "b@example.com", "status": "active"}`)},
+		// Written after the rebuild started.
+		bus.Message{Key: []byte(events.TypeUserCreated), Value: []byte(`{"id": 3, "email": "c@example.com"}`)},
+	)
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	processor := &recordingProcessor{}
+	consumer := replay{Subscriber: memory.Subscriber("events"), marks: map[int]int64{0: 3}}
+	rebuilder := profilerebuilder.New(log, consumer, processor)
+
+	applied, skipped, err := rebuilder.Rebuild(ctx)
+	if err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+	if applied != 2 || skipped != 1 {
+		t.Errorf("Rebuild() = %d applied, %d skipped, want 2 and 1", applied, skipped)
+	}
+	if len(processor.types) != 2 ||
+		processor.types[0] != events.TypeUserCreated || processor.types[1] != events.TypeUserSnapshot {
+		t.Errorf("processed %v, want UserCreated then UserSnapshot", processor.types)
+	}
+}
+
+func TestRebuilder_NothingToReplay(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	consumer := replay{Subscriber: membus.New().Subscriber("events"), marks: map[int]int64{}}
+	applied, skipped, err := profilerebuilder.New(log, consumer, &recordingProcessor{}).Rebuild(ctx)
+	if err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+	if applied != 0 || skipped != 0 {
+		t.Errorf("Rebuild() = %d applied, %d skipped, want none", applied, skipped)
+	}
+}
@@ -26,6 +26,7 @@ type UserService struct {
 
 type UserProvider interface {
 	GetUserByID(ctx context.Context, userID int64) (*models.User, error)
+	GetOrgUserByID(ctx context.Context, orgID int64, userID int64) (*models.User, error)
 }
 
 type UserUpdater interface {
@@ -60,6 +61,27 @@ func (us *UserService) GetUser(ctx context.Context, userID int64) (*models.User,
 	return user, nil
 }
 
+// GetOrgUser returns the user only if it is a member of the organization.
+func (us *UserService) GetOrgUser(ctx context.Context, orgID int64, userID int64) (*models.User, error) {
+	const op = "userservice.GetOrgUser"
+
+	log := us.log.With(slog.String("op", op), slog.Int64("org_id", orgID), slog.Int64("user_id", userID))
+	log.Debug("getting organization user by id")
+
+	user, err := us.userProvider.GetOrgUserByID(ctx, orgID, userID)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", slog.String("error", err.Error()))
+			return nil, fmt.Errorf("%s: error user not found: %w", op, ErrUserNotFound)
+		}
+		log.Error("error get user", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: error get user: %w", op, err)
+	}
+
+	return user, nil
+}
+
 func (us *UserService) UpdateUser(ctx context.Context, user *models.User) (*models.User, error) {
 	const op = "userservice.UpdateUser"
 	log := us.log.With(slog.String("op", op), slog.Int64("user_id", user.ID))
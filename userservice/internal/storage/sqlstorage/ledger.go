@@ -0,0 +1,107 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"userservice/internal/storage"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type txKey struct{}
+
+// preparer is what storage methods need from *sql.DB and *sql.Tx.
+type preparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// conn returns the transaction ProcessEventOnce put into ctx, so the side
+// effects of an event commit together with its ledger entry, or the
+// database otherwise.
+func (s *SQLStorage) conn(ctx context.Context) preparer {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return s.db
+}
+
+// ProcessEventOnce records eventID in the processed events ledger and
+// calls process in the same transaction. Storage calls made by process
+// with the context it is given join the transaction. If eventID was
+// already recorded, process isn't called and ErrEventProcessed is returned.
+func (s *SQLStorage) ProcessEventOnce(
+	ctx context.Context,
+	eventID string,
+	process func(ctx context.Context) error,
+) (err error) {
+	const op = "sqlstorage.ProcessEventOnce"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("%s: commit tx: %w", op, commitErr)
+		}
+	}()
+
+	query, args, err := sq.Insert("processed_events").
+		Columns("event_id").
+		Values(eventID).
+		Suffix("ON CONFLICT (event_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if inserted == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrEventProcessed)
+	}
+
+	if err := process(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PruneProcessedEvents removes ledger entries older than olderThan and
+// returns how many were removed.
+func (s *SQLStorage) PruneProcessedEvents(ctx context.Context, olderThan time.Duration) (int64, error) {
+	const op = "sqlstorage.PruneProcessedEvents"
+
+	query, args, err := sq.Delete("processed_events").
+		Where(sq.Expr("processed_at < datetime('now', ?)", fmt.Sprintf("-%d seconds", int64(olderThan.Seconds())))).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pruned, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,112 @@
+package sqlstorage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+	"userservice/internal/domain/models"
+	"userservice/internal/storage"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+func newTestStorage(t *testing.T) *SQLStorage {
+	t.Helper()
+
+	storagePath := filepath.Join(t.TempDir(), "userservice.db")
+	m, err := migrate.New("file://../../../migrations", "sqlite3://"+storagePath)
+	if err != nil {
+		t.Fatalf("create migrator: %v", err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+	if srcErr, dbErr := m.Close(); srcErr != nil || dbErr != nil {
+		t.Fatalf("close migrator: %v, %v", srcErr, dbErr)
+	}
+
+	s, err := New("sqlite3", storagePath)
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	t.Cleanup(func() { _ = s.db.Close() })
+
+	return s
+}
+
+func TestProcessEventOnce(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	calls := 0
+	addMembership := func(ctx context.Context) error {
+		calls++
+		return s.AddMembership(ctx, 1, 42)
+	}
+
+	if err := s.ProcessEventOnce(ctx, "event-1", addMembership); err != nil {
+		t.Fatalf("ProcessEventOnce() error = %v", err)
+	}
+	if err := s.ProcessEventOnce(ctx, "event-1", addMembership); !errors.Is(err, storage.ErrEventProcessed) {
+		t.Fatalf("ProcessEventOnce() error = %v, want %v", err, storage.ErrEventProcessed)
+	}
+	if calls != 1 {
+		t.Errorf("process called %d times, want 1", calls)
+	}
+}
+
+func TestProcessEventOnce_RollsBackWithSideEffect(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	failure := errors.New("boom")
+	err := s.ProcessEventOnce(ctx, "event-1", func(ctx context.Context) error {
+		if _, err := s.CreateUser(ctx, &models.User{ID: 42, Name: "n", Surname: "s"}); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("ProcessEventOnce() error = %v, want %v", err, failure)
+	}
+
+	if _, err := s.GetUserByID(ctx, 42); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("user of the failed event was kept: %v", err)
+	}
+
+	// The event wasn't recorded, so its redelivery is processed.
+	if err := s.ProcessEventOnce(ctx, "event-1", func(context.Context) error { return nil }); err != nil {
+		t.Errorf("redelivered event not processed: %v", err)
+	}
+}
+
+func TestPruneProcessedEvents(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	noop := func(context.Context) error { return nil }
+	for _, eventID := range []string{"old", "new"} {
+		if err := s.ProcessEventOnce(ctx, eventID, noop); err != nil {
+			t.Fatalf("ProcessEventOnce() error = %v", err)
+		}
+	}
+	if _, err := s.db.Exec(`UPDATE processed_events SET processed_at = datetime('now', '-2 days') WHERE event_id = 'old'`); err != nil {
+		t.Fatalf("age event: %v", err)
+	}
+
+	pruned, err := s.PruneProcessedEvents(ctx, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("PruneProcessedEvents() error = %v", err)
+	}
+	if pruned != 1 {
+		t.Errorf("pruned %d events, want 1", pruned)
+	}
+
+	if err := s.ProcessEventOnce(ctx, "new", noop); !errors.Is(err, storage.ErrEventProcessed) {
+		t.Errorf("recent event was pruned: %v", err)
+	}
+}
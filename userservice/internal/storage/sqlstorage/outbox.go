@@ -0,0 +1,256 @@
+package sqlstorage
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"events"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+	"userservice/internal/domain/models"
+	"userservice/internal/storage"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// producer is the name userservice signs its events with.
+const producer = "userservice"
+
+// inTx calls fn with a context carrying a transaction, which storage
+// methods given that context join. If ctx already carries one, fn joins
+// it and the caller commits.
+func (s *SQLStorage) inTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("commit tx: %w", commitErr)
+		}
+	}()
+
+	return fn(context.WithValue(ctx, txKey{}, tx))
+}
+
+// saveEvent writes an event to the outbox. Call it with the context of
+// the transaction making the change the event describes; the trace context
+// of ctx is kept for publishing.
+func (s *SQLStorage) saveEvent(ctx context.Context, eventType string, payload any) error {
+	const op = "sqlstorage.saveEvent"
+
+	envelope, err := events.New(ctx, eventType, producer, payload)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("%s: marshal envelope: %w", op, err)
+	}
+
+	var partitionKey string
+	if keyed, ok := payload.(events.Keyed); ok {
+		partitionKey = keyed.PartitionKey()
+	}
+
+	query, args, err := sq.Insert("outbox").
+		Columns("event_type", "payload", "partition_key", "trace_parent").
+		Values(eventType, string(data), partitionKey, events.TraceParent(ctx)).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	stmt, err := s.conn(ctx).PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// profileUpdate returns the UserProfileUpdated event of a change from
+// before to after, or false if no profile field changed.
+func profileUpdate(before *models.User, after *models.User) (events.UserProfileUpdated, bool) {
+	event := events.UserProfileUpdated{
+		UserID:     after.ID,
+		Name:       after.Name,
+		Surname:    after.Surname,
+		AvatarHash: avatarHash(after.Avatar),
+	}
+
+	if before.Name != after.Name {
+		event.Changed = append(event.Changed, events.FieldName)
+	}
+	if before.Surname != after.Surname {
+		event.Changed = append(event.Changed, events.FieldSurname)
+	}
+	if avatarHash(before.Avatar) != event.AvatarHash {
+		event.Changed = append(event.Changed, events.FieldAvatar)
+	}
+
+	return event, len(event.Changed) > 0
+}
+
+// avatarHash returns the hex SHA-256 of avatar, so events don't carry the
+// image itself.
+func avatarHash(avatar []byte) string {
+	if len(avatar) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(avatar)
+	return hex.EncodeToString(sum[:])
+}
+
+// ClaimEvents leases up to limit due outbox events to relayID, oldest
+// first. Events leased to another relay are skipped until the lease
+// expires, so each event is published by one relay at a time. The claim
+// is a single statement, which SQLite runs atomically.
+func (s *SQLStorage) ClaimEvents(
+	ctx context.Context,
+	relayID string,
+	limit int,
+	lease time.Duration,
+) ([]models.Event, error) {
+	const op = "sqlstorage.ClaimEvents"
+
+	due, dueArgs, err := sq.Select("id").
+		From("outbox").
+		Where(sq.Eq{"status": "new"}).
+		Where("next_attempt_at <= CURRENT_TIMESTAMP").
+		Where("(lease_until IS NULL OR lease_until <= CURRENT_TIMESTAMP)").
+		OrderBy("id").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	query, args, err := sq.Update("outbox").
+		Set("claimed_by", relayID).
+		Set("lease_until", sq.Expr("datetime('now', ?)", sqliteDelay(lease))).
+		Where("id IN ("+due+")", dueArgs...).
+		Suffix("RETURNING id, event_type, payload, partition_key, trace_parent, attempts").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	claimed := make([]models.Event, 0, limit)
+	for rows.Next() {
+		var event models.Event
+		if err := rows.Scan(
+			&event.ID,
+			&event.Type,
+			&event.Payload,
+			&event.PartitionKey,
+			&event.TraceParent,
+			&event.Attempts,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		claimed = append(claimed, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(claimed) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrNoNewEvents)
+	}
+
+	// RETURNING doesn't keep the order of the subquery.
+	slices.SortFunc(claimed, func(a, b models.Event) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return claimed, nil
+}
+
+// MarkEventsAsSent marks a published batch claimed by relayID as sent.
+func (s *SQLStorage) MarkEventsAsSent(ctx context.Context, relayID string, eventIDs []int64) error {
+	const op = "sqlstorage.MarkEventsAsSent"
+
+	query, args, err := releaseLease(sq.Update("outbox")).
+		Set("status", "sent").
+		Set("sent_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"id": eventIDs, "claimed_by": relayID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ScheduleEventRetries records failed publish attempts of events claimed
+// by relayID and postpones each event by its RetryIn.
+func (s *SQLStorage) ScheduleEventRetries(ctx context.Context, relayID string, failures []models.EventFailure) error {
+	const op = "sqlstorage.ScheduleEventRetries"
+
+	return s.inTx(ctx, func(ctx context.Context) error {
+		for _, failure := range failures {
+			query, args, err := releaseLease(sq.Update("outbox")).
+				Set("attempts", sq.Expr("attempts + 1")).
+				Set("last_error", failure.Error).
+				Set("next_attempt_at", sq.Expr("datetime('now', ?)", sqliteDelay(failure.RetryIn))).
+				Where(sq.Eq{"id": failure.EventID, "claimed_by": relayID}).
+				ToSql()
+			if err != nil {
+				return fmt.Errorf("%s: build query: %w", op, err)
+			}
+
+			stmt, err := s.conn(ctx).PrepareContext(ctx, query)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			_, err = stmt.ExecContext(ctx, args...)
+			stmt.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// releaseLease clears the lease of the events an update touches.
+func releaseLease(update sq.UpdateBuilder) sq.UpdateBuilder {
+	return update.Set("claimed_by", nil).Set("lease_until", nil)
+}
+
+// sqliteDelay formats d as a datetime() modifier, rounding up to whole
+// seconds.
+func sqliteDelay(d time.Duration) string {
+	return fmt.Sprintf("+%d seconds", int64(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,139 @@
+package sqlstorage
+
+import (
+	"context"
+	"errors"
+	"events"
+	"slices"
+	"testing"
+	"time"
+	"userservice/internal/domain/models"
+	"userservice/internal/storage"
+)
+
+func TestUpdateUser_WritesProfileUpdated(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateUser(ctx, &models.User{ID: 42, Name: "Ivan", Surname: "Ivanov"}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	avatar := []byte("avatar")
+	if _, err := s.UpdateUser(ctx, &models.User{ID: 42, Name: "Petr", Surname: "Ivanov", Avatar: avatar}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	// Saving the same profile again changes nothing and writes no event.
+	if _, err := s.UpdateUser(ctx, &models.User{ID: 42, Name: "Petr", Surname: "Ivanov", Avatar: avatar}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+
+	pending, err := s.ClaimEvents(ctx, "test", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimEvents() error = %v", err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("outbox holds %d events, want 1", len(pending))
+	}
+	if pending[0].Type != events.TypeUserProfileUpdated || pending[0].PartitionKey != "42" {
+		t.Errorf("event = %+v, want UserProfileUpdated keyed by user 42", pending[0])
+	}
+
+	envelope, err := events.Parse(pending[0].Type, []byte(pending[0].Payload))
+	if err != nil {
+		t.Fatalf("parse event: %v", err)
+	}
+	var payload events.UserProfileUpdated
+	if err := envelope.Decode(&payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if envelope.Producer != "userservice" {
+		t.Errorf("producer = %q, want userservice", envelope.Producer)
+	}
+	if !slices.Equal(payload.Changed, []string{events.FieldName, events.FieldAvatar}) {
+		t.Errorf("changed = %v, want name and avatar", payload.Changed)
+	}
+	if payload.Name != "Petr" || payload.AvatarHash != avatarHash(avatar) || payload.AvatarHash == "" {
+		t.Errorf("payload = %+v", payload)
+	}
+
+	if err := s.MarkEventsAsSent(ctx, "test", []int64{pending[0].ID}); err != nil {
+		t.Fatalf("MarkEventsAsSent() error = %v", err)
+	}
+	if _, err := s.ClaimEvents(ctx, "test", 10, time.Minute); !errors.Is(err, storage.ErrNoNewEvents) {
+		t.Errorf("ClaimEvents() error = %v, want %v", err, storage.ErrNoNewEvents)
+	}
+}
+
+func TestUpdateUser_NotFoundWritesNoEvent(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	if _, err := s.UpdateUser(ctx, &models.User{ID: 42, Name: "n", Surname: "s"}); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Fatalf("UpdateUser() error = %v, want %v", err, storage.ErrUserNotFound)
+	}
+	if _, err := s.ClaimEvents(ctx, "test", 10, time.Minute); !errors.Is(err, storage.ErrNoNewEvents) {
+		t.Errorf("ClaimEvents() error = %v, want %v", err, storage.ErrNoNewEvents)
+	}
+}
+
+func TestScheduleEventRetries_PostponesEvent(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateUser(ctx, &models.User{ID: 42, Name: "Ivan", Surname: "Ivanov"}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if _, err := s.UpdateUser(ctx, &models.User{ID: 42, Name: "Petr", Surname: "Ivanov"}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	pending, err := s.ClaimEvents(ctx, "test", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimEvents() error = %v", err)
+	}
+
+	failures := []models.EventFailure{{EventID: pending[0].ID, Error: "broker down", RetryIn: time.Minute}}
+	if err := s.ScheduleEventRetries(ctx, "test", failures); err != nil {
+		t.Fatalf("ScheduleEventRetries() error = %v", err)
+	}
+	if _, err := s.ClaimEvents(ctx, "test", 10, time.Minute); !errors.Is(err, storage.ErrNoNewEvents) {
+		t.Errorf("postponed event is due: %v", err)
+	}
+}
+
+func TestClaimEvents_Leases(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateUser(ctx, &models.User{ID: 42, Name: "Ivan", Surname: "Ivanov"}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if _, err := s.UpdateUser(ctx, &models.User{ID: 42, Name: "Petr", Surname: "Ivanov"}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+
+	claimed, err := s.ClaimEvents(ctx, "relay-a", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimEvents() error = %v", err)
+	}
+	if _, err := s.ClaimEvents(ctx, "relay-b", 10, time.Minute); !errors.Is(err, storage.ErrNoNewEvents) {
+		t.Fatalf("ClaimEvents() of leased events error = %v, want %v", err, storage.ErrNoNewEvents)
+	}
+
+	// Another relay can't mark the events it doesn't hold.
+	if err := s.MarkEventsAsSent(ctx, "relay-b", []int64{claimed[0].ID}); err != nil {
+		t.Fatalf("MarkEventsAsSent() error = %v", err)
+	}
+
+	failures := []models.EventFailure{{EventID: claimed[0].ID, Error: "broker down"}}
+	if err := s.ScheduleEventRetries(ctx, "relay-a", failures); err != nil {
+		t.Fatalf("ScheduleEventRetries() error = %v", err)
+	}
+	retried, err := s.ClaimEvents(ctx, "relay-b", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimEvents() after the lease was released error = %v", err)
+	}
+	if len(retried) != 1 || retried[0].ID != claimed[0].ID || retried[0].Attempts != 1 {
+		t.Errorf("ClaimEvents() = %+v, want event %d with one attempt", retried, claimed[0].ID)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"events"
 	"fmt"
 	"log/slog"
 	"userservice/internal/domain/models"
@@ -44,7 +45,7 @@ func (s *SQLStorage) GetUserByID(ctx context.Context, userId int64) (*models.Use
 		return nil, fmt.Errorf("%s: build query: %w", op, err)
 	}
 
-	stmt, err := s.db.PrepareContext(ctx, query)
+	stmt, err := s.conn(ctx).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -61,55 +62,128 @@ func (s *SQLStorage) GetUserByID(ctx context.Context, userId int64) (*models.Use
 	return &u, nil
 }
 
+// UpdateUser updates the profile and, if a field changed, writes a
+// UserProfileUpdated event to the outbox in the same transaction.
 func (s *SQLStorage) UpdateUser(ctx context.Context, user *models.User) (*models.User, error) {
 	const op = "sqlstorage.UpdateUser"
-	query, args, err := sq.Update("users").SetMap(sq.Eq{
-		"name":    user.Name,
-		"surname": user.Surname,
-		"avatar":  user.Avatar,
-	}).Where(sq.Eq{"id": user.ID}).Suffix("RETURNING id, name, surname, avatar").ToSql()
+
+	var updated models.User
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		before, err := s.GetUserByID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		query, args, err := sq.Update("users").SetMap(sq.Eq{
+			"name":    user.Name,
+			"surname": user.Surname,
+			"avatar":  user.Avatar,
+		}).Where(sq.Eq{"id": user.ID}).Suffix("RETURNING id, name, surname, avatar").ToSql()
+		if err != nil {
+			return fmt.Errorf("build query: %w", err)
+		}
+		stmt, err := s.conn(ctx).PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		row := stmt.QueryRowContext(ctx, args...)
+
+		if err := row.Scan(&updated.ID, &updated.Name, &updated.Surname, &updated.Avatar); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("user not found: %w", storage.ErrUserNotFound)
+			}
+			return err
+		}
+
+		event, changed := profileUpdate(before, &updated)
+		if !changed {
+			return nil
+		}
+		return s.saveEvent(ctx, events.TypeUserProfileUpdated, event)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &updated, nil
+}
+
+func (s *SQLStorage) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	const op = "sqlstorage.CreateUser"
+
+	query, args, err := sq.Insert("users").Columns("id", "name", "surname", "avatar").
+		Values(user.ID, user.Name, user.Surname, user.Avatar).
+		Suffix("ON CONFLICT (id) DO NOTHING RETURNING id, name, surname, avatar").
+		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: build query: %w", op, err)
 	}
-	stmt, err := s.db.PrepareContext(ctx, query)
+	stmt, err := s.conn(ctx).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 	row := stmt.QueryRowContext(ctx, args...)
-
 	var u models.User
 	if err := row.Scan(&u.ID, &u.Name, &u.Surname, &u.Avatar); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("%s: user not found: %w", op, storage.ErrUserNotFound)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrUserAlreadyExists)
 		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return &u, nil
 }
 
-func (s *SQLStorage) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
-	const op = "sqlstorage.CreateUser"
+// GetOrgUserByID returns the user only if it is a member of the
+// organization, so profile lookups can't cross tenants.
+func (s *SQLStorage) GetOrgUserByID(ctx context.Context, orgID int64, userID int64) (*models.User, error) {
+	const op = "sqlstorage.GetOrgUserByID"
 
-	query, args, err := sq.Insert("users").Columns("id", "name", "surname", "avatar").
-		Values(user.ID, user.Name, user.Surname, user.Avatar).
-		Suffix("ON CONFLICT (id) DO NOTHING RETURNING id, name, surname, avatar").
+	query, args, err := sq.Select("users.id", "users.name", "users.surname", "users.avatar").
+		From("users").
+		Join("memberships ON memberships.user_id = users.id").
+		Where(sq.Eq{"memberships.org_id": orgID, "users.id": userID}).
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: build query: %w", op, err)
 	}
-	stmt, err := s.db.PrepareContext(ctx, query)
+
+	stmt, err := s.conn(ctx).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 	row := stmt.QueryRowContext(ctx, args...)
+
 	var u models.User
 	if err := row.Scan(&u.ID, &u.Name, &u.Surname, &u.Avatar); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("%s: %w", op, storage.ErrUserAlreadyExists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: user not found: %w", op, storage.ErrUserNotFound)
 		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return &u, nil
 }
+
+func (s *SQLStorage) AddMembership(ctx context.Context, orgID int64, userID int64) error {
+	const op = "sqlstorage.AddMembership"
+
+	query, args, err := sq.Insert("memberships").Columns("org_id", "user_id").
+		Values(orgID, userID).
+		Suffix("ON CONFLICT (org_id, user_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: build query: %w", op, err)
+	}
+	stmt, err := s.conn(ctx).PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
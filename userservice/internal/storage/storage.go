@@ -9,10 +9,14 @@ import (
 var (
 	ErrUserNotFound      = errors.New("user not found in storage by id")
 	ErrUserAlreadyExists = errors.New("user already exists in storage with given id")
+	ErrEventProcessed    = errors.New("event already processed")
+	ErrNoNewEvents       = errors.New("no new events in outbox")
 )
 
 type Storage interface {
 	GetUserByID(ctx context.Context, userID int64) (*models.User, error)
 	UpdateUser(ctx context.Context, user *models.User) (*models.User, error)
 	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
+	GetOrgUserByID(ctx context.Context, orgID int64, userID int64) (*models.User, error)
+	AddMembership(ctx context.Context, orgID int64, userID int64) error
 }
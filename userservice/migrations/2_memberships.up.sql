@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS memberships (
+    org_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    PRIMARY KEY (org_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_memberships_user_id ON memberships(user_id);
@@ -0,0 +1,3 @@
+-- The trace context of the request that wrote the event, published in the
+-- traceparent header.
+ALTER TABLE outbox ADD COLUMN trace_parent TEXT NOT NULL DEFAULT '';